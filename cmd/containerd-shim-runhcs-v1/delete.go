@@ -8,6 +8,7 @@ import (
 	"os"
 	"time"
 
+	"github.com/Microsoft/hcsshim/internal/artifacts"
 	"github.com/Microsoft/hcsshim/internal/hcs"
 	"github.com/Microsoft/hcsshim/internal/oc"
 	"github.com/containerd/containerd/runtime/v2/task"
@@ -82,6 +83,14 @@ The delete command will be executed in the container's bundle as its cwd.
 			}
 		}
 
+		// Remove any artifacts the shim created outside the bundle (e.g. a
+		// nested uVM scratch folder under a layer folder) that a crashed
+		// shim never got to release itself. Best effort: a failure here
+		// shouldn't block removing the bundle below.
+		if err := artifacts.CleanupAll(ctx, bundleFlag); err != nil {
+			fmt.Fprintf(os.Stderr, "failed to clean up tracked artifacts for '%s': %v", idFlag, err)
+		}
+
 		// Remove the bundle on disk
 		if err := os.RemoveAll(bundleFlag); err != nil && !os.IsNotExist(err) {
 			return err
@@ -7,6 +7,7 @@ import (
 	"github.com/Microsoft/hcsshim/internal/oc"
 	"github.com/containerd/containerd/namespaces"
 	shim "github.com/containerd/containerd/runtime/v2/shim"
+	"github.com/containerd/typeurl"
 	"go.opencensus.io/trace"
 )
 
@@ -14,6 +15,24 @@ type publisher interface {
 	publishEvent(ctx context.Context, topic string, event interface{}) (err error)
 }
 
+// containerReadinessEventTopic is published once a container's
+// readiness probe (see oci.AnnotationContainerReadinessProbeExec) first
+// succeeds. It has no containerd-defined eventtypes counterpart, so unlike
+// the runtime.Task*EventTopic events it carries a package-local payload
+// type rather than one from containerd/api/events; since that type is not a
+// proto message, typeurl marshals it as JSON, which is all a struct this
+// simple needs.
+const containerReadinessEventTopic = "/tasks/container-ready"
+
+type containerReadinessEvent struct {
+	ContainerID string
+}
+
+func init() {
+	// typeurl.MarshalAny only accepts registered types for non-proto values.
+	typeurl.Register(&containerReadinessEvent{}, "hcsshim", "containerReadinessEvent")
+}
+
 type eventPublisher struct {
 	remotePublisher *shim.RemoteEventsPublisher
 }
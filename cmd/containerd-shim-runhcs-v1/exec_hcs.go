@@ -9,7 +9,9 @@ import (
 	"github.com/Microsoft/hcsshim/internal/cmd"
 	"github.com/Microsoft/hcsshim/internal/cow"
 	"github.com/Microsoft/hcsshim/internal/guestrequest"
+	"github.com/Microsoft/hcsshim/internal/jobobject"
 	"github.com/Microsoft/hcsshim/internal/log"
+	"github.com/Microsoft/hcsshim/internal/readiness"
 	"github.com/Microsoft/hcsshim/internal/signals"
 	"github.com/Microsoft/hcsshim/internal/uvm"
 	"github.com/Microsoft/hcsshim/osversion"
@@ -37,9 +39,13 @@ const (
 )
 
 // newHcsExec creates an exec to track the lifetime of `spec` in `c` which is
-// actually created on the call to `Start()`. If `id==tid` then this is the init
+// actually created on the call to `Start()`. If `id==tid` then this is an init
 // exec and the exec will also start `c` on the call to `Start()` before execing
-// the process `spec.Process`.
+// the process `spec.Process` -- unless isRestart is also true, in which case
+// `c` is already running (this is a restartInit replacement for a crashed
+// init; see hcsTask.restartInit) and Start must not start it again or publish
+// another init-start event. readinessProbeExec and readinessProbeInterval are
+// only meaningful for an init exec; see oci.AnnotationContainerReadinessProbeExec.
 func newHcsExec(
 	ctx context.Context,
 	events publisher,
@@ -49,29 +55,39 @@ func newHcsExec(
 	id, bundle string,
 	isWCOW bool,
 	spec *specs.Process,
-	io cmd.UpstreamIO) shimExec {
+	io cmd.UpstreamIO,
+	execLimits *jobobject.JobLimits,
+	readinessProbeExec string,
+	readinessProbeInterval time.Duration,
+	isRestart bool) shimExec {
 	log.G(ctx).WithFields(logrus.Fields{
-		"tid":    tid,
-		"eid":    id, // Init exec ID is always same as Task ID
-		"bundle": bundle,
-		"wcow":   isWCOW,
+		"tid":       tid,
+		"eid":       id, // Init exec ID is always same as Task ID
+		"bundle":    bundle,
+		"wcow":      isWCOW,
+		"isRestart": isRestart,
 	}).Debug("newHcsExec")
 
 	he := &hcsExec{
-		events:      events,
-		tid:         tid,
-		host:        host,
-		c:           c,
-		id:          id,
-		bundle:      bundle,
-		isWCOW:      isWCOW,
-		spec:        spec,
-		io:          io,
-		processDone: make(chan struct{}),
-		state:       shimExecStateCreated,
-		exitStatus:  255, // By design for non-exited process status.
-		exited:      make(chan struct{}),
+		events:                 events,
+		tid:                    tid,
+		host:                   host,
+		c:                      c,
+		id:                     id,
+		bundle:                 bundle,
+		isWCOW:                 isWCOW,
+		spec:                   spec,
+		io:                     io,
+		execLimits:             execLimits,
+		readinessProbeExec:     readinessProbeExec,
+		readinessProbeInterval: readinessProbeInterval,
+		isRestart:              isRestart,
+		processDone:            make(chan struct{}),
+		state:                  shimExecStateCreated,
+		exitStatus:             255, // By design for non-exited process status.
+		exited:                 make(chan struct{}),
 	}
+	globalExecHistory.record(tid, id, execHistoryEventCreated, 0, nil)
 	go he.waitForContainerExit()
 	return he
 }
@@ -118,7 +134,35 @@ type hcsExec struct {
 	// create time in order to be valid.
 	//
 	// This MUST be treated as read only in the lifetime of the exec.
-	io              cmd.UpstreamIO
+	io cmd.UpstreamIO
+	// execLimits, if non-nil, is the job object this exec's process is
+	// assigned to on Start, in addition to whatever job the container's
+	// own process is already in. It is only applied for a true exec
+	// (`id != tid`) of a process-isolated WCOW container (`host == nil`), and
+	// is ignored otherwise; see oci.AnnotationContainerExecMemoryLimitInMB
+	// for why.
+	//
+	// This MUST be treated as read only in the lifetime of the exec.
+	execLimits *jobobject.JobLimits
+	// readinessProbeExec, if non-empty, is a shell command execed repeatedly
+	// inside the init exec's container, every readinessProbeInterval, until it
+	// exits zero; see oci.AnnotationContainerReadinessProbeExec. Only ever set
+	// for the init exec (`id == tid`).
+	//
+	// This MUST be treated as read only in the lifetime of the exec.
+	readinessProbeExec     string
+	readinessProbeInterval time.Duration
+	// isRestart is true if this exec is a restartInit replacement for a
+	// crashed init exec (see hcsTask.restartInit), rather than the
+	// container's original init. Its id equals tid exactly like the
+	// original init, but since the container itself is already running,
+	// Start must not call c.Start again, and must not publish another
+	// TaskStart -- containerd saw exactly one TaskStart for this task and
+	// has no concept of its init process restarting in place. Only ever
+	// set for an init exec (`id == tid`).
+	//
+	// This MUST be treated as read only in the lifetime of the exec.
+	isRestart       bool
 	processDone     chan struct{}
 	processDoneOnce sync.Once
 
@@ -130,6 +174,10 @@ type hcsExec struct {
 	exitStatus uint32
 	exitedAt   time.Time
 	p          *cmd.Cmd
+	// job is the job object created for `execLimits`, if any. Only ever
+	// set from Start, and only ever read/closed from waitForExit, so it
+	// needs no lock of its own.
+	job *jobobject.JobObject
 
 	// exited is a wait block which waits async for the process to exit.
 	exited     chan struct{}
@@ -189,11 +237,13 @@ func (he *hcsExec) Start(ctx context.Context) (err error) {
 	}
 	defer func() {
 		if err != nil {
-			he.exitFromCreatedL(ctx, 1)
+			he.exitFromCreatedL(ctx, 1, err)
 		}
 	}()
-	if he.id == he.tid {
-		// This is the init exec. We need to start the container itself
+	if he.id == he.tid && !he.isRestart {
+		// This is the container's original init exec. We need to start the
+		// container itself. A restartInit replacement skips this -- the
+		// container is already running.
 		err = he.c.Start(ctx)
 		if err != nil {
 			return err
@@ -230,10 +280,23 @@ func (he *hcsExec) Start(ctx context.Context) (err error) {
 	// Assign the PID and transition the state.
 	he.pid = he.p.Process.Pid()
 	he.state = shimExecStateRunning
+	globalExecHistory.record(he.tid, he.id, execHistoryEventStarted, 0, nil)
+
+	if he.execLimits != nil && he.host == nil && he.id != he.tid {
+		// A process-isolated WCOW exec: the PID above is host-visible, so we
+		// can put it in a job object of its own, nested inside whatever job
+		// the container's own process is already in, without the
+		// container's own limits needing to change.
+		if err = he.applyExecLimitsL(ctx); err != nil {
+			he.p.Process.Kill(ctx)
+			return err
+		}
+	}
 
 	// Publish the task/exec start event. This MUST happen before waitForExit to
 	// avoid publishing the exit previous to the start.
-	if he.id != he.tid {
+	switch {
+	case he.id != he.tid:
 		he.events.publishEvent(
 			ctx,
 			runtime.TaskExecStartedEventTopic,
@@ -242,7 +305,7 @@ func (he *hcsExec) Start(ctx context.Context) (err error) {
 				ExecID:      he.id,
 				Pid:         uint32(he.pid),
 			})
-	} else {
+	case !he.isRestart:
 		he.events.publishEvent(
 			ctx,
 			runtime.TaskStartEventTopic,
@@ -250,6 +313,17 @@ func (he *hcsExec) Start(ctx context.Context) (err error) {
 				ContainerID: he.tid,
 				Pid:         uint32(he.pid),
 			})
+		if he.readinessProbeExec != "" {
+			go he.waitForReady()
+		}
+	default:
+		// A restartInit replacement: containerd already has this task's one
+		// TaskStart from the original init and has no concept of an init
+		// process restarting in place, so publish nothing here. Still
+		// re-arm the readiness probe against the fresh process.
+		if he.readinessProbeExec != "" {
+			go he.waitForReady()
+		}
 	}
 
 	// wait in the background for the exit.
@@ -257,12 +331,96 @@ func (he *hcsExec) Start(ctx context.Context) (err error) {
 	return nil
 }
 
+// waitForReady runs he.readinessProbeExec to completion (see
+// readiness.Wait) and publishes containerReadinessEventTopic once it
+// succeeds. It gives up silently once he.processDone closes, since there is
+// no longer a container to probe.
+func (he *hcsExec) waitForReady() {
+	ctx, span := trace.StartSpan(context.Background(), "hcsExec::waitForReady")
+	defer span.End()
+	span.AddAttributes(trace.StringAttribute("tid", he.tid))
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	go func() {
+		select {
+		case <-he.processDone:
+			cancel()
+		case <-ctx.Done():
+		}
+	}()
+
+	if err := readiness.Wait(ctx, he.c, he.readinessProbeExec, he.readinessProbeInterval); err != nil {
+		log.G(ctx).WithError(err).Debug("readiness probe did not succeed before container exited")
+		return
+	}
+	he.events.publishEvent(ctx, containerReadinessEventTopic, &containerReadinessEvent{ContainerID: he.tid})
+}
+
+// applyExecLimitsL creates a job object from `he.execLimits` and assigns
+// `he.p.Process`'s PID to it. The caller MUST hold `he.sl` and MUST only call
+// this once the process has actually started.
+func (he *hcsExec) applyExecLimitsL(ctx context.Context) error {
+	notify := he.execLimits.NotifyMemoryLimitInBytes != 0
+	job, err := jobobject.Create(ctx, "", notify)
+	if err != nil {
+		return errors.Wrapf(err, "exec: '%s' in task: '%s' failed to create exec job object", he.id, he.tid)
+	}
+	if err := job.SetResourceLimits(he.execLimits); err != nil {
+		job.Close()
+		return errors.Wrapf(err, "exec: '%s' in task: '%s' failed to set exec job object limits", he.id, he.tid)
+	}
+	if err := job.Assign(uint32(he.pid)); err != nil {
+		job.Close()
+		return errors.Wrapf(err, "exec: '%s' in task: '%s' failed to assign exec to job object", he.id, he.tid)
+	}
+	he.job = job
+	if notify {
+		go he.watchExecJobNotificationsL(ctx)
+	}
+	return nil
+}
+
+// watchExecJobNotificationsL polls `he.job` for notification-limit messages
+// and logs a warning with the violation detail every time the soft memory
+// threshold set by execLimits.NotifyMemoryLimitInBytes is crossed. It returns
+// once the job's notification queue is closed, which happens when `he.job` is
+// closed in waitForExit.
+func (he *hcsExec) watchExecJobNotificationsL(ctx context.Context) {
+	for {
+		msg, err := he.job.PollNotification()
+		if err != nil {
+			// The only time this returns an error is when the queue has been
+			// closed out from under us, which happens once the exec's process
+			// has exited and its job object has been cleaned up.
+			return
+		}
+		if _, ok := msg.(jobobject.MsgNotificationLimit); !ok {
+			continue
+		}
+		violation, err := he.job.QueryLimitViolation()
+		if err != nil {
+			log.G(ctx).WithError(err).WithFields(logrus.Fields{
+				"tid": he.tid,
+				"eid": he.id,
+			}).Warn("failed to query exec job object limit violation")
+			continue
+		}
+		log.G(ctx).WithFields(logrus.Fields{
+			"tid":            he.tid,
+			"eid":            he.id,
+			"jobMemory":      violation.JobMemory,
+			"jobMemoryLimit": violation.JobMemoryLimit,
+		}).Warn("exec job object soft memory limit exceeded")
+	}
+}
+
 func (he *hcsExec) Kill(ctx context.Context, signal uint32) error {
 	he.sl.Lock()
 	defer he.sl.Unlock()
 	switch he.state {
 	case shimExecStateCreated:
-		he.exitFromCreatedL(ctx, 1)
+		he.exitFromCreatedL(ctx, 1, nil)
 		return nil
 	case shimExecStateRunning:
 		supported := false
@@ -342,7 +500,7 @@ func (he *hcsExec) ForceExit(ctx context.Context, status int) {
 	if he.state != shimExecStateExited {
 		switch he.state {
 		case shimExecStateCreated:
-			he.exitFromCreatedL(ctx, status)
+			he.exitFromCreatedL(ctx, status, nil)
 		case shimExecStateRunning:
 			// Kill the process to unblock `he.waitForExit`
 			he.p.Process.Kill(ctx)
@@ -360,7 +518,7 @@ func (he *hcsExec) ForceExit(ctx context.Context, status int) {
 // To transition for a created state the following must be done:
 //
 // 1. Issue `he.processDoneCancel` to unblock the goroutine
-// `he.waitForContainerExit()``.
+// `he.waitForContainerExit()“.
 //
 // 2. Set `he.state`, `he.exitStatus` and `he.exitedAt` to the exited values.
 //
@@ -371,7 +529,7 @@ func (he *hcsExec) ForceExit(ctx context.Context, status int) {
 //
 // We DO NOT send the async `TaskExit` event because we never would have sent
 // the `TaskStart`/`TaskExecStarted` event.
-func (he *hcsExec) exitFromCreatedL(ctx context.Context, status int) {
+func (he *hcsExec) exitFromCreatedL(ctx context.Context, status int, exitErr error) {
 	if he.state != shimExecStateExited {
 		// Avoid logging the force if we already exited gracefully
 		log.G(ctx).WithField("status", status).Debug("hcsExec::exitFromCreatedL")
@@ -384,6 +542,7 @@ func (he *hcsExec) exitFromCreatedL(ctx context.Context, status int) {
 		he.exitedAt = time.Now()
 		// Release all upstream IO connections (if any)
 		he.io.Close(ctx)
+		globalExecHistory.record(he.tid, he.id, execHistoryEventExited, uint32(status), exitErr)
 		// Free any waiters
 		he.exitedOnce.Do(func() {
 			close(he.exited)
@@ -421,9 +580,9 @@ func (he *hcsExec) waitForExit() {
 		trace.StringAttribute("tid", he.tid),
 		trace.StringAttribute("eid", he.id))
 
-	err := he.p.Process.Wait()
-	if err != nil {
-		log.G(ctx).WithError(err).Error("failed process Wait")
+	waitErr := he.p.Process.Wait()
+	if waitErr != nil {
+		log.G(ctx).WithError(waitErr).Error("failed process Wait")
 	}
 
 	// Issue the process cancellation to unblock the container wait as early as
@@ -443,6 +602,18 @@ func (he *hcsExec) waitForExit() {
 	he.exitedAt = time.Now()
 	he.sl.Unlock()
 
+	// Record whichever of the two errors above is non-nil as the exec's
+	// last-known error; a failed Wait is the more actionable of the two.
+	historyErr := waitErr
+	if historyErr == nil {
+		historyErr = err
+	}
+	globalExecHistory.record(he.tid, he.id, execHistoryEventExited, uint32(code), historyErr)
+
+	if he.job != nil {
+		he.job.Close()
+	}
+
 	// Wait for all IO copies to complete and free the resources.
 	he.p.Wait()
 	he.io.Close(ctx)
@@ -493,7 +664,7 @@ func (he *hcsExec) waitForContainerExit() {
 		he.sl.Lock()
 		switch he.state {
 		case shimExecStateCreated:
-			he.exitFromCreatedL(ctx, 1)
+			he.exitFromCreatedL(ctx, 1, nil)
 		case shimExecStateRunning:
 			// Kill the process to unblock `he.waitForExit`.
 			he.p.Process.Kill(ctx)
@@ -0,0 +1,144 @@
+package main
+
+import (
+	"context"
+	"io"
+	"testing"
+
+	"github.com/Microsoft/hcsshim/internal/cmd"
+	"github.com/Microsoft/hcsshim/internal/cow"
+	"github.com/Microsoft/hcsshim/internal/schema1"
+	hcsschema "github.com/Microsoft/hcsshim/internal/schema2"
+	eventstypes "github.com/containerd/containerd/api/events"
+	specs "github.com/opencontainers/runtime-spec/specs-go"
+)
+
+// fakeCowContainer is a minimal cow.Container whose CreateProcess returns a
+// process that has already exited, so hcsExec.Start/waitForExit can run to
+// completion without a real HCS container. Its Wait never returns, as for a
+// container that is still running, so hcsExec.waitForContainerExit cannot
+// force an exec out of the created state before Start runs. It records
+// whether Start was called, since restartInit must not call it a second time.
+type fakeCowContainer struct {
+	id         string
+	startCalls int
+}
+
+var _ = (cow.Container)(&fakeCowContainer{})
+
+func (f *fakeCowContainer) OS() string  { return "windows" }
+func (f *fakeCowContainer) IsOCI() bool { return false }
+func (f *fakeCowContainer) CreateProcess(ctx context.Context, _ interface{}) (cow.Process, error) {
+	return newFakeCowProcess(), nil
+}
+func (f *fakeCowContainer) Close() error { return nil }
+func (f *fakeCowContainer) ID() string   { return f.id }
+func (f *fakeCowContainer) Properties(ctx context.Context, _ ...schema1.PropertyType) (*schema1.ContainerProperties, error) {
+	return &schema1.ContainerProperties{}, nil
+}
+func (f *fakeCowContainer) PropertiesV2(ctx context.Context, _ ...hcsschema.PropertyType) (*hcsschema.Properties, error) {
+	return &hcsschema.Properties{}, nil
+}
+func (f *fakeCowContainer) Start(ctx context.Context) error {
+	f.startCalls++
+	return nil
+}
+func (f *fakeCowContainer) Shutdown(ctx context.Context) error              { return nil }
+func (f *fakeCowContainer) Terminate(ctx context.Context) error             { return nil }
+func (f *fakeCowContainer) Wait() error                                     { select {} }
+func (f *fakeCowContainer) Modify(ctx context.Context, _ interface{}) error { return nil }
+
+// fakeCowProcess is a cow.Process that is already exited by the time it is
+// returned from CreateProcess.
+type fakeCowProcess struct {
+	done chan struct{}
+}
+
+var _ = (cow.Process)(&fakeCowProcess{})
+
+func newFakeCowProcess() *fakeCowProcess {
+	p := &fakeCowProcess{done: make(chan struct{})}
+	close(p.done)
+	return p
+}
+
+func (p *fakeCowProcess) Close() error                         { return nil }
+func (p *fakeCowProcess) CloseStdin(ctx context.Context) error { return nil }
+func (p *fakeCowProcess) Pid() int                             { return 1 }
+func (p *fakeCowProcess) Stdio() (io.Writer, io.Reader, io.Reader) {
+	return nil, nil, nil
+}
+func (p *fakeCowProcess) ResizeConsole(ctx context.Context, width, height uint16) error {
+	return nil
+}
+func (p *fakeCowProcess) Kill(ctx context.Context) (bool, error) {
+	return true, nil
+}
+func (p *fakeCowProcess) Signal(ctx context.Context, options interface{}) (bool, error) {
+	return true, nil
+}
+func (p *fakeCowProcess) Wait() error {
+	<-p.done
+	return nil
+}
+func (p *fakeCowProcess) ExitCode() (int, error) {
+	return 0, nil
+}
+
+func Test_hcsExec_Start_RestartInPlace_DoesNotDuplicateInitEvents(t *testing.T) {
+	ctx := context.Background()
+	tid := t.Name()
+	events := newFakePublisher()
+	c := &fakeCowContainer{id: tid}
+	spec := &specs.Process{Args: []string{"cmd"}}
+
+	io1, err := cmd.NewUpstreamIO(ctx, tid, "", "", "", false)
+	if err != nil {
+		t.Fatalf("NewUpstreamIO failed: %v", err)
+	}
+	original := newHcsExec(ctx, events, tid, nil, c, tid, "", true, spec, io1, nil, "", 0, false)
+	if err := original.Start(ctx); err != nil {
+		t.Fatalf("original init Start failed: %v", err)
+	}
+	if c.startCalls != 1 {
+		t.Fatalf("expected container Start to be called once, got %d", c.startCalls)
+	}
+
+	io2, err := cmd.NewUpstreamIO(ctx, tid, "", "", "", false)
+	if err != nil {
+		t.Fatalf("NewUpstreamIO failed: %v", err)
+	}
+	replacement := newHcsExec(ctx, events, tid, nil, c, tid, "", true, spec, io2, nil, "", 0, true)
+	if err := replacement.Start(ctx); err != nil {
+		t.Fatalf("restart init Start failed: %v", err)
+	}
+	if c.startCalls != 1 {
+		t.Fatalf("expected container Start to still be called once after restart, got %d", c.startCalls)
+	}
+	if replacement.ID() != tid {
+		t.Fatalf("expected restarted exec to keep id %q, got %q", tid, replacement.ID())
+	}
+
+	<-replacement.(*hcsExec).exited
+
+	var starts, execStarts, exits int
+	for _, e := range events.getEvents() {
+		switch e.(type) {
+		case *eventstypes.TaskStart:
+			starts++
+		case *eventstypes.TaskExecStarted:
+			execStarts++
+		case *eventstypes.TaskExit:
+			exits++
+		}
+	}
+	if starts != 1 {
+		t.Fatalf("expected exactly one TaskStart event, got %d", starts)
+	}
+	if execStarts != 0 {
+		t.Fatalf("expected no TaskExecStarted events for a restarted init, got %d", execStarts)
+	}
+	if exits != 0 {
+		t.Fatalf("expected no TaskExit events published by the exec itself (task teardown owns that), got %d", exits)
+	}
+}
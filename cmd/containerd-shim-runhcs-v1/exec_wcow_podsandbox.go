@@ -30,6 +30,7 @@ func newWcowPodSandboxExec(ctx context.Context, events publisher, tid, bundle st
 		exitStatus: 255, // By design for non-exited process status.
 		exited:     make(chan struct{}),
 	}
+	globalExecHistory.record(tid, tid, execHistoryEventCreated, 0, nil)
 	return wpse
 }
 
@@ -129,6 +130,7 @@ func (wpse *wcowPodSandboxExec) Start(ctx context.Context) error {
 	// Transition the state
 	wpse.state = shimExecStateRunning
 	wpse.pid = 1 // Fake but init pid is always 1
+	globalExecHistory.record(wpse.tid, wpse.tid, execHistoryEventStarted, 0, nil)
 
 	// Publish the task start event. We mever have an exec for the WCOW
 	// PodSandbox.
@@ -151,6 +153,7 @@ func (wpse *wcowPodSandboxExec) Kill(ctx context.Context, signal uint32) error {
 		wpse.state = shimExecStateExited
 		wpse.exitStatus = 1
 		wpse.exitedAt = time.Now()
+		globalExecHistory.record(wpse.tid, wpse.tid, execHistoryEventExited, wpse.exitStatus, nil)
 		close(wpse.exited)
 		return nil
 	case shimExecStateRunning:
@@ -158,6 +161,7 @@ func (wpse *wcowPodSandboxExec) Kill(ctx context.Context, signal uint32) error {
 		wpse.state = shimExecStateExited
 		wpse.exitStatus = 0
 		wpse.exitedAt = time.Now()
+		globalExecHistory.record(wpse.tid, wpse.tid, execHistoryEventExited, wpse.exitStatus, nil)
 
 		// NOTE: We do not support a non `init` exec for this "fake" init
 		// process. Skip any exited event which will be sent by the task.
@@ -198,6 +202,7 @@ func (wpse *wcowPodSandboxExec) ForceExit(ctx context.Context, status int) {
 		wpse.state = shimExecStateExited
 		wpse.exitStatus = 1
 		wpse.exitedAt = time.Now()
+		globalExecHistory.record(wpse.tid, wpse.tid, execHistoryEventExited, wpse.exitStatus, nil)
 
 		// NOTE: We do not support a non `init` exec for this "fake" init
 		// process. Skip any exited event which will be sent by the task.
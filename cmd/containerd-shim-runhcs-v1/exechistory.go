@@ -0,0 +1,95 @@
+package main
+
+import (
+	"sync"
+	"time"
+)
+
+// execHistorySize is the number of lifecycle transitions retained by
+// execHistoryBuffer before the oldest entries are overwritten. Sized generously
+// enough to cover a burst of exec churn (e.g. a probe firing every few
+// seconds for the last several minutes) without holding an unbounded amount
+// of memory for the life of the shim.
+const execHistorySize = 512
+
+// execHistoryEvent identifies the kind of lifecycle transition an
+// execHistoryEntry records.
+type execHistoryEvent string
+
+const (
+	execHistoryEventCreated execHistoryEvent = "created"
+	execHistoryEventStarted execHistoryEvent = "started"
+	execHistoryEventExited  execHistoryEvent = "exited"
+)
+
+// execHistoryEntry records a single lifecycle transition of a task or exec,
+// so that it can be inspected after the fact without having debug logging
+// enabled at the time.
+type execHistoryEntry struct {
+	TaskID     string
+	ExecID     string
+	Event      execHistoryEvent
+	ExitStatus uint32
+	Err        string
+	Timestamp  time.Time
+}
+
+// execHistoryBuffer is a fixed-size ring buffer of execHistoryEntry shared by every
+// task and exec hosted by this shim, queried via the DiagExecHistory shimdiag
+// RPC.
+type execHistoryBuffer struct {
+	mu      sync.Mutex
+	entries [execHistorySize]execHistoryEntry
+	// next is the index the next recorded entry will be written to.
+	next int
+	// count is the number of valid entries in `entries`, capped at
+	// execHistorySize once the buffer has wrapped.
+	count int
+}
+
+var globalExecHistory execHistoryBuffer
+
+// record appends an entry to the ring buffer, overwriting the oldest entry
+// once the buffer is full.
+func (b *execHistoryBuffer) record(tid, eid string, event execHistoryEvent, exitStatus uint32, err error) {
+	entry := execHistoryEntry{
+		TaskID:     tid,
+		ExecID:     eid,
+		Event:      event,
+		ExitStatus: exitStatus,
+		Timestamp:  time.Now(),
+	}
+	if err != nil {
+		entry.Err = err.Error()
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.entries[b.next] = entry
+	b.next = (b.next + 1) % execHistorySize
+	if b.count < execHistorySize {
+		b.count++
+	}
+}
+
+// query returns every recorded entry matching `tid` and, if non-empty, `eid`,
+// oldest first. An empty `tid` matches every task.
+func (b *execHistoryBuffer) query(tid, eid string) []execHistoryEntry {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	var matched []execHistoryEntry
+	start := b.next - b.count
+	for i := 0; i < b.count; i++ {
+		idx := ((start+i)%execHistorySize + execHistorySize) % execHistorySize
+		e := b.entries[idx]
+		if tid != "" && e.TaskID != tid {
+			continue
+		}
+		if eid != "" && e.ExecID != eid {
+			continue
+		}
+		matched = append(matched, e)
+	}
+	return matched
+}
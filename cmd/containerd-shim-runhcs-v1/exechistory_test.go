@@ -0,0 +1,49 @@
+package main
+
+import (
+	"errors"
+	"testing"
+)
+
+func Test_execHistoryBuffer_query_filters(t *testing.T) {
+	var b execHistoryBuffer
+	b.record("task1", "task1", execHistoryEventCreated, 0, nil)
+	b.record("task1", "task1", execHistoryEventStarted, 0, nil)
+	b.record("task1", "task1", execHistoryEventExited, 1, errors.New("boom"))
+	b.record("task2", "exec2", execHistoryEventCreated, 0, nil)
+
+	all := b.query("", "")
+	if len(all) != 4 {
+		t.Fatalf("expected 4 entries, got %d", len(all))
+	}
+
+	task1Entries := b.query("task1", "")
+	if len(task1Entries) != 3 {
+		t.Fatalf("expected 3 entries for task1, got %d", len(task1Entries))
+	}
+	last := task1Entries[len(task1Entries)-1]
+	if last.Event != execHistoryEventExited || last.ExitStatus != 1 || last.Err != "boom" {
+		t.Fatalf("unexpected last entry for task1: %+v", last)
+	}
+
+	task2Entries := b.query("task2", "exec2")
+	if len(task2Entries) != 1 {
+		t.Fatalf("expected 1 entry for task2/exec2, got %d", len(task2Entries))
+	}
+
+	if len(b.query("nonexistent", "")) != 0 {
+		t.Fatal("expected no entries for an unknown task id")
+	}
+}
+
+func Test_execHistoryBuffer_query_wraps(t *testing.T) {
+	var b execHistoryBuffer
+	for i := 0; i < execHistorySize+10; i++ {
+		b.record("task1", "task1", execHistoryEventStarted, 0, nil)
+	}
+
+	entries := b.query("task1", "")
+	if len(entries) != execHistorySize {
+		t.Fatalf("expected the ring buffer to cap at %d entries, got %d", execHistorySize, len(entries))
+	}
+}
@@ -132,7 +132,26 @@ type Options struct {
 	// share_scratch specifies if we'd like to reuse scratch space between multiple containers.
 	// This currently only affects LCOW. The sandbox containers scratch space is re-used for all
 	// subsequent containers launched in the pod.
-	ShareScratch         bool     `protobuf:"varint,14,opt,name=share_scratch,json=shareScratch,proto3" json:"share_scratch,omitempty"`
+	ShareScratch bool `protobuf:"varint,14,opt,name=share_scratch,json=shareScratch,proto3" json:"share_scratch,omitempty"`
+	// default_environment_variables are "KEY=VALUE" entries appended to every
+	// container's process environment unless the container's spec already
+	// sets that key. Lets platform teams enforce node-level defaults for this
+	// runtime class without an external admission webhook.
+	DefaultEnvironmentVariables []string `protobuf:"bytes,15,rep,name=default_environment_variables,json=defaultEnvironmentVariables,proto3" json:"default_environment_variables,omitempty"`
+	// forced_annotations are "KEY=VALUE" entries set as annotations on every
+	// container's spec, overriding any value the container's spec already
+	// set for that key.
+	ForcedAnnotations []string `protobuf:"bytes,16,rep,name=forced_annotations,json=forcedAnnotations,proto3" json:"forced_annotations,omitempty"`
+	// default_mounts are "source=destination[,ro]" entries added as mounts to
+	// every container's spec, skipping any entry whose destination is
+	// already mounted by the container's own spec. ':' is deliberately not
+	// used as a separator since it appears in Windows drive-letter paths.
+	DefaultMounts []string `protobuf:"bytes,17,rep,name=default_mounts,json=defaultMounts,proto3" json:"default_mounts,omitempty"`
+	// allowed_device_classes, if non-empty, restricts which device names a
+	// container may request via the "io.microsoft.container.device.<name>"
+	// annotation prefix (see AnnotationContainerDeviceResolverPrefix) to this
+	// list. A container requesting a name not in this list fails to create.
+	AllowedDeviceClasses []string `protobuf:"bytes,18,rep,name=allowed_device_classes,json=allowedDeviceClasses,proto3" json:"allowed_device_classes,omitempty"`
 	XXX_NoUnkeyedLiteral struct{} `json:"-"`
 	XXX_unrecognized     []byte   `json:"-"`
 	XXX_sizecache        int32    `json:"-"`
@@ -395,6 +414,48 @@ func (m *Options) MarshalTo(dAtA []byte) (int, error) {
 		}
 		i++
 	}
+	if len(m.DefaultEnvironmentVariables) > 0 {
+		for _, s := range m.DefaultEnvironmentVariables {
+			dAtA[i] = 0x7a
+			i++
+			l = len(s)
+			i = encodeVarintRunhcs(dAtA, i, uint64(l))
+			i += copy(dAtA[i:], s)
+		}
+	}
+	if len(m.ForcedAnnotations) > 0 {
+		for _, s := range m.ForcedAnnotations {
+			dAtA[i] = 0x82
+			i++
+			dAtA[i] = 0x1
+			i++
+			l = len(s)
+			i = encodeVarintRunhcs(dAtA, i, uint64(l))
+			i += copy(dAtA[i:], s)
+		}
+	}
+	if len(m.DefaultMounts) > 0 {
+		for _, s := range m.DefaultMounts {
+			dAtA[i] = 0x8a
+			i++
+			dAtA[i] = 0x1
+			i++
+			l = len(s)
+			i = encodeVarintRunhcs(dAtA, i, uint64(l))
+			i += copy(dAtA[i:], s)
+		}
+	}
+	if len(m.AllowedDeviceClasses) > 0 {
+		for _, s := range m.AllowedDeviceClasses {
+			dAtA[i] = 0x92
+			i++
+			dAtA[i] = 0x1
+			i++
+			l = len(s)
+			i = encodeVarintRunhcs(dAtA, i, uint64(l))
+			i += copy(dAtA[i:], s)
+		}
+	}
 	if m.XXX_unrecognized != nil {
 		i += copy(dAtA[i:], m.XXX_unrecognized)
 	}
@@ -534,6 +595,30 @@ func (m *Options) Size() (n int) {
 	if m.ShareScratch {
 		n += 2
 	}
+	if len(m.DefaultEnvironmentVariables) > 0 {
+		for _, s := range m.DefaultEnvironmentVariables {
+			l = len(s)
+			n += 1 + l + sovRunhcs(uint64(l))
+		}
+	}
+	if len(m.ForcedAnnotations) > 0 {
+		for _, s := range m.ForcedAnnotations {
+			l = len(s)
+			n += 2 + l + sovRunhcs(uint64(l))
+		}
+	}
+	if len(m.DefaultMounts) > 0 {
+		for _, s := range m.DefaultMounts {
+			l = len(s)
+			n += 2 + l + sovRunhcs(uint64(l))
+		}
+	}
+	if len(m.AllowedDeviceClasses) > 0 {
+		for _, s := range m.AllowedDeviceClasses {
+			l = len(s)
+			n += 2 + l + sovRunhcs(uint64(l))
+		}
+	}
 	if m.XXX_unrecognized != nil {
 		n += len(m.XXX_unrecognized)
 	}
@@ -612,6 +697,10 @@ func (this *Options) String() string {
 		`DefaultContainerScratchSizeInGb:` + fmt.Sprintf("%v", this.DefaultContainerScratchSizeInGb) + `,`,
 		`DefaultVmScratchSizeInGb:` + fmt.Sprintf("%v", this.DefaultVmScratchSizeInGb) + `,`,
 		`ShareScratch:` + fmt.Sprintf("%v", this.ShareScratch) + `,`,
+		`DefaultEnvironmentVariables:` + fmt.Sprintf("%v", this.DefaultEnvironmentVariables) + `,`,
+		`ForcedAnnotations:` + fmt.Sprintf("%v", this.ForcedAnnotations) + `,`,
+		`DefaultMounts:` + fmt.Sprintf("%v", this.DefaultMounts) + `,`,
+		`AllowedDeviceClasses:` + fmt.Sprintf("%v", this.AllowedDeviceClasses) + `,`,
 		`XXX_unrecognized:` + fmt.Sprintf("%v", this.XXX_unrecognized) + `,`,
 		`}`,
 	}, "")
@@ -1007,6 +1096,134 @@ func (m *Options) Unmarshal(dAtA []byte) error {
 				}
 			}
 			m.ShareScratch = bool(v != 0)
+		case 15:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field DefaultEnvironmentVariables", wireType)
+			}
+			var stringLen uint64
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowRunhcs
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				stringLen |= uint64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			intStringLen := int(stringLen)
+			if intStringLen < 0 {
+				return ErrInvalidLengthRunhcs
+			}
+			postIndex := iNdEx + intStringLen
+			if postIndex < 0 {
+				return ErrInvalidLengthRunhcs
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.DefaultEnvironmentVariables = append(m.DefaultEnvironmentVariables, string(dAtA[iNdEx:postIndex]))
+			iNdEx = postIndex
+		case 16:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field ForcedAnnotations", wireType)
+			}
+			var stringLen uint64
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowRunhcs
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				stringLen |= uint64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			intStringLen := int(stringLen)
+			if intStringLen < 0 {
+				return ErrInvalidLengthRunhcs
+			}
+			postIndex := iNdEx + intStringLen
+			if postIndex < 0 {
+				return ErrInvalidLengthRunhcs
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.ForcedAnnotations = append(m.ForcedAnnotations, string(dAtA[iNdEx:postIndex]))
+			iNdEx = postIndex
+		case 17:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field DefaultMounts", wireType)
+			}
+			var stringLen uint64
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowRunhcs
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				stringLen |= uint64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			intStringLen := int(stringLen)
+			if intStringLen < 0 {
+				return ErrInvalidLengthRunhcs
+			}
+			postIndex := iNdEx + intStringLen
+			if postIndex < 0 {
+				return ErrInvalidLengthRunhcs
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.DefaultMounts = append(m.DefaultMounts, string(dAtA[iNdEx:postIndex]))
+			iNdEx = postIndex
+		case 18:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field AllowedDeviceClasses", wireType)
+			}
+			var stringLen uint64
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowRunhcs
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				stringLen |= uint64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			intStringLen := int(stringLen)
+			if intStringLen < 0 {
+				return ErrInvalidLengthRunhcs
+			}
+			postIndex := iNdEx + intStringLen
+			if postIndex < 0 {
+				return ErrInvalidLengthRunhcs
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.AllowedDeviceClasses = append(m.AllowedDeviceClasses, string(dAtA[iNdEx:postIndex]))
+			iNdEx = postIndex
 		default:
 			iNdEx = preIndex
 			skippy, err := skipRunhcs(dAtA[iNdEx:])
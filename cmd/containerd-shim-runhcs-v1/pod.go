@@ -6,8 +6,12 @@ import (
 	"os"
 	"path/filepath"
 	"sync"
+	"time"
 
+	"github.com/Microsoft/hcsshim/internal/admission"
+	"github.com/Microsoft/hcsshim/internal/artifacts"
 	"github.com/Microsoft/hcsshim/internal/hcsoci"
+	"github.com/Microsoft/hcsshim/internal/layers"
 	"github.com/Microsoft/hcsshim/internal/log"
 	"github.com/Microsoft/hcsshim/internal/oci"
 	"github.com/Microsoft/hcsshim/internal/uvm"
@@ -18,9 +22,15 @@ import (
 	"github.com/containerd/containerd/runtime/v2/task"
 	specs "github.com/opencontainers/runtime-spec/specs-go"
 	"github.com/pkg/errors"
-	"golang.org/x/sync/errgroup"
 )
 
+// shutdownTaskGracePeriod bounds how long KillTask waits for a workload task
+// to exit in response to a signal before moving on to the next task during a
+// pod-wide kill. CRI/containerd don't forward per-container grace periods
+// down to a single kill-all call, so this is an internal bound rather than a
+// caller-supplied one.
+const shutdownTaskGracePeriod = 5 * time.Second
+
 // shimPod represents the logical grouping of all tasks in a single set of
 // shared namespaces. The pod sandbox (container) is represented by the task
 // that matches the `shimPod.ID()`
@@ -84,9 +94,12 @@ func createPod(ctx context.Context, events publisher, req *task.CreateTaskReques
 	owner := filepath.Base(os.Args[0])
 	isWCOW := oci.IsWCOW(s)
 
-	var parent *uvm.UtilityVM
-	if oci.IsIsolated(s) {
-		// Create the UVM parent
+	// sharingKey, if non-empty, opts this pod into reusing a utility VM
+	// shared with any other pod that requests the same key instead of
+	// creating its own. See oci.SandboxUVMSharingKey.
+	sharingKey := oci.SandboxUVMSharingKey(s)
+
+	createParent := func() (*uvm.UtilityVM, error) {
 		opts, err := oci.SpecToUVMCreateOpts(ctx, s, fmt.Sprintf("%s@vm", req.ID), owner)
 		if err != nil {
 			return nil, err
@@ -94,10 +107,7 @@ func createPod(ctx context.Context, events publisher, req *task.CreateTaskReques
 		switch opts.(type) {
 		case *uvm.OptionsLCOW:
 			lopts := (opts).(*uvm.OptionsLCOW)
-			parent, err = uvm.CreateLCOW(ctx, lopts)
-			if err != nil {
-				return nil, err
-			}
+			return uvm.CreateLCOW(ctx, lopts)
 		case *uvm.OptionsWCOW:
 			wopts := (opts).(*uvm.OptionsWCOW)
 
@@ -109,22 +119,62 @@ func createPod(ctx context.Context, events publisher, req *task.CreateTaskReques
 			copy(layers, s.Windows.LayerFolders)
 
 			vmPath := filepath.Join(layers[layersLen-1], "vm")
-			err := os.MkdirAll(vmPath, 0)
-			if err != nil {
+			if err := os.MkdirAll(vmPath, 0); err != nil {
 				return nil, err
 			}
+			if err := artifacts.Track(req.Bundle, vmPath); err != nil {
+				log.G(ctx).WithError(err).WithField("path", vmPath).Warning("failed to track uVM scratch folder for cleanup")
+			}
 			layers[layersLen-1] = vmPath
 			wopts.LayerFolders = layers
 
-			parent, err = uvm.CreateWCOW(ctx, wopts)
+			return uvm.CreateWCOW(ctx, wopts)
+		}
+		return nil, errors.New("oci spec does not contain WCOW or LCOW spec")
+	}
+
+	var parent *uvm.UtilityVM
+	// ownsParentVM is `true` if this pod is the one that created `parent`
+	// (whether or not it is shared), and is therefore responsible for
+	// starting it. A pod that reused an existing shared VM must not start or
+	// close it -- the owning pod already did, and other pods may still be
+	// using it.
+	ownsParentVM := true
+	if oci.IsIsolated(s) {
+		// Reject or delay this pod before spending the time/resources to
+		// create (or acquire) its uVM if the host doesn't have the headroom
+		// the pod asked for. This only runs for isolated pods since those
+		// are the ones that can cause a new uVM to be created; a pod that
+		// shares an already-running uVM doesn't add hypervisor overcommit.
+		if err := admission.Check(ctx, s); err != nil {
+			return nil, err
+		}
+		if sharingKey != "" {
+			parent, ownsParentVM, err = uvm.AcquireSharedUVM(sharingKey, createParent)
+		} else {
+			parent, err = createParent()
+		}
+		if err != nil {
+			return nil, err
+		}
+		if ownsParentVM {
+			err = parent.Start(ctx)
 			if err != nil {
+				if sharingKey != "" {
+					uvm.ReleaseSharedUVM(sharingKey)
+				} else {
+					parent.Close()
+				}
 				return nil, err
 			}
 		}
-		err = parent.Start(ctx)
-		if err != nil {
-			parent.Close()
-			return nil, err
+		// Begin warming any layers the sandbox annotations hint at needing,
+		// ahead of the workload container's own CreateTask, so that work
+		// overlaps with everything else that happens between the sandbox
+		// and the first container (image pull completion, CNI setup, etc.)
+		// instead of happening serially after it.
+		if hints := oci.SandboxPrefetchLayers(s); len(hints) > 0 {
+			layers.PrefetchLayers(parent, hints)
 		}
 	} else if !isWCOW {
 		return nil, errors.Wrap(errdefs.ErrFailedPrecondition, "oci spec does not contain WCOW or LCOW spec")
@@ -132,7 +182,11 @@ func createPod(ctx context.Context, events publisher, req *task.CreateTaskReques
 	defer func() {
 		// clean up the uvm if we fail any further operations
 		if err != nil && parent != nil {
-			parent.Close()
+			if sharingKey != "" {
+				uvm.ReleaseSharedUVM(sharingKey)
+			} else {
+				parent.Close()
+			}
 		}
 	}()
 
@@ -172,7 +226,7 @@ func createPod(ctx context.Context, events publisher, req *task.CreateTaskReques
 				}
 			}
 		}
-		p.sandboxTask = newWcowPodSandboxTask(ctx, events, req.ID, req.Bundle, parent)
+		p.sandboxTask = newWcowPodSandboxTask(ctx, events, req.ID, req.Bundle, parent, ownsParentVM, sharingKey)
 		// Publish the created event. We only do this for a fake WCOW task. A
 		// HCS Task will event itself based on actual process lifetime.
 		events.publishEvent(
@@ -200,7 +254,7 @@ func createPod(ctx context.Context, events publisher, req *task.CreateTaskReques
 		}
 		// LCOW (and WCOW Process Isolated for the time being) requires a real
 		// task for the sandbox.
-		lt, err := newHcsTask(ctx, events, parent, true, req, s)
+		lt, err := newHcsTask(ctx, events, parent, ownsParentVM, sharingKey, req, s)
 		if err != nil {
 			return nil, err
 		}
@@ -235,6 +289,22 @@ type pod struct {
 	// to release the lock to allow concurrent creates.
 	wcl           sync.Mutex
 	workloadTasks sync.Map
+	// creationOrder is the order workload task id's were added to
+	// `workloadTasks`, oldest first. It's used so a pod-wide kill can signal
+	// workload tasks in reverse creation order (newest first) ahead of the
+	// sandbox task, instead of signaling everything at once and racing the
+	// uVM's own teardown. It MUST only be accessed while holding `wcl`.
+	//
+	// Ephemeral containers (oci.IsEphemeralContainer) are deliberately left
+	// out of this: they have no dependents and nothing depends on them, so
+	// ordering them against the rest of the pod's shutdown doesn't apply.
+	// They're tracked separately in `ephemeralTasks` instead.
+	creationOrder []string
+	// ephemeralTasks is the set of task ids created with
+	// oci.AnnotationContainerEphemeral set, e.g. a `kubectl debug` container
+	// added to an already-running pod. A pod-wide kill signals these first,
+	// independent of `creationOrder`'s dependency-ordered sequence.
+	ephemeralTasks sync.Map
 }
 
 func (p *pod) ID() string {
@@ -283,11 +353,20 @@ func (p *pod) CreateTask(ctx context.Context, req *task.CreateTaskRequest, s *sp
 			sid)
 	}
 
-	st, err := newHcsTask(ctx, p.events, p.host, false, req, s)
+	st, err := newHcsTask(ctx, p.events, p.host, false, "", req, s)
 	if err != nil {
 		return nil, err
 	}
 
+	if oci.IsEphemeralContainer(s) {
+		log.G(ctx).WithField("tid", req.ID).Debug("creating ephemeral container in pod")
+		p.ephemeralTasks.Store(req.ID, struct{}{})
+	} else {
+		p.wcl.Lock()
+		p.creationOrder = append(p.creationOrder, req.ID)
+		p.wcl.Unlock()
+	}
+
 	p.workloadTasks.Store(req.ID, st)
 	return st, nil
 }
@@ -311,21 +390,62 @@ func (p *pod) KillTask(ctx context.Context, tid, eid string, signal uint32, all
 	if all && eid != "" {
 		return errors.Wrapf(errdefs.ErrFailedPrecondition, "cannot signal all with non empty ExecID: '%s'", eid)
 	}
-	eg := errgroup.Group{}
 	if all && tid == p.id {
-		// We are in a kill all on the sandbox task. Signal everything.
-		p.workloadTasks.Range(func(key, value interface{}) bool {
-			wt := value.(shimTask)
-			eg.Go(func() error {
-				return wt.KillExec(ctx, eid, signal, all)
-			})
-
-			// iterate all
-			return false
+		// Signal any ephemeral (e.g. `kubectl debug`) containers first and
+		// without waiting on them: they have no dependents, and nothing in
+		// the pod depends on them either, so they shouldn't hold up or be
+		// ordered against the rest of the shutdown sequence below.
+		p.ephemeralTasks.Range(func(k, _ interface{}) bool {
+			etid := k.(string)
+			raw, loaded := p.workloadTasks.Load(etid)
+			if !loaded {
+				return true
+			}
+			et := raw.(shimTask)
+			if err := et.KillExec(ctx, eid, signal, all); err != nil {
+				log.G(ctx).WithError(err).WithField("tid", etid).Warn("failed to signal ephemeral container during pod shutdown")
+			}
+			return true
 		})
+
+		// We are in a kill all on the sandbox task. Signal workload tasks
+		// individually in reverse creation order, giving each a bounded grace
+		// period to exit before moving on to the next, so that a workload
+		// task isn't cut off mid-shutdown by the sandbox/uVM tearing down out
+		// from under it. CRI doesn't give the shim a container dependency
+		// graph, so reverse creation order is the best approximation of
+		// dependency order available here.
+		p.wcl.Lock()
+		order := make([]string, len(p.creationOrder))
+		copy(order, p.creationOrder)
+		p.wcl.Unlock()
+
+		for i := len(order) - 1; i >= 0; i-- {
+			raw, loaded := p.workloadTasks.Load(order[i])
+			if !loaded {
+				continue
+			}
+			wt := raw.(shimTask)
+			if err := wt.KillExec(ctx, eid, signal, all); err != nil {
+				log.G(ctx).WithError(err).WithField("tid", order[i]).Warn("failed to signal workload task during pod shutdown")
+				continue
+			}
+			waitForTaskExit(wt, shutdownTaskGracePeriod)
+		}
+	}
+	return t.KillExec(ctx, eid, signal, all)
+}
+
+// waitForTaskExit waits for `t`'s init exec to exit, up to `timeout`. It
+// returns once either happens; it does not report which.
+func waitForTaskExit(t shimTask, timeout time.Duration) {
+	done := make(chan struct{})
+	go func() {
+		t.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(timeout):
 	}
-	eg.Go(func() error {
-		return t.KillExec(ctx, eid, signal, all)
-	})
-	return eg.Wait()
 }
@@ -13,6 +13,7 @@ import (
 
 	"github.com/Microsoft/go-winio"
 	runhcsopts "github.com/Microsoft/hcsshim/cmd/containerd-shim-runhcs-v1/options"
+	"github.com/Microsoft/hcsshim/internal/hang"
 	"github.com/Microsoft/hcsshim/internal/shimdiag"
 	"github.com/Microsoft/hcsshim/pkg/octtrpc"
 	"github.com/containerd/containerd/log"
@@ -29,6 +30,18 @@ import (
 
 var svc *service
 
+// hangPolicy is the per-RPC timeout policy applied to the shim's ttrpc
+// server (see internal/hang). `Create` and `Delete` can involve booting or
+// tearing down a whole utility VM, so they get a longer allowance than the
+// rest of the task lifecycle calls, which are expected to return quickly.
+var hangPolicy = &hang.Policy{
+	Default: 30 * time.Second,
+	Overrides: map[string]time.Duration{
+		"Create": 4 * time.Minute,
+		"Delete": time.Minute,
+	},
+}
+
 var serveCommand = cli.Command{
 	Name:           "serve",
 	Hidden:         true,
@@ -169,7 +182,7 @@ var serveCommand = cli.Command{
 			tid:       idFlag,
 			isSandbox: ctx.Bool("is-sandbox"),
 		}
-		s, err := ttrpc.NewServer(ttrpc.WithUnaryServerInterceptor(octtrpc.ServerInterceptor()))
+		s, err := ttrpc.NewServer(ttrpc.WithUnaryServerInterceptor(hang.NewUnaryServerInterceptor(hangPolicy, octtrpc.ServerInterceptor())))
 		if err != nil {
 			return err
 		}
@@ -8,9 +8,9 @@ import (
 	"sync/atomic"
 	"time"
 
+	"github.com/Microsoft/hcsshim/internal/errdefs"
 	"github.com/Microsoft/hcsshim/internal/oc"
 	"github.com/Microsoft/hcsshim/internal/shimdiag"
-	"github.com/containerd/containerd/errdefs"
 	"github.com/containerd/containerd/runtime/v2/task"
 	google_protobuf1 "github.com/gogo/protobuf/types"
 	"go.opencensus.io/trace"
@@ -23,6 +23,21 @@ type cdevent struct {
 
 var _ = (task.TaskService)(&service{})
 
+// service implements the Runtime V2 `task.TaskService`. Sandbox (POD)
+// lifecycle is inferred from this task API alone: whether `tid` denotes a POD
+// is passed in at `shim start`/`shim serve` via `--is-sandbox` (see
+// `isSandbox` below), and the POD is considered created/started/stopped in
+// lock-step with its sandbox task rather than through any dedicated sandbox
+// lifecycle calls.
+//
+// containerd's newer runtime/sandbox Controller API decouples sandbox
+// lifecycle from task lifecycle so it can be driven explicitly (create/start/
+// stop/platform) instead of inferred this way. Implementing that interface
+// here requires the corresponding generated ttrpc/proto types, which this
+// module's vendored containerd snapshot does not yet provide; until they're
+// vendored, `service` continues to serve only `task.TaskService` and
+// `shimdiag.ShimDiagService` (see serve.go) and infers sandbox state as
+// described above.
 type service struct {
 	events publisher
 	// tid is the original task id to be served. This can either be a single
@@ -49,6 +64,11 @@ type service struct {
 	// taken when creating tasks in a POD sandbox as they can happen
 	// concurrently.
 	cl sync.Mutex
+
+	// startContainerHooks holds each task's `spec.Hooks.StartContainer`,
+	// keyed by task id, from `Create` until `startInternal` runs them and
+	// until cleanup in `deleteInternal`. See runPreStartHooks.
+	startContainerHooks sync.Map
 }
 
 func (s *service) State(ctx context.Context, req *task.StateRequest) (resp *task.StateResponse, err error) {
@@ -306,6 +326,70 @@ func (s *service) DiagShare(ctx context.Context, req *shimdiag.ShareRequest) (_
 	return r, errdefs.ToGRPC(e)
 }
 
+func (s *service) DiagSetLogLevel(ctx context.Context, req *shimdiag.SetLogLevelRequest) (_ *shimdiag.SetLogLevelResponse, err error) {
+	defer panicRecover()
+	ctx, span := trace.StartSpan(ctx, "DiagSetLogLevel")
+	defer span.End()
+	defer func() { oc.SetSpanStatus(span, err) }()
+
+	span.AddAttributes(
+		trace.StringAttribute("level", req.Level),
+		trace.StringAttribute("categories", strings.Join(req.Categories, " ")))
+
+	if s.isSandbox {
+		span.AddAttributes(trace.StringAttribute("pod-id", s.tid))
+	}
+
+	r, e := s.diagSetLogLevelInternal(ctx, req)
+	return r, errdefs.ToGRPC(e)
+}
+
+func (s *service) DiagListDevices(ctx context.Context, req *shimdiag.DiagDevicesRequest) (_ *shimdiag.DiagDevicesResponse, err error) {
+	defer panicRecover()
+	ctx, span := trace.StartSpan(ctx, "DiagListDevices")
+	defer span.End()
+	defer func() { oc.SetSpanStatus(span, err) }()
+
+	if s.isSandbox {
+		span.AddAttributes(trace.StringAttribute("pod-id", s.tid))
+	}
+
+	r, e := s.diagListDevicesInternal(ctx, req)
+	return r, errdefs.ToGRPC(e)
+}
+
+func (s *service) DiagGuestInfo(ctx context.Context, req *shimdiag.DiagGuestInfoRequest) (_ *shimdiag.DiagGuestInfoResponse, err error) {
+	defer panicRecover()
+	ctx, span := trace.StartSpan(ctx, "DiagGuestInfo")
+	defer span.End()
+	defer func() { oc.SetSpanStatus(span, err) }()
+
+	if s.isSandbox {
+		span.AddAttributes(trace.StringAttribute("pod-id", s.tid))
+	}
+
+	r, e := s.diagGuestInfoInternal(ctx, req)
+	return r, errdefs.ToGRPC(e)
+}
+
+func (s *service) DiagExecHistory(ctx context.Context, req *shimdiag.DiagExecHistoryRequest) (_ *shimdiag.DiagExecHistoryResponse, err error) {
+	defer panicRecover()
+	ctx, span := trace.StartSpan(ctx, "DiagExecHistory")
+	defer span.End()
+	defer func() { oc.SetSpanStatus(span, err) }()
+
+	span.AddAttributes(
+		trace.StringAttribute("tid", req.TaskId),
+		trace.StringAttribute("eid", req.ExecId))
+
+	if s.isSandbox {
+		span.AddAttributes(trace.StringAttribute("pod-id", s.tid))
+	}
+
+	r, e := s.diagExecHistoryInternal(ctx, req)
+	return r, errdefs.ToGRPC(e)
+}
+
 func (s *service) ResizePty(ctx context.Context, req *task.ResizePtyRequest) (_ *google_protobuf1.Empty, err error) {
 	defer panicRecover()
 	ctx, span := trace.StartSpan(ctx, "ResizePty")
@@ -6,10 +6,14 @@ import (
 	"os"
 	"path/filepath"
 	"strings"
+	"time"
 
 	runhcsopts "github.com/Microsoft/hcsshim/cmd/containerd-shim-runhcs-v1/options"
+	"github.com/Microsoft/hcsshim/internal/hooks"
+	"github.com/Microsoft/hcsshim/internal/log"
 	"github.com/Microsoft/hcsshim/internal/oci"
 	"github.com/Microsoft/hcsshim/internal/shimdiag"
+	"github.com/Microsoft/hcsshim/internal/shimstate"
 	containerd_v1_types "github.com/containerd/containerd/api/types/task"
 	"github.com/containerd/containerd/errdefs"
 	"github.com/containerd/containerd/mount"
@@ -18,6 +22,7 @@ import (
 	google_protobuf1 "github.com/gogo/protobuf/types"
 	"github.com/opencontainers/runtime-spec/specs-go"
 	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
 )
 
 var empty = &google_protobuf1.Empty{}
@@ -26,7 +31,6 @@ var empty = &google_protobuf1.Empty{}
 // callers responsibility to verify that `s.isSandbox == true` before calling
 // this method.
 //
-//
 // If `pod==nil` returns `errdefs.ErrFailedPrecondition`.
 func (s *service) getPod() (shimPod, error) {
 	raw := s.taskOrPod.Load()
@@ -91,7 +95,8 @@ func (s *service) createInternal(ctx context.Context, req *task.CreateTaskReques
 	}
 	f.Close()
 
-	spec = oci.UpdateSpecFromOptions(spec, shimOpts)
+	spec = oci.UpdateSpecFromOptions(ctx, spec, shimOpts)
+	oci.ParseAnnotationsDeviceInterfaceClassGUIDs(&spec)
 
 	if len(req.Rootfs) == 0 {
 		// If no mounts are passed via the snapshotter its the callers full
@@ -148,6 +153,39 @@ func (s *service) createInternal(ctx context.Context, req *task.CreateTaskReques
 		return nil, errors.Wrap(errdefs.ErrFailedPrecondition, "if using terminal, stderr must be empty")
 	}
 
+	if err := oci.ValidateAnnotations(&spec); err != nil {
+		return nil, errors.Wrap(errdefs.ErrInvalidArgument, err.Error())
+	}
+
+	if err := oci.ValidateDeviceClassAllowlist(&spec, shimOpts); err != nil {
+		return nil, errors.Wrap(errdefs.ErrInvalidArgument, err.Error())
+	}
+
+	if err := oci.ValidateNetworkingModeNone(&spec); err != nil {
+		return nil, errors.Wrap(errdefs.ErrInvalidArgument, err.Error())
+	}
+
+	if r := oci.CheckConformance(&spec); r.HasDropped() {
+		// These fields are meaningful under a different isolation mode than
+		// the one this spec selected -- e.g. Process.Capabilities on a WCOW
+		// spec -- so the shim never read them and never will. Logging rather
+		// than failing here is deliberate: unlike the annotation/networking
+		// checks above, an unsupported field usually means the caller reused
+		// a spec built for a generic OCI runtime, not a genuine
+		// misconfiguration worth refusing the task for.
+		log.G(ctx).WithFields(logrus.Fields{
+			"tid":     req.ID,
+			"mode":    r.Mode,
+			"dropped": r.Dropped,
+		}).Warn("spec sets fields this isolation mode does not support; they will be ignored")
+	}
+
+	if spec.Hooks != nil {
+		if err := hooks.Run(ctx, spec.Hooks.CreateRuntime, ociState(req.ID, req.Bundle, "creating", 0)); err != nil {
+			return nil, errors.Wrap(err, "CreateRuntime hook failed")
+		}
+	}
+
 	resp := &task.CreateTaskResponse{}
 	s.cl.Lock()
 	if s.isSandbox {
@@ -161,6 +199,9 @@ func (s *service) createInternal(ctx context.Context, req *task.CreateTaskReques
 			}
 			e, _ := t.GetExec("")
 			resp.Pid = uint32(e.Pid())
+			if err := s.runPostCreateHooks(ctx, req, &spec, int(resp.Pid)); err != nil {
+				return nil, err
+			}
 			return resp, nil
 		}
 		pod, err = createPod(ctx, s.events, req, &spec)
@@ -172,6 +213,7 @@ func (s *service) createInternal(ctx context.Context, req *task.CreateTaskReques
 		e, _ := t.GetExec("")
 		resp.Pid = uint32(e.Pid())
 		s.taskOrPod.Store(pod)
+		saveShimState(ctx, req.Bundle, req.ID, true, t.HostID())
 	} else {
 		t, err := newHcsStandaloneTask(ctx, s.events, req, &spec)
 		if err != nil {
@@ -181,11 +223,68 @@ func (s *service) createInternal(ctx context.Context, req *task.CreateTaskReques
 		e, _ := t.GetExec("")
 		resp.Pid = uint32(e.Pid())
 		s.taskOrPod.Store(t)
+		saveShimState(ctx, req.Bundle, req.ID, false, t.HostID())
 	}
 	s.cl.Unlock()
+	if err := s.runPostCreateHooks(ctx, req, &spec, int(resp.Pid)); err != nil {
+		return nil, err
+	}
 	return resp, nil
 }
 
+// ociState builds the `specs.State` handed to OCI hooks (see the `hooks`
+// package) for the task identified by `id`.
+func ociState(id, bundle, status string, pid int) *specs.State {
+	return &specs.State{
+		Version: specs.Version,
+		ID:      id,
+		Status:  status,
+		Pid:     pid,
+		Bundle:  bundle,
+	}
+}
+
+// runPostCreateHooks runs `spec.Hooks.CreateContainer` (the "post-create"
+// integration point) for the task just created by `req`, and stashes
+// `spec.Hooks.StartContainer` (the "pre-start" point) for startInternal to
+// run once this task is started.
+func (s *service) runPostCreateHooks(ctx context.Context, req *task.CreateTaskRequest, spec *specs.Spec, pid int) error {
+	if spec.Hooks == nil {
+		return nil
+	}
+	if err := hooks.Run(ctx, spec.Hooks.CreateContainer, ociState(req.ID, req.Bundle, "created", pid)); err != nil {
+		return errors.Wrap(err, "CreateContainer hook failed")
+	}
+	if len(spec.Hooks.StartContainer) > 0 {
+		s.startContainerHooks.Store(req.ID, startContainerHooksEntry{bundle: req.Bundle, hooks: spec.Hooks.StartContainer})
+	}
+	return nil
+}
+
+// startContainerHooksEntry is what `service.startContainerHooks` stores per
+// task id.
+type startContainerHooksEntry struct {
+	bundle string
+	hooks  []specs.Hook
+}
+
+// saveShimState persists the bookkeeping a future shim process would need to
+// identify this task or POD after a restart. See the shimstate package for
+// the current limits of what that actually enables today.
+//
+// This is best effort: a failure to save shim state does not fail task
+// creation since nothing depends on the file yet.
+func saveShimState(ctx context.Context, bundle, tid string, isSandbox bool, hostID string) {
+	err := shimstate.Save(bundle, &shimstate.Snapshot{
+		TID:       tid,
+		IsSandbox: isSandbox,
+		HostID:    hostID,
+	})
+	if err != nil {
+		log.G(ctx).WithError(err).Warning("failed to save shim state")
+	}
+}
+
 func (s *service) startInternal(ctx context.Context, req *task.StartRequest) (*task.StartResponse, error) {
 	t, err := s.getTask(req.ID)
 	if err != nil {
@@ -195,6 +294,11 @@ func (s *service) startInternal(ctx context.Context, req *task.StartRequest) (*t
 	if err != nil {
 		return nil, err
 	}
+	if req.ExecID == "" {
+		if err := s.runPreStartHooks(ctx, req.ID, e.Pid()); err != nil {
+			return nil, err
+		}
+	}
 	err = e.Start(ctx)
 	if err != nil {
 		return nil, err
@@ -204,6 +308,18 @@ func (s *service) startInternal(ctx context.Context, req *task.StartRequest) (*t
 	}, nil
 }
 
+// runPreStartHooks runs `spec.Hooks.StartContainer` (the "pre-start"
+// integration point) for the init exec of the task `tid`, if it had any, as
+// stashed by runPostCreateHooks.
+func (s *service) runPreStartHooks(ctx context.Context, tid string, pid int) error {
+	v, ok := s.startContainerHooks.Load(tid)
+	if !ok {
+		return nil
+	}
+	entry := v.(startContainerHooksEntry)
+	return errors.Wrap(hooks.Run(ctx, entry.hooks, ociState(tid, entry.bundle, "created", pid)), "StartContainer hook failed")
+}
+
 func (s *service) deleteInternal(ctx context.Context, req *task.DeleteRequest) (*task.DeleteResponse, error) {
 	// TODO: JTERRY75 we need to send this to the POD for isSandbox
 
@@ -215,6 +331,9 @@ func (s *service) deleteInternal(ctx context.Context, req *task.DeleteRequest) (
 	if err != nil {
 		return nil, err
 	}
+	if req.ExecID == "" {
+		s.startContainerHooks.Delete(req.ID)
+	}
 	// TODO: We should be removing the task after this right?
 	return &task.DeleteResponse{
 		Pid:        uint32(pid),
@@ -347,6 +466,86 @@ func (s *service) diagShareInternal(ctx context.Context, req *shimdiag.ShareRequ
 	return &shimdiag.ShareResponse{}, nil
 }
 
+func (s *service) diagSetLogLevelInternal(ctx context.Context, req *shimdiag.SetLogLevelRequest) (*shimdiag.SetLogLevelResponse, error) {
+	t, err := s.getTask(s.tid)
+	if err != nil {
+		return nil, err
+	}
+	if err := t.SetGuestLogLevel(ctx, req); err != nil {
+		return nil, err
+	}
+	return &shimdiag.SetLogLevelResponse{}, nil
+}
+
+func (s *service) diagListDevicesInternal(ctx context.Context, req *shimdiag.DiagDevicesRequest) (*shimdiag.DiagDevicesResponse, error) {
+	t, err := s.getTask(s.tid)
+	if err != nil {
+		return nil, err
+	}
+	devices, err := t.ListDevices(ctx)
+	if err != nil {
+		return nil, err
+	}
+	resp := &shimdiag.DiagDevicesResponse{
+		Devices: make([]*shimdiag.DeviceInfo, len(devices)),
+	}
+	for i, d := range devices {
+		resp.Devices[i] = &shimdiag.DeviceInfo{
+			Kind:         d.Kind,
+			InstancePath: d.InstancePath,
+			Controller:   int32(d.Controller),
+			Lun:          d.LUN,
+			BackingFile:  d.BackingFile,
+		}
+	}
+	return resp, nil
+}
+
+func (s *service) diagGuestInfoInternal(ctx context.Context, req *shimdiag.DiagGuestInfoRequest) (*shimdiag.DiagGuestInfoResponse, error) {
+	t, err := s.getTask(s.tid)
+	if err != nil {
+		return nil, err
+	}
+	info, err := t.GuestInfo(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return &shimdiag.DiagGuestInfoResponse{
+		Os:                            info.OS,
+		ProtocolVersion:               info.ProtocolVersion,
+		SignalProcessSupported:        info.Capabilities.SignalProcessSupported,
+		DumpStacksSupported:           info.Capabilities.DumpStacksSupported,
+		DeleteContainerStateSupported: info.Capabilities.DeleteContainerStateSupported,
+		UpdateContainerSupported:      info.Capabilities.UpdateContainerSupported,
+		NamespaceAddRequestSupported:  info.Capabilities.NamespaceAddRequestSupported,
+	}, nil
+}
+
+// diagExecHistoryInternal reports recorded lifecycle transitions for tasks
+// and execs hosted by this shim.
+//
+// Unlike diagListDevicesInternal and diagGuestInfoInternal this does not
+// require a live task: the whole point of exec history is to be able to
+// explain what happened to an exec after it (and possibly its task) has
+// already exited and been removed from the shim's task list.
+func (s *service) diagExecHistoryInternal(ctx context.Context, req *shimdiag.DiagExecHistoryRequest) (*shimdiag.DiagExecHistoryResponse, error) {
+	entries := globalExecHistory.query(req.TaskId, req.ExecId)
+	resp := &shimdiag.DiagExecHistoryResponse{
+		Entries: make([]*shimdiag.ExecHistoryEntry, len(entries)),
+	}
+	for i, e := range entries {
+		resp.Entries[i] = &shimdiag.ExecHistoryEntry{
+			TaskId:     e.TaskID,
+			ExecId:     e.ExecID,
+			Event:      string(e.Event),
+			ExitStatus: e.ExitStatus,
+			Error:      e.Err,
+			Timestamp:  e.Timestamp.Format(time.RFC3339Nano),
+		}
+	}
+	return resp, nil
+}
+
 func (s *service) resizePtyInternal(ctx context.Context, req *task.ResizePtyRequest) (*google_protobuf1.Empty, error) {
 	t, err := s.getTask(req.ID)
 	if err != nil {
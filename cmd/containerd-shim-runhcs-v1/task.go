@@ -8,6 +8,7 @@ import (
 	"github.com/Microsoft/hcsshim/cmd/containerd-shim-runhcs-v1/options"
 	"github.com/Microsoft/hcsshim/cmd/containerd-shim-runhcs-v1/stats"
 	"github.com/Microsoft/hcsshim/internal/shimdiag"
+	"github.com/Microsoft/hcsshim/internal/uvm"
 	"github.com/containerd/containerd/runtime/v2/task"
 	specs "github.com/opencontainers/runtime-spec/specs-go"
 )
@@ -87,9 +88,28 @@ type shimTask interface {
 	//
 	// If the host is not hypervisor isolated returns error.
 	Share(ctx context.Context, req *shimdiag.ShareRequest) error
+	// SetGuestLogLevel changes the log level (and, optionally, debug
+	// categories) of the GCS running in the task's host UVM.
+	//
+	// If the host is not hypervisor isolated returns error.
+	SetGuestLogLevel(ctx context.Context, req *shimdiag.SetLogLevelRequest) error
+	// ListDevices returns the set of devices (VPCI and SCSI) currently
+	// assigned to the task's host UVM.
+	//
+	// If the host is not hypervisor isolated returns error.
+	ListDevices(ctx context.Context) ([]uvm.AssignedDevice, error)
+	// GuestInfo returns what's known about the task's host UVM's connected
+	// guest.
+	//
+	// If the host is not hypervisor isolated returns error.
+	GuestInfo(ctx context.Context) (uvm.GuestInfo, error)
 	// Stats returns various metrics for the task.
 	//
 	// If the host is hypervisor isolated and this task owns the host additional
 	// metrics on the UVM may be returned as well.
 	Stats(ctx context.Context) (*stats.Statistics, error)
+	// HostID returns the compute system ID of the task's host UVM.
+	//
+	// If the task is not hypervisor isolated returns `""`.
+	HostID() string
 }
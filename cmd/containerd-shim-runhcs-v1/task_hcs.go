@@ -6,6 +6,7 @@ import (
 	"os"
 	"path/filepath"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	eventstypes "github.com/containerd/containerd/api/events"
@@ -25,6 +26,7 @@ import (
 	"github.com/Microsoft/hcsshim/internal/cow"
 	"github.com/Microsoft/hcsshim/internal/hcs"
 	"github.com/Microsoft/hcsshim/internal/hcsoci"
+	"github.com/Microsoft/hcsshim/internal/jobobject"
 	"github.com/Microsoft/hcsshim/internal/log"
 	"github.com/Microsoft/hcsshim/internal/oci"
 	"github.com/Microsoft/hcsshim/internal/resources"
@@ -35,6 +37,31 @@ import (
 	"github.com/Microsoft/hcsshim/osversion"
 )
 
+// execResourceLimitsFromSpec builds the job object limits to apply to every
+// non-init exec started against a task, from
+// oci.AnnotationContainerExecMemoryLimitInMB,
+// oci.AnnotationContainerExecCPULimit, and
+// oci.AnnotationContainerExecMemoryNotifyLimitInMB on `s`. Returns nil if
+// neither the memory nor the CPU limit annotation is set.
+func execResourceLimitsFromSpec(ctx context.Context, s *specs.Spec) *jobobject.JobLimits {
+	mem := oci.ParseAnnotationsExecMemoryLimitInMB(ctx, s, oci.AnnotationContainerExecMemoryLimitInMB, 0)
+	cpu := oci.ParseAnnotationsExecCPULimit(ctx, s, oci.AnnotationContainerExecCPULimit, 0)
+	if mem == 0 && cpu == 0 {
+		return nil
+	}
+	limits := &jobobject.JobLimits{
+		MemoryLimitInBytes: mem * 1024 * 1024,
+		CPULimit:           uint32(cpu),
+	}
+	// The notify limit only makes sense relative to the hard memory limit, so
+	// it has no effect unless that's also set.
+	if mem != 0 {
+		notify := oci.ParseAnnotationsExecMemoryNotifyLimitInMB(ctx, s, oci.AnnotationContainerExecMemoryNotifyLimitInMB, 0)
+		limits.NotifyMemoryLimitInBytes = notify * 1024 * 1024
+	}
+	return limits
+}
+
 func newHcsStandaloneTask(ctx context.Context, events publisher, req *task.CreateTaskRequest, s *specs.Spec) (shimTask, error) {
 	log.G(ctx).WithField("tid", req.ID).Debug("newHcsStandaloneTask")
 
@@ -97,7 +124,7 @@ func newHcsStandaloneTask(ctx context.Context, events publisher, req *task.Creat
 		return nil, errors.Wrap(errdefs.ErrFailedPrecondition, "oci spec does not contain WCOW or LCOW spec")
 	}
 
-	shim, err := newHcsTask(ctx, events, parent, true, req, s)
+	shim, err := newHcsTask(ctx, events, parent, true, "", req, s)
 	if err != nil {
 		if parent != nil {
 			parent.Close()
@@ -116,6 +143,7 @@ func newHcsTask(
 	events publisher,
 	parent *uvm.UtilityVM,
 	ownsParent bool,
+	hostSharingKey string,
 	req *task.CreateTaskRequest,
 	s *specs.Spec) (_ shimTask, err error) {
 	log.G(ctx).WithFields(logrus.Fields{
@@ -163,14 +191,22 @@ func newHcsTask(
 	}
 
 	ht := &hcsTask{
-		events:   events,
-		id:       req.ID,
-		isWCOW:   oci.IsWCOW(s),
-		c:        system,
-		cr:       resources,
-		ownsHost: ownsParent,
-		host:     parent,
-		closed:   make(chan struct{}),
+		events:                 events,
+		id:                     req.ID,
+		isWCOW:                 oci.IsWCOW(s),
+		c:                      system,
+		cr:                     resources,
+		initSpec:               s.Process,
+		ownsHost:               ownsParent,
+		hostSharingKey:         hostSharingKey,
+		host:                   parent,
+		closed:                 make(chan struct{}),
+		restartPolicy:          oci.ParseAnnotationsRestartPolicy(ctx, s, oci.AnnotationContainerRestartPolicy, oci.RestartPolicyNo),
+		restartMaxAttempts:     oci.ParseAnnotationsRestartMaxAttempts(ctx, s, oci.AnnotationContainerRestartMaxAttempts, defaultRestartMaxAttempts),
+		restartBaseDelay:       time.Duration(oci.ParseAnnotationsRestartDelayInMs(ctx, s, oci.AnnotationContainerRestartDelayInMs, uint64(defaultRestartBaseDelay/time.Millisecond))) * time.Millisecond,
+		execResourceLimits:     execResourceLimitsFromSpec(ctx, s),
+		readinessProbeExec:     oci.ReadinessProbeExec(s),
+		readinessProbeInterval: oci.ReadinessProbeInterval(ctx, s),
 	}
 	ht.init = newHcsExec(
 		ctx,
@@ -183,6 +219,10 @@ func newHcsTask(
 		ht.isWCOW,
 		s.Process,
 		io,
+		nil,
+		ht.readinessProbeExec,
+		ht.readinessProbeInterval,
+		false,
 	)
 
 	if parent != nil {
@@ -246,13 +286,67 @@ type hcsTask struct {
 	//
 	// Note: the invariant `container state == init.State()` MUST be true. IE:
 	// if the init process exits the container as a whole and all exec's MUST
-	// exit.
+	// exit, UNLESS the restart policy below replaces it with a freshly
+	// started exec against the same (still running) container; in that case
+	// the invariant is re-established against the new `init`.
+	//
+	// Reads and writes MUST go through getInit/restartInit, which hold
+	// initMu, since a restart can replace it for as long as waitInitExit is
+	// still running.
+	initMu sync.RWMutex
+	init   shimExec
+	// initSpec is the OCI process spec `init` was started from. It is kept
+	// around, instead of read back off `init`, so restartInit can start a
+	// fresh exec with it after the original is gone.
 	//
 	// It MUST be treated as read only in the lifetime of the task.
-	init shimExec
+	initSpec *specs.Process
+	// restartPolicy, restartMaxAttempts, and restartBaseDelay configure
+	// whether and how waitInitExit restarts `init` in place after it exits,
+	// instead of ending the task. restartCount and restartStopped are
+	// waitInitExit's own bookkeeping for that loop.
+	//
+	// restartPolicy/restartMaxAttempts/restartBaseDelay MUST be treated as
+	// read only in the lifetime of the task. restartCount is only ever
+	// touched by the single waitInitExit goroutine. restartStopped MUST be
+	// accessed via sync/atomic.
+	restartPolicy      oci.RestartPolicy
+	restartMaxAttempts uint32
+	restartBaseDelay   time.Duration
+	restartCount       uint32
+	restartStopped     int32
+	// execResourceLimits, if non-nil, caps the job object every non-init
+	// exec started against this task's container is assigned to (see
+	// hcsExec.Start). It only takes effect for a process-isolated WCOW task
+	// (`host == nil`); execs in a hypervisor-isolated WCOW or an LCOW task
+	// are not limited by it. It is parsed once, from the task's spec annotations,
+	// at task creation: nil if neither
+	// oci.AnnotationContainerExecMemoryLimitInMB nor
+	// oci.AnnotationContainerExecCPULimit was set.
+	//
+	// execResourceLimits MUST be treated as read only in the lifetime of the
+	// task.
+	execResourceLimits *jobobject.JobLimits
+	// readinessProbeExec and readinessProbeInterval configure the init exec's
+	// readiness probe (see oci.AnnotationContainerReadinessProbeExec). They
+	// are parsed once, from the task's spec annotations, at task creation,
+	// and passed through to every init exec newHcsExec creates for this task
+	// -- including replacements restartInit starts after a crash --
+	// readinessProbeExec == "" if the annotation wasn't set.
+	//
+	// readinessProbeExec/readinessProbeInterval MUST be treated as read only
+	// in the lifetime of the task.
+	readinessProbeExec     string
+	readinessProbeInterval time.Duration
 	// ownsHost is `true` if this task owns `host`. If so when this tasks init
 	// exec shuts down it is required that `host` be shut down as well.
 	ownsHost bool
+	// hostSharingKey is non-empty if `host` was acquired via
+	// uvm.AcquireSharedUVM under this key instead of created solely for this
+	// task. If so, and `ownsHost == true`, `host` must be released via
+	// uvm.ReleaseSharedUVM(hostSharingKey) rather than closed directly, since
+	// other pods sharing the same key may still be using it.
+	hostSharingKey string
 	// host is the hosting VM for this exec if hypervisor isolated. If
 	// `host==nil` this is an Argon task so no UVM cleanup is required.
 	//
@@ -272,10 +366,109 @@ type hcsTask struct {
 	closeHostOnce sync.Once
 }
 
+const (
+	// defaultRestartMaxAttempts bounds how many times waitInitExit will
+	// restart a crashed init process under restart policy "always" or
+	// "on-failure" if the container's annotations don't override it.
+	defaultRestartMaxAttempts = 5
+	// defaultRestartBaseDelay is the delay before the first restart
+	// attempt if the container's annotations don't override it.
+	defaultRestartBaseDelay = time.Second
+	// maxRestartBackoff caps the delay between restart attempts, however
+	// many times it has doubled.
+	maxRestartBackoff = time.Second * 30
+)
+
+// restartBackoff returns the delay to wait before the attempt'th restart
+// (1-indexed) of a crashed init process: base, doubled with each attempt,
+// capped at maxRestartBackoff.
+func restartBackoff(attempt uint32, base time.Duration) time.Duration {
+	d := base
+	for i := uint32(1); i < attempt && d < maxRestartBackoff; i++ {
+		d *= 2
+	}
+	if d > maxRestartBackoff {
+		d = maxRestartBackoff
+	}
+	return d
+}
+
 func (ht *hcsTask) ID() string {
 	return ht.id
 }
 
+// getInit returns the task's current init exec. It may be called
+// concurrently with a restartInit replacing it.
+func (ht *hcsTask) getInit() shimExec {
+	ht.initMu.RLock()
+	defer ht.initMu.RUnlock()
+	return ht.init
+}
+
+// shouldRestart reports whether waitInitExit should restart `init` in
+// place, given that it just exited with `exitStatus`, rather than ending
+// the task.
+func (ht *hcsTask) shouldRestart(exitStatus uint32) bool {
+	if atomic.LoadInt32(&ht.restartStopped) != 0 {
+		// Something (KillExec on the init exec) already asked this task to
+		// stop; don't fight that by bringing it back.
+		return false
+	}
+	switch ht.restartPolicy {
+	case oci.RestartPolicyAlways:
+	case oci.RestartPolicyOnFailure:
+		if exitStatus == 0 {
+			return false
+		}
+	default:
+		return false
+	}
+	return ht.restartMaxAttempts == 0 || ht.restartCount < ht.restartMaxAttempts
+}
+
+// restartInit replaces the task's init exec with a freshly started one
+// against the same container, reusing the uVM, layers, and network
+// endpoints instead of tearing any of them down; only the init process
+// itself crashed. The replacement keeps `ht.id` as both its exec ID and
+// task ID, exactly like the original init -- containerd created exactly one
+// exec for this task and has no concept of that exec's process restarting
+// in place, so fabricating a distinct exec ID here would describe a
+// CreateExec containerd never made. newHcsExec's isRestart=true instead
+// tells Start to skip the (already done) container-start call and to not
+// publish a second TaskStart.
+func (ht *hcsTask) restartInit(ctx context.Context, attempt uint32) (shimExec, error) {
+	ht.initMu.Lock()
+	defer ht.initMu.Unlock()
+
+	status := ht.init.Status()
+	io, err := cmd.NewUpstreamIO(ctx, ht.id, status.Stdout, status.Stderr, status.Stdin, status.Terminal)
+	if err != nil {
+		return nil, err
+	}
+
+	he := newHcsExec(
+		ctx,
+		ht.events,
+		ht.id,
+		ht.host,
+		ht.c,
+		ht.id,
+		status.Bundle,
+		ht.isWCOW,
+		ht.initSpec,
+		io,
+		nil,
+		ht.readinessProbeExec,
+		ht.readinessProbeInterval,
+		true,
+	)
+	if err := he.Start(ctx); err != nil {
+		return nil, err
+	}
+	ht.init = he
+	return he, nil
+}
+
 func (ht *hcsTask) CreateExec(ctx context.Context, req *task.ExecProcessRequest, spec *specs.Process) error {
 	ht.ecl.Lock()
 	defer ht.ecl.Unlock()
@@ -286,7 +479,8 @@ func (ht *hcsTask) CreateExec(ctx context.Context, req *task.ExecProcessRequest,
 		return errors.Wrapf(errdefs.ErrAlreadyExists, "exec: '%s' in task: '%s' already exists", req.ExecID, ht.id)
 	}
 
-	if ht.init.State() != shimExecStateRunning {
+	init := ht.getInit()
+	if init.State() != shimExecStateRunning {
 		return errors.Wrapf(errdefs.ErrFailedPrecondition, "exec: '' in task: '%s' must be running to create additional execs", ht.id)
 	}
 
@@ -302,10 +496,14 @@ func (ht *hcsTask) CreateExec(ctx context.Context, req *task.ExecProcessRequest,
 		ht.host,
 		ht.c,
 		req.ExecID,
-		ht.init.Status().Bundle,
+		init.Status().Bundle,
 		ht.isWCOW,
 		spec,
 		io,
+		ht.execResourceLimits,
+		"",
+		0,
+		false,
 	)
 
 	ht.execs.Store(req.ExecID, he)
@@ -324,7 +522,7 @@ func (ht *hcsTask) CreateExec(ctx context.Context, req *task.ExecProcessRequest,
 
 func (ht *hcsTask) GetExec(eid string) (shimExec, error) {
 	if eid == "" {
-		return ht.init, nil
+		return ht.getInit(), nil
 	}
 	raw, loaded := ht.execs.Load(eid)
 	if !loaded {
@@ -356,6 +554,11 @@ func (ht *hcsTask) KillExec(ctx context.Context, eid string, signal uint32, all
 			return false
 		})
 	}
+	if eid == "" {
+		// Something is explicitly asking the init process to stop; don't
+		// let the restart policy bring it back once it does.
+		atomic.StoreInt32(&ht.restartStopped, 1)
+	}
 	if signal == 0x9 && eid == "" && ht.host != nil {
 		// If this is a SIGKILL against the init process we start a background
 		// timer and wait on either the timer expiring or the process exiting
@@ -434,7 +637,8 @@ func (ht *hcsTask) Pids(ctx context.Context) ([]options.ProcessDetails, error) {
 		// Iterate all
 		return false
 	})
-	pidMap[ht.init.Pid()] = ht.init.ID()
+	init := ht.getInit()
+	pidMap[init.Pid()] = init.ID()
 
 	// Get the guest pids
 	props, err := ht.c.Properties(ctx, schema1.PropertyTypeProcessList)
@@ -463,7 +667,7 @@ func (ht *hcsTask) Pids(ctx context.Context) ([]options.ProcessDetails, error) {
 
 func (ht *hcsTask) Wait() *task.StateResponse {
 	<-ht.closed
-	return ht.init.Wait()
+	return ht.getInit().Wait()
 }
 
 func (ht *hcsTask) waitInitExit() {
@@ -471,8 +675,27 @@ func (ht *hcsTask) waitInitExit() {
 	defer span.End()
 	span.AddAttributes(trace.StringAttribute("tid", ht.id))
 
-	// Wait for it to exit on its own
-	ht.init.Wait()
+	for {
+		// Wait for it to exit on its own
+		status := ht.getInit().Wait()
+
+		if !ht.shouldRestart(status.ExitStatus) {
+			break
+		}
+
+		ht.restartCount++
+		delay := restartBackoff(ht.restartCount, ht.restartBaseDelay)
+		log.G(ctx).WithFields(logrus.Fields{
+			"attempt": ht.restartCount,
+			"delay":   delay,
+		}).Info("hcsTask::waitInitExit restarting init process")
+		time.Sleep(delay)
+
+		if _, err := ht.restartInit(ctx, ht.restartCount); err != nil {
+			log.G(ctx).WithError(err).Error("failed to restart init process; reporting exit instead")
+			break
+		}
+	}
 
 	// Close the host and event the exit
 	ht.close(ctx)
@@ -591,12 +814,18 @@ func (ht *hcsTask) closeHost(ctx context.Context) {
 		log.G(ctx).Debug("hcsTask::closeHostOnce")
 
 		if ht.ownsHost && ht.host != nil {
-			if err := ht.host.Close(); err != nil {
+			var err error
+			if ht.hostSharingKey != "" {
+				err = uvm.ReleaseSharedUVM(ht.hostSharingKey)
+			} else {
+				err = ht.host.Close()
+			}
+			if err != nil {
 				log.G(ctx).WithError(err).Error("failed host vm shutdown")
 			}
 		}
 		// Send the `init` exec exit notification always.
-		exit := ht.init.Status()
+		exit := ht.getInit().Status()
 		ht.events.publishEvent(
 			ctx,
 			runtime.TaskExitEventTopic,
@@ -630,6 +859,13 @@ func (ht *hcsTask) DumpGuestStacks(ctx context.Context) string {
 	return ""
 }
 
+func (ht *hcsTask) SetGuestLogLevel(ctx context.Context, req *shimdiag.SetLogLevelRequest) error {
+	if ht.host == nil {
+		return errTaskNotIsolated
+	}
+	return ht.host.SetGuestLogLevel(ctx, req.Level, req.Categories)
+}
+
 func (ht *hcsTask) Share(ctx context.Context, req *shimdiag.ShareRequest) error {
 	if ht.host == nil {
 		return errTaskNotIsolated
@@ -655,6 +891,20 @@ func (ht *hcsTask) Share(ctx context.Context, req *shimdiag.ShareRequest) error
 	return err
 }
 
+func (ht *hcsTask) ListDevices(ctx context.Context) ([]uvm.AssignedDevice, error) {
+	if ht.host == nil {
+		return nil, errTaskNotIsolated
+	}
+	return ht.host.AssignedDevices(), nil
+}
+
+func (ht *hcsTask) GuestInfo(ctx context.Context) (uvm.GuestInfo, error) {
+	if ht.host == nil {
+		return uvm.GuestInfo{}, errTaskNotIsolated
+	}
+	return ht.host.GuestInfo(), nil
+}
+
 func hcsPropertiesToWindowsStats(props *hcsschema.Properties) *stats.Statistics_Windows {
 	wcs := &stats.Statistics_Windows{Windows: &stats.WindowsContainerStatistics{}}
 	if props.Statistics != nil {
@@ -708,3 +958,10 @@ func (ht *hcsTask) Stats(ctx context.Context) (*stats.Statistics, error) {
 	}
 	return s, nil
 }
+
+func (ht *hcsTask) HostID() string {
+	if ht.host == nil {
+		return ""
+	}
+	return ht.host.ID()
+}
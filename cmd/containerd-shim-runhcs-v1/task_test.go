@@ -7,6 +7,7 @@ import (
 	"github.com/Microsoft/hcsshim/cmd/containerd-shim-runhcs-v1/options"
 	"github.com/Microsoft/hcsshim/cmd/containerd-shim-runhcs-v1/stats"
 	"github.com/Microsoft/hcsshim/internal/shimdiag"
+	"github.com/Microsoft/hcsshim/internal/uvm"
 	v1 "github.com/containerd/cgroups/stats/v1"
 	"github.com/containerd/containerd/errdefs"
 	"github.com/containerd/containerd/runtime/v2/task"
@@ -95,6 +96,18 @@ func (tst *testShimTask) Share(ctx context.Context, req *shimdiag.ShareRequest)
 	return errors.New("not implemented")
 }
 
+func (tst *testShimTask) SetGuestLogLevel(ctx context.Context, req *shimdiag.SetLogLevelRequest) error {
+	return errors.New("not implemented")
+}
+
+func (tst *testShimTask) ListDevices(ctx context.Context) ([]uvm.AssignedDevice, error) {
+	return nil, errors.New("not implemented")
+}
+
+func (tst *testShimTask) GuestInfo(ctx context.Context) (uvm.GuestInfo, error) {
+	return uvm.GuestInfo{}, errors.New("not implemented")
+}
+
 func (tst *testShimTask) Stats(ctx context.Context) (*stats.Statistics, error) {
 	if tst.isWCOW {
 		return getWCOWTestStats(), nil
@@ -102,6 +115,10 @@ func (tst *testShimTask) Stats(ctx context.Context) (*stats.Statistics, error) {
 	return getLCOWTestStats(), nil
 }
 
+func (tst *testShimTask) HostID() string {
+	return ""
+}
+
 func getWCOWTestStats() *stats.Statistics {
 	return &stats.Statistics{
 		Container: &stats.Statistics_Windows{
@@ -25,23 +25,27 @@ import (
 // container and process since it is not needed to hold open any namespaces like
 // the equivalent on Linux.
 //
-// It is assumed that this is the only fake WCOW task and that this task owns
-// `parent`. When the fake WCOW `init` process exits via `Signal` `parent` will
-// be forcibly closed by this task.
-func newWcowPodSandboxTask(ctx context.Context, events publisher, id, bundle string, parent *uvm.UtilityVM) shimTask {
+// If `ownsHost` is `true` this task owns `parent`: when the fake WCOW `init`
+// process exits via `Signal` `parent` will be forcibly closed by this task
+// (via `hostSharingKey`, if non-empty, rather than directly -- see
+// uvm.ReleaseSharedUVM). If `ownsHost` is `false` this task only tracks
+// `parent` for lifetime purposes; some other pod sharing it owns the close.
+func newWcowPodSandboxTask(ctx context.Context, events publisher, id, bundle string, parent *uvm.UtilityVM, ownsHost bool, hostSharingKey string) shimTask {
 	log.G(ctx).WithField("tid", id).Debug("newWcowPodSandboxTask")
 
 	wpst := &wcowPodSandboxTask{
-		events: events,
-		id:     id,
-		init:   newWcowPodSandboxExec(ctx, events, id, bundle),
-		host:   parent,
-		closed: make(chan struct{}),
+		events:         events,
+		id:             id,
+		init:           newWcowPodSandboxExec(ctx, events, id, bundle),
+		host:           parent,
+		ownsHost:       ownsHost,
+		hostSharingKey: hostSharingKey,
+		closed:         make(chan struct{}),
 	}
 	if parent != nil {
-		// We have (and own) a parent UVM. Listen for its exit and forcibly
-		// close this task. This is not expected but in the event of a UVM crash
-		// we need to handle this case.
+		// We have a parent UVM (and may own it). Listen for its exit and
+		// forcibly close this task. This is not expected but in the event of
+		// a UVM crash we need to handle this case.
 		go wpst.waitParentExit()
 	}
 	// In the normal case the `Signal` call from the caller killed this fake
@@ -78,6 +82,14 @@ type wcowPodSandboxTask struct {
 	// host is the hosting VM for this task if hypervisor isolated. If
 	// `host==nil` this is an Argon task so no UVM cleanup is required.
 	host *uvm.UtilityVM
+	// ownsHost is `true` if this task owns `host` and must close it when the
+	// fake init process exits.
+	ownsHost bool
+	// hostSharingKey is non-empty if `host` was acquired via
+	// uvm.AcquireSharedUVM under this key. If so, and `ownsHost == true`,
+	// `host` must be released via uvm.ReleaseSharedUVM(hostSharingKey)
+	// rather than closed directly.
+	hostSharingKey string
 
 	closed    chan struct{}
 	closeOnce sync.Once
@@ -156,18 +168,23 @@ func (wpst *wcowPodSandboxTask) Wait() *task.StateResponse {
 	return wpst.init.Wait()
 }
 
-// close safely closes the hosting UVM. Because of the specialty of this task it
-// is assumed that this is always the owner of `wpst.host`. Once closed and all
-// resources released it events the `runtime.TaskExitEventTopic` for all
-// upstream listeners.
+// close safely closes the hosting UVM if this task is the owner (`ownsHost`).
+// Once closed and all resources released it events the
+// `runtime.TaskExitEventTopic` for all upstream listeners.
 //
 // This call is idempotent and safe to call multiple times.
 func (wpst *wcowPodSandboxTask) close(ctx context.Context) {
 	wpst.closeOnce.Do(func() {
 		log.G(ctx).Debug("wcowPodSandboxTask::closeOnce")
 
-		if wpst.host != nil {
-			if err := wpst.host.Close(); err != nil {
+		if wpst.ownsHost && wpst.host != nil {
+			var err error
+			if wpst.hostSharingKey != "" {
+				err = uvm.ReleaseSharedUVM(wpst.hostSharingKey)
+			} else {
+				err = wpst.host.Close()
+			}
+			if err != nil {
 				log.G(ctx).WithError(err).Error("failed host vm shutdown")
 			}
 		}
@@ -243,6 +260,35 @@ func (wpst *wcowPodSandboxTask) Share(ctx context.Context, req *shimdiag.ShareRe
 	return wpst.host.Share(ctx, req.HostPath, req.UvmPath, req.ReadOnly)
 }
 
+func (wpst *wcowPodSandboxTask) SetGuestLogLevel(ctx context.Context, req *shimdiag.SetLogLevelRequest) error {
+	if wpst.host == nil {
+		return errTaskNotIsolated
+	}
+	return wpst.host.SetGuestLogLevel(ctx, req.Level, req.Categories)
+}
+
+func (wpst *wcowPodSandboxTask) ListDevices(ctx context.Context) ([]uvm.AssignedDevice, error) {
+	if wpst.host == nil {
+		return nil, errTaskNotIsolated
+	}
+	return wpst.host.AssignedDevices(), nil
+}
+
+func (wpst *wcowPodSandboxTask) GuestInfo(ctx context.Context) (uvm.GuestInfo, error) {
+	if wpst.host == nil {
+		return uvm.GuestInfo{}, errTaskNotIsolated
+	}
+	return wpst.host.GuestInfo(), nil
+}
+
+// Stats returns the VM-level statistics for the sandbox's host, if it is
+// hypervisor isolated.
+//
+// It does not yet include the sandbox's network statistics (see
+// hns.NamespaceStatistics): the `stats.Statistics` protobuf message has no
+// field to carry them, and this module does not vendor the protoc/gogo
+// codegen needed to regenerate cmd/containerd-shim-runhcs-v1/stats/stats.pb.go
+// from an updated stats.proto.
 func (wpst *wcowPodSandboxTask) Stats(ctx context.Context) (*stats.Statistics, error) {
 	vmStats, err := wpst.host.Stats(ctx)
 	if err != nil {
@@ -252,3 +298,10 @@ func (wpst *wcowPodSandboxTask) Stats(ctx context.Context) (*stats.Statistics, e
 	stats.VM = vmStats
 	return stats, nil
 }
+
+func (wpst *wcowPodSandboxTask) HostID() string {
+	if wpst.host == nil {
+		return ""
+	}
+	return wpst.host.ID()
+}
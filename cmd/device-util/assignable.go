@@ -0,0 +1,60 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"strings"
+
+	"github.com/Microsoft/hcsshim/internal/devices"
+	"github.com/urfave/cli"
+)
+
+const (
+	enumeratorFlag = "enumerator"
+	idsFlag        = "ids"
+
+	defaultEnumerator = "PCI"
+)
+
+var assignableCommand = cli.Command{
+	Name:  "assignable",
+	Usage: "lists devices eligible for VPCI assignment, their IOMMU groups, and their assignment state",
+	Flags: []cli.Flag{
+		cli.StringFlag{
+			Name:  enumeratorFlag,
+			Value: defaultEnumerator,
+			Usage: "Enumerator to query devices for, e.g. 'PCI'.",
+		},
+	},
+	Action: func(context *cli.Context) error {
+		ad, err := devices.GetAssignableDevices(context.String(enumeratorFlag))
+		if err != nil {
+			return err
+		}
+		return json.NewEncoder(os.Stdout).Encode(ad)
+	},
+}
+
+var validateAssignmentCommand = cli.Command{
+	Name:  "validate-assignment",
+	Usage: "validates that the given devices form complete IOMMU groups",
+	Flags: []cli.Flag{
+		cli.StringFlag{
+			Name:  enumeratorFlag,
+			Value: defaultEnumerator,
+			Usage: "Enumerator to query devices for, e.g. 'PCI'.",
+		},
+		cli.StringFlag{
+			Name:  idsFlag,
+			Usage: "Required: instance IDs of the devices to be assigned. Comma separated string.",
+		},
+	},
+	Action: func(context *cli.Context) error {
+		if !context.IsSet(idsFlag) {
+			return errors.New("`validate-assignment` command must specify at least one device instance ID")
+		}
+		ids := strings.Split(context.String(idsFlag), ",")
+		return devices.ValidateAssignment(context.String(enumeratorFlag), ids)
+	},
+}
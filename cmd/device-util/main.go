@@ -19,6 +19,8 @@ func main() {
 	app.Commands = []cli.Command{
 		queryChildrenCommand,
 		readObjDirCommand,
+		assignableCommand,
+		validateAssignmentCommand,
 	}
 	app.Usage = usage
 
@@ -0,0 +1,35 @@
+package main
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/Microsoft/hcsshim/internal/appargs"
+	"github.com/Microsoft/hcsshim/internal/hcs"
+	"github.com/urfave/cli"
+)
+
+var killCommand = cli.Command{
+	Name:      "kill",
+	Usage:     "force-terminates a stuck compute system",
+	ArgsUsage: "<id>",
+	Before:    appargs.Validate(appargs.NonEmptyString),
+	Action: func(c *cli.Context) error {
+		id := c.Args().First()
+
+		system, err := hcs.OpenComputeSystem(context.Background(), id)
+		if err != nil {
+			return err
+		}
+		defer system.Close()
+
+		if err := system.Terminate(context.Background()); err != nil && !hcs.IsPending(err) {
+			return err
+		}
+		if err := system.Wait(); err != nil {
+			return err
+		}
+		fmt.Println(id)
+		return nil
+	},
+}
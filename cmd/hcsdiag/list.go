@@ -0,0 +1,65 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"text/tabwriter"
+
+	"github.com/Microsoft/hcsshim/internal/hcs"
+	"github.com/Microsoft/hcsshim/internal/schema1"
+	"github.com/urfave/cli"
+)
+
+var listCommand = cli.Command{
+	Name:  "list",
+	Usage: "lists the compute systems present on the host",
+	Flags: []cli.Flag{
+		cli.StringFlag{
+			Name:  "format, f",
+			Value: "table",
+			Usage: "select one of: table or json",
+		},
+		cli.StringSliceFlag{
+			Name:  "owner",
+			Usage: "only show compute systems with one of these owners",
+		},
+		cli.StringSliceFlag{
+			Name:  "type",
+			Usage: "only show compute systems of one of these types (e.g. Container, VirtualMachine)",
+		},
+		cli.StringSliceFlag{
+			Name:  "state",
+			Usage: "only show compute systems in one of these states (e.g. Running, Paused)",
+		},
+	},
+	Action: func(c *cli.Context) error {
+		q := schema1.ComputeSystemQuery{
+			Owners: []string(c.StringSlice("owner")),
+			Types:  []string(c.StringSlice("type")),
+		}
+		filter := hcs.ComputeSystemFilter{
+			States: []string(c.StringSlice("state")),
+		}
+		systems, err := hcs.GetComputeSystemsFiltered(context.Background(), q, filter)
+		if err != nil {
+			return err
+		}
+
+		switch c.String("format") {
+		case "table":
+			w := tabwriter.NewWriter(os.Stdout, 12, 1, 3, ' ', 0)
+			fmt.Fprint(w, "ID\tOWNER\tTYPE\tSTATE\tDISKS\n")
+			for _, s := range systems {
+				fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%d\n",
+					s.ID, s.Owner, s.SystemType, s.State, len(s.MappedVirtualDiskControllers))
+			}
+			return w.Flush()
+		case "json":
+			return json.NewEncoder(os.Stdout).Encode(systems)
+		default:
+			return fmt.Errorf("invalid format option")
+		}
+	},
+}
@@ -0,0 +1,26 @@
+package main
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/urfave/cli"
+)
+
+const usage = `hcsdiag is a command line tool for inspecting and recovering HCS compute systems`
+
+func main() {
+	app := cli.NewApp()
+	app.Name = "hcsdiag"
+	app.Usage = usage
+	app.Commands = []cli.Command{
+		listCommand,
+		showCommand,
+		killCommand,
+	}
+
+	if err := app.Run(os.Args); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+}
@@ -0,0 +1,36 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+
+	"github.com/Microsoft/hcsshim/internal/appargs"
+	"github.com/Microsoft/hcsshim/internal/hcs"
+	"github.com/urfave/cli"
+)
+
+var showCommand = cli.Command{
+	Name:      "show",
+	Usage:     "dumps the full properties of a compute system as JSON",
+	ArgsUsage: "<id>",
+	Before:    appargs.Validate(appargs.NonEmptyString),
+	Action: func(c *cli.Context) error {
+		id := c.Args().First()
+
+		system, err := hcs.OpenComputeSystem(context.Background(), id)
+		if err != nil {
+			return err
+		}
+		defer system.Close()
+
+		properties, err := system.Properties(context.Background())
+		if err != nil {
+			return err
+		}
+
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		return enc.Encode(properties)
+	},
+}
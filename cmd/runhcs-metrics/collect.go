@@ -0,0 +1,114 @@
+package main
+
+import (
+	"context"
+	"time"
+
+	"github.com/Microsoft/go-winio"
+	"github.com/Microsoft/hcsshim/cmd/containerd-shim-runhcs-v1/stats"
+	"github.com/Microsoft/hcsshim/internal/shimdiag"
+	"github.com/containerd/containerd/runtime/v2/task"
+	"github.com/containerd/ttrpc"
+	"github.com/containerd/typeurl"
+	"github.com/sirupsen/logrus"
+)
+
+// dialTimeout bounds connecting to a single shim's pipe, so one shim stuck
+// mid-startup (pipe exists, nothing accepting yet) doesn't stall the whole
+// scrape.
+const dialTimeout = 2 * time.Second
+
+// shimStats is everything collect gathered for a single task hosted by a
+// single shim, ready to be rendered as a metrics sample.
+type shimStats struct {
+	shim string
+	task string
+	*stats.Statistics
+}
+
+// collect dials every shim currently running on this node and returns the
+// statistics of every task each one is hosting. A shim that fails to
+// dial, or a task that fails to report stats (e.g. it exited between
+// DiagExecHistory and Stats), is logged and skipped rather than failing
+// the whole scrape.
+func collect(ctx context.Context) []shimStats {
+	shims, err := findShims()
+	if err != nil {
+		logrus.WithError(err).Error("failed to enumerate shims")
+		return nil
+	}
+
+	var out []shimStats
+	for _, shim := range shims {
+		out = append(out, collectShim(ctx, shim)...)
+	}
+	return out
+}
+
+func collectShim(ctx context.Context, shim string) []shimStats {
+	timeout := dialTimeout
+	conn, err := winio.DialPipe(shimPrefix+shim+shimSuffix, &timeout)
+	if err != nil {
+		logrus.WithField("shim", shim).WithError(err).Warn("failed to dial shim")
+		return nil
+	}
+	defer conn.Close()
+	client := ttrpc.NewClient(conn)
+	defer client.Close()
+
+	taskIDs, err := taskIDsForShim(ctx, client, shim)
+	if err != nil {
+		logrus.WithField("shim", shim).WithError(err).Warn("failed to discover tasks for shim")
+		return nil
+	}
+
+	taskSvc := task.NewTaskClient(client)
+	var out []shimStats
+	for _, tid := range taskIDs {
+		resp, err := taskSvc.Stats(ctx, &task.StatsRequest{ID: tid})
+		if err != nil {
+			logrus.WithFields(logrus.Fields{"shim": shim, "task": tid}).WithError(err).Warn("failed to get task stats")
+			continue
+		}
+		if resp.Stats == nil {
+			continue
+		}
+		v, err := typeurl.UnmarshalAny(resp.Stats)
+		if err != nil {
+			logrus.WithFields(logrus.Fields{"shim": shim, "task": tid}).WithError(err).Warn("failed to unmarshal task stats")
+			continue
+		}
+		s, ok := v.(*stats.Statistics)
+		if !ok {
+			continue
+		}
+		out = append(out, shimStats{shim: shim, task: tid, Statistics: s})
+	}
+	return out
+}
+
+// taskIDsForShim returns the distinct task IDs shim has ever recorded a
+// lifecycle event for, via its shimdiag exec history -- the shim's own
+// task service has no "list tasks" RPC, so this is the only way to
+// discover per-container task IDs hosted inside a pod shim from outside
+// the process. Falls back to just the shim's own ID (its top-level task,
+// which for a standalone, non-pod container is the only task it hosts)
+// if the history is empty.
+func taskIDsForShim(ctx context.Context, client *ttrpc.Client, shim string) ([]string, error) {
+	resp, err := shimdiag.NewShimDiagClient(client).DiagExecHistory(ctx, &shimdiag.DiagExecHistoryRequest{})
+	if err != nil {
+		return nil, err
+	}
+	seen := map[string]bool{}
+	var ids []string
+	for _, e := range resp.Entries {
+		if !seen[e.TaskId] {
+			seen[e.TaskId] = true
+			ids = append(ids, e.TaskId)
+		}
+	}
+	if len(ids) == 0 {
+		ids = []string{shim}
+	}
+	return ids, nil
+}
@@ -0,0 +1,43 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"os"
+
+	"github.com/sirupsen/logrus"
+	"github.com/urfave/cli"
+)
+
+func main() {
+	app := cli.NewApp()
+	app.Name = "runhcs-metrics"
+	app.Usage = "Aggregate per-shim statistics on this node into one Prometheus scrape target"
+	app.Flags = []cli.Flag{
+		cli.StringFlag{
+			Name:  "address,a",
+			Value: ":9346",
+			Usage: "Address to serve /metrics on",
+		},
+	}
+	app.Action = run
+
+	if err := app.Run(os.Args); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+}
+
+// run serves /metrics on address until the process is killed. Every scrape
+// re-collects from every shim currently running on the node -- there is no
+// caching, since shim churn (pods/containers starting and stopping) means
+// a stale cache would quickly mislabel or drop series.
+func run(c *cli.Context) error {
+	address := c.String("address")
+	http.HandleFunc("/metrics", func(w http.ResponseWriter, r *http.Request) {
+		samples := collect(r.Context())
+		render(w, samples)
+	})
+	logrus.WithField("address", address).Info("serving /metrics")
+	return http.ListenAndServe(address, nil)
+}
@@ -0,0 +1,96 @@
+package main
+
+import (
+	"fmt"
+	"io"
+
+	"github.com/Microsoft/hcsshim/cmd/containerd-shim-runhcs-v1/stats"
+)
+
+// metric is one Prometheus exposition-format sample: name, its labels (in
+// insertion order, for stable output), and value.
+type metric struct {
+	name   string
+	labels [][2]string
+	value  float64
+}
+
+func (m metric) writeTo(w io.Writer) {
+	fmt.Fprint(w, m.name)
+	if len(m.labels) > 0 {
+		fmt.Fprint(w, "{")
+		for i, l := range m.labels {
+			if i > 0 {
+				fmt.Fprint(w, ",")
+			}
+			fmt.Fprintf(w, "%s=%q", l[0], l[1])
+		}
+		fmt.Fprint(w, "}")
+	}
+	fmt.Fprintf(w, " %v\n", m.value)
+}
+
+// help is a single "# HELP name text" / "# TYPE name gauge" pair, written
+// once per distinct metric name ahead of its samples, per the Prometheus
+// text exposition format.
+type help struct {
+	name string
+	text string
+}
+
+var helpText = []help{
+	{"runhcs_container_cpu_usage_seconds_total", "Total CPU time consumed by the container, in seconds"},
+	{"runhcs_container_memory_private_working_set_bytes", "Container private working set"},
+	{"runhcs_container_memory_commit_bytes", "Container memory commit usage"},
+	{"runhcs_container_storage_read_bytes_total", "Total bytes read by the container"},
+	{"runhcs_container_storage_write_bytes_total", "Total bytes written by the container"},
+	{"runhcs_vm_cpu_usage_seconds_total", "Total CPU time consumed by the container's utility VM, in seconds"},
+	{"runhcs_vm_memory_working_set_bytes", "Utility VM working set"},
+}
+
+// render writes every sample in stats, across every shim/task discovered by
+// collect, as Prometheus exposition-format text.
+func render(w io.Writer, samples []shimStats) {
+	for _, h := range helpText {
+		fmt.Fprintf(w, "# HELP %s %s\n# TYPE %s gauge\n", h.name, h.text, h.name)
+	}
+	for _, s := range samples {
+		labels := [][2]string{{"shim", s.shim}, {"task", s.task}}
+		for _, m := range statsToMetrics(s.Statistics, labels) {
+			m.writeTo(w)
+		}
+	}
+}
+
+func statsToMetrics(s *stats.Statistics, labels [][2]string) []metric {
+	var out []metric
+	if c := s.GetWindows(); c != nil {
+		if p := c.Processor; p != nil {
+			out = append(out, metric{"runhcs_container_cpu_usage_seconds_total", labels, float64(p.TotalRuntimeNS) / 1e9})
+		}
+		if m := c.Memory; m != nil {
+			out = append(out,
+				metric{"runhcs_container_memory_private_working_set_bytes", labels, float64(m.MemoryUsagePrivateWorkingSetBytes)},
+				metric{"runhcs_container_memory_commit_bytes", labels, float64(m.MemoryUsageCommitBytes)})
+		}
+		if st := c.Storage; st != nil {
+			out = append(out,
+				metric{"runhcs_container_storage_read_bytes_total", labels, float64(st.ReadSizeBytes)},
+				metric{"runhcs_container_storage_write_bytes_total", labels, float64(st.WriteSizeBytes)})
+		}
+	}
+	// Linux containers report cgroup metrics (io.containerd.cgroups.v1.Metrics)
+	// instead of the Windows-specific shape above; cgroup metrics have their
+	// own well-established Prometheus exporter (see containerd's own
+	// cgroups-based collector), so this tool only adds the HCS/uVM-specific
+	// metrics no existing exporter already covers.
+	if vm := s.VM; vm != nil {
+		if p := vm.Processor; p != nil {
+			out = append(out, metric{"runhcs_vm_cpu_usage_seconds_total", labels, float64(p.TotalRuntimeNS) / 1e9})
+		}
+		if m := vm.Memory; m != nil {
+			out = append(out, metric{"runhcs_vm_memory_working_set_bytes", labels, float64(m.WorkingSetBytes)})
+		}
+	}
+	return out
+}
@@ -0,0 +1,45 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+
+	"golang.org/x/sys/windows"
+)
+
+// shimPrefix/shimSuffix bracket the pod/task ID in a shim's named pipe
+// address; see cmd/shimdiag, which discovers shims the same way.
+const (
+	shimPrefix = `\\.\pipe\ProtectedPrefix\Administrators\containerd-shim-`
+	shimSuffix = `-pipe`
+)
+
+// findShims returns the ID of every shim currently listening on a
+// containerd-shim-runhcs-v1 pipe on this node.
+func findShims() ([]string, error) {
+	path := `\\.\pipe\*`
+	path16, err := windows.UTF16FromString(path)
+	if err != nil {
+		return nil, err
+	}
+	var data windows.Win32finddata
+	h, err := windows.FindFirstFile(&path16[0], &data)
+	if err != nil {
+		return nil, &os.PathError{Op: "FindFirstFile", Path: path, Err: err}
+	}
+	var names []string
+	for {
+		name := `\\.\pipe\` + windows.UTF16ToString(data.FileName[:])
+		if matched, _ := filepath.Match(shimPrefix+"*"+shimSuffix, name); matched {
+			names = append(names, name[len(shimPrefix):len(name)-len(shimSuffix)])
+		}
+		err = windows.FindNextFile(h, &data)
+		if err == windows.ERROR_NO_MORE_FILES {
+			break
+		}
+		if err != nil {
+			return nil, &os.PathError{Op: "FindNextFile", Path: path, Err: err}
+		}
+	}
+	return names, nil
+}
@@ -0,0 +1,101 @@
+package main
+
+import (
+	gcontext "context"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/Microsoft/hcsshim/internal/appargs"
+	"github.com/Microsoft/hcsshim/internal/hcs"
+	"github.com/urfave/cli"
+)
+
+// containerSaveStateFileName and vmSaveStateFileName are the file names
+// written under an image path by the checkpoint command, and read back by
+// the restore command.
+const (
+	containerSaveStateFileName = "container.save"
+	vmSaveStateFileName        = "vm.save"
+)
+
+var checkpointCommand = cli.Command{
+	Name:  "checkpoint",
+	Usage: "checkpoints a paused container to an image path",
+	ArgsUsage: `<container-id> <image-path>
+
+Where "<container-id>" is the name for the instance of the container to be
+checkpointed, and "<image-path>" is the directory the checkpoint state will
+be written to. The directory is created if it does not already exist.
+
+The container must be paused (see "runhcs pause") before it is checkpointed.`,
+	Description: `The checkpoint command saves the state of a paused container, and the uVM
+hosting it if any, to the given image path. The resulting image path can
+later be passed to "runhcs restore" to resume the container from the saved
+state.
+
+Use runhcs list to identify instances of containers and their current status.
+
+If one or more "--replicate-to" paths are given, the image path is copied to
+a same-named subdirectory of each one (which may be a UNC path to a share on
+another node) after it is written, so a fleet of peer nodes can each serve
+clones of the same templated workload. A checksum manifest is written
+alongside the save state files and the copy on each peer is verified against
+it; a peer whose copy fails verification aborts the checkpoint.`,
+	Flags: []cli.Flag{
+		cli.StringSliceFlag{
+			Name:  "replicate-to",
+			Usage: "path(s) to push the checkpoint to after it is saved, for peer nodes to restore templates from",
+		},
+	},
+	Before: appargs.Validate(argID, argID),
+	Action: func(context *cli.Context) error {
+		id := context.Args().First()
+		imagePath := context.Args().Get(1)
+
+		container, err := getContainer(id, true)
+		if err != nil {
+			return err
+		}
+		defer container.Close()
+
+		status, err := container.Status()
+		if err != nil {
+			return err
+		}
+		if status != containerPaused {
+			return fmt.Errorf("cannot checkpoint a container in the '%s' state, it must be paused first", status)
+		}
+
+		if err := os.MkdirAll(imagePath, 0); err != nil {
+			return err
+		}
+
+		ctx := gcontext.Background()
+		if container.HostID != "" {
+			host, err := getContainer(container.HostID, true)
+			if err != nil {
+				return err
+			}
+			defer host.Close()
+			if err := host.hc.Save(ctx, hcs.NewSaveOptions(hcs.SaveTypeAsTemplate, filepath.Join(imagePath, vmSaveStateFileName))); err != nil {
+				return err
+			}
+		}
+
+		if err := container.hc.Save(ctx, hcs.NewSaveOptions(hcs.SaveTypeAsTemplate, filepath.Join(imagePath, containerSaveStateFileName))); err != nil {
+			return err
+		}
+
+		if peers := context.StringSlice("replicate-to"); len(peers) > 0 {
+			// Only a checkpoint actually being pushed to peers needs a
+			// checksum manifest -- it exists to verify the copy survived
+			// the push, not to checksum every local checkpoint.
+			if err := writeChecksumManifest(imagePath); err != nil {
+				return err
+			}
+			return replicateToPeers(imagePath, peers)
+		}
+		return nil
+	},
+}
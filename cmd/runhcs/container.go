@@ -78,6 +78,19 @@ const (
 	keyPidMapFmt = "pid-%d"
 )
 
+// containerStateMigrator is the regstate.Migrator for persistedState. It is
+// currently a no-op chain: persistedState hasn't changed shape since the
+// migration framework was introduced, so CurrentVersion just marks "every
+// record written from here on has an envelope a future Migrator can build
+// on" -- there's nothing yet to upgrade a version 0 (pre-envelope) record
+// through.
+var containerStateMigrator = &regstate.Migrator{
+	CurrentVersion: 1,
+	Migrations: []regstate.Migration{
+		func(old []byte) ([]byte, error) { return old, nil },
+	},
+}
+
 type container struct {
 	persistedState
 	ShimPid   int
@@ -251,6 +264,12 @@ type containerConfig struct {
 	ShimLogFile, VMLogFile string
 	Spec                   *specs.Spec
 	VMConsolePipe          string
+
+	// VMRestoreStateFilePath is the path to a file written by a previous
+	// "runhcs checkpoint", used to restore the container's uVM instead of
+	// booting it fresh. Only applies when a new uVM is started for this
+	// container.
+	VMRestoreStateFilePath string
 }
 
 func createContainer(cfg *containerConfig) (_ *container, err error) {
@@ -368,7 +387,7 @@ func createContainer(cfg *containerConfig) (_ *container, err error) {
 			HostUniqueID:   hostUniqueID,
 		},
 	}
-	err = stateKey.Create(cfg.ID, keyState, &c.persistedState)
+	err = stateKey.CreateVersioned(cfg.ID, keyState, containerStateMigrator, &c.persistedState)
 	if err != nil {
 		return nil, err
 	}
@@ -400,8 +419,10 @@ func createContainer(cfg *containerConfig) (_ *container, err error) {
 		case *uvm.OptionsLCOW:
 			lopts := opts.(*uvm.OptionsLCOW)
 			lopts.ConsolePipe = cfg.VMConsolePipe
+			lopts.RestoreStateFilePath = cfg.VMRestoreStateFilePath
 		case *uvm.OptionsWCOW:
 			wopts := opts.(*uvm.OptionsWCOW)
+			wopts.RestoreStateFilePath = cfg.VMRestoreStateFilePath
 
 			// In order for the UVM sandbox.vhdx not to collide with the actual
 			// nested Argon sandbox.vhdx we append the \vm folder to the last entry
@@ -634,7 +655,7 @@ func (c *container) Exec() error {
 
 func getContainer(id string, notStopped bool) (*container, error) {
 	var c container
-	err := stateKey.Get(id, keyState, &c.persistedState)
+	err := stateKey.GetVersioned(id, keyState, containerStateMigrator, &c.persistedState)
 	if err != nil {
 		return nil, err
 	}
@@ -0,0 +1,138 @@
+package main
+
+import (
+	gcontext "context"
+	"encoding/json"
+	"os"
+	"time"
+
+	"github.com/Microsoft/hcsshim/internal/appargs"
+	"github.com/Microsoft/hcsshim/internal/schema1"
+	"github.com/urfave/cli"
+)
+
+// event and stats follow the JSON envelope emitted by runc's events command
+// (see github.com/containerd/go-runc's Event/Stats types): "type", "id" and a
+// "data" object whose cpu.usage total/kernel/user are in nanoseconds, as in
+// runc. The memory and storage sections carry Windows container statistics
+// and do not have the shape of runc's memory and blkio sections.
+type event struct {
+	Type  string `json:"type"`
+	ID    string `json:"id"`
+	Stats *stats `json:"data,omitempty"`
+}
+
+type stats struct {
+	Cpu     cpuStats     `json:"cpu"`
+	Memory  memoryStats  `json:"memory"`
+	Storage storageStats `json:"storage"`
+}
+
+type cpuStats struct {
+	Usage cpuUsage `json:"usage"`
+}
+
+type cpuUsage struct {
+	Total  uint64 `json:"total"`
+	Kernel uint64 `json:"kernel"`
+	User   uint64 `json:"user"`
+}
+
+type memoryStats struct {
+	Usage memoryUsage `json:"usage"`
+}
+
+type memoryUsage struct {
+	Commit     uint64 `json:"commit"`
+	CommitPeak uint64 `json:"commitPeak"`
+	PrivateWS  uint64 `json:"privateWorkingSet"`
+}
+
+type storageStats struct {
+	ReadCountNormalized  uint64 `json:"readCountNormalized"`
+	ReadSizeBytes        uint64 `json:"readSizeBytes"`
+	WriteCountNormalized uint64 `json:"writeCountNormalized"`
+	WriteSizeBytes       uint64 `json:"writeSizeBytes"`
+}
+
+func statsFromProperties(s schema1.Statistics) *stats {
+	return &stats{
+		Cpu: cpuStats{
+			Usage: cpuUsage{
+				Total:  s.Processor.TotalRuntime100ns * 100,
+				Kernel: s.Processor.RuntimeKernel100ns * 100,
+				User:   s.Processor.RuntimeUser100ns * 100,
+			},
+		},
+		Memory: memoryStats{
+			Usage: memoryUsage{
+				Commit:     s.Memory.UsageCommitBytes,
+				CommitPeak: s.Memory.UsageCommitPeakBytes,
+				PrivateWS:  s.Memory.UsagePrivateWorkingSetBytes,
+			},
+		},
+		Storage: storageStats{
+			ReadCountNormalized:  s.Storage.ReadCountNormalized,
+			ReadSizeBytes:        s.Storage.ReadSizeBytes,
+			WriteCountNormalized: s.Storage.WriteCountNormalized,
+			WriteSizeBytes:       s.Storage.WriteSizeBytes,
+		},
+	}
+}
+
+var eventsCommand = cli.Command{
+	Name:  "events",
+	Usage: "displays container cpu, memory, and IO usage statistics",
+	ArgsUsage: `<container-id>
+
+Where "<container-id>" is the name for the instance of the container.`,
+	Description: `The events command displays information about the container. By default the
+information is displayed every 5 seconds. Only "stats" events are emitted;
+container state changes such as OOM notifications are not reported.`,
+	Flags: []cli.Flag{
+		cli.DurationFlag{
+			Name:  "interval",
+			Value: 5 * time.Second,
+			Usage: "set the stats collection interval",
+		},
+		cli.BoolFlag{
+			Name:  "stats",
+			Usage: "display the container's stats then exit",
+		},
+	},
+	Before: appargs.Validate(argID),
+	Action: func(context *cli.Context) error {
+		id := context.Args().First()
+		interval := context.Duration("interval")
+		enc := json.NewEncoder(os.Stdout)
+
+		emitStats := func() error {
+			container, err := getContainer(id, true)
+			if err != nil {
+				return err
+			}
+			defer container.Close()
+
+			props, err := container.hc.Properties(gcontext.Background(), schema1.PropertyTypeStatistics)
+			if err != nil {
+				return err
+			}
+			return enc.Encode(&event{
+				Type:  "stats",
+				ID:    id,
+				Stats: statsFromProperties(props.Statistics),
+			})
+		}
+
+		if context.Bool("stats") {
+			return emitStats()
+		}
+
+		for {
+			if err := emitStats(); err != nil {
+				return err
+			}
+			time.Sleep(interval)
+		}
+	},
+}
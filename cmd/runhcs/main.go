@@ -100,10 +100,11 @@ func main() {
 		},
 	}
 	app.Commands = []cli.Command{
+		checkpointCommand,
 		createCommand,
 		createScratchCommand,
 		deleteCommand,
-		// eventsCommand,
+		eventsCommand,
 		execCommand,
 		killCommand,
 		listCommand,
@@ -111,6 +112,7 @@ func main() {
 		prepareDiskCommand,
 		psCommand,
 		resizeTtyCommand,
+		restoreCommand,
 		resumeCommand,
 		runCommand,
 		shimCommand,
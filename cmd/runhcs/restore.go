@@ -0,0 +1,66 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/Microsoft/hcsshim/internal/appargs"
+	"github.com/urfave/cli"
+)
+
+var restoreCommand = cli.Command{
+	Name:  "restore",
+	Usage: "restores a container from a checkpoint image path",
+	ArgsUsage: `<container-id> <image-path>
+
+Where "<container-id>" is your name for the instance of the container that
+you are restoring, and "<image-path>" is a directory previously written by
+"runhcs checkpoint".
+
+The name you provide for the container instance must be unique on your host.`,
+	Description: `The restore command creates an instance of a container from a checkpoint
+taken by "runhcs checkpoint". As with "runhcs create", the bundle is a
+directory with a specification file named "` + specConfig + `" and a root
+filesystem.
+
+Restore is only supported for hypervisor-isolated containers: the uVM hosting
+the container is restored from the image path's saved state. There is no
+restore path yet for process-isolated (Argon) containers.
+
+If one or more "--fetch-from" paths are given and the image path does not
+already have a checkpoint, runhcs pulls one on demand: it tries each path in
+turn for a same-named subdirectory written by "runhcs checkpoint
+--replicate-to", copies it into the image path, and verifies it against the
+checkpoint's checksum manifest before restoring from it.`,
+	Flags: append(createRunFlags, cli.StringSliceFlag{
+		Name:  "fetch-from",
+		Usage: "peer path(s) to pull the checkpoint from on demand if it isn't already present at <image-path>",
+	}),
+	Before: appargs.Validate(argID, argID),
+	Action: func(context *cli.Context) error {
+		imagePath := context.Args().Get(1)
+		if _, err := os.Stat(filepath.Join(imagePath, vmSaveStateFileName)); err != nil {
+			if peers := context.StringSlice("fetch-from"); len(peers) > 0 {
+				if ferr := fetchFromPeers(imagePath, peers); ferr != nil {
+					return ferr
+				}
+			} else {
+				return err
+			}
+		}
+
+		if err := verifyChecksumManifest(imagePath); err != nil {
+			return fmt.Errorf("checkpoint at %q failed integrity verification: %w", imagePath, err)
+		}
+
+		cfg, err := containerConfigFromContext(context)
+		if err != nil {
+			return err
+		}
+		cfg.VMRestoreStateFilePath = filepath.Join(imagePath, vmSaveStateFileName)
+
+		_, err = createContainer(cfg)
+		return err
+	},
+}
@@ -0,0 +1,192 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// checksumManifestFileName is written into an image path by checkpoint
+// alongside the save state files, and read back by restore (and by
+// replicateToPeers/fetchFromPeers) to confirm nothing was corrupted or
+// truncated in transit to or from a peer node.
+const checksumManifestFileName = "checksums.sha256"
+
+// writeChecksumManifest hashes every regular file already present in
+// imagePath (other than the manifest itself) and writes the result to
+// checksumManifestFileName in the same directory, one "<hex digest>  <name>"
+// line per file, sorted by name for a stable diff.
+func writeChecksumManifest(imagePath string) error {
+	entries, err := ioutil.ReadDir(imagePath)
+	if err != nil {
+		return err
+	}
+
+	var names []string
+	for _, e := range entries {
+		if e.IsDir() || e.Name() == checksumManifestFileName {
+			continue
+		}
+		names = append(names, e.Name())
+	}
+	sort.Strings(names)
+
+	var sb strings.Builder
+	for _, name := range names {
+		sum, err := hashFile(filepath.Join(imagePath, name))
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(&sb, "%s  %s\n", sum, name)
+	}
+
+	return ioutil.WriteFile(filepath.Join(imagePath, checksumManifestFileName), []byte(sb.String()), 0o644)
+}
+
+// verifyChecksumManifest re-hashes every file listed in imagePath's
+// checksumManifestFileName and returns an error naming the first one whose
+// contents no longer match. If imagePath has no checksum manifest -- a
+// checkpoint that was never pushed through replicateToPeers/fetchFromPeers
+// only gets one (see checkpointCommand's "--replicate-to"), and one written
+// by a runhcs without this feature never will -- there is nothing to verify
+// against, so this returns nil rather than failing the checkpoint as
+// corrupt.
+func verifyChecksumManifest(imagePath string) error {
+	manifest, err := ioutil.ReadFile(filepath.Join(imagePath, checksumManifestFileName))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return err
+	}
+
+	for _, line := range strings.Split(strings.TrimRight(string(manifest), "\n"), "\n") {
+		if line == "" {
+			continue
+		}
+		fields := strings.SplitN(line, "  ", 2)
+		if len(fields) != 2 {
+			return fmt.Errorf("malformed checksum manifest entry %q", line)
+		}
+		want, name := fields[0], fields[1]
+
+		got, err := hashFile(filepath.Join(imagePath, name))
+		if err != nil {
+			return err
+		}
+		if got != want {
+			return fmt.Errorf("checksum mismatch for %q: manifest has %s, found %s", name, want, got)
+		}
+	}
+	return nil
+}
+
+func hashFile(path string) (string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+
+	h := sha256.New()
+	if _, err := io.Copy(h, f); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// replicateToPeers copies every file in imagePath (including the checksum
+// manifest written by writeChecksumManifest) into the same-named
+// subdirectory of each path in peers, then verifies the copy against the
+// manifest before moving on to the next peer. peers may be local paths or
+// any path the OS resolves transparently, such as a UNC path to a share on
+// another node -- runhcs has no network client of its own, so "pushing to a
+// peer node" means the peer's image directory is reachable as a path.
+func replicateToPeers(imagePath string, peers []string) error {
+	entries, err := ioutil.ReadDir(imagePath)
+	if err != nil {
+		return err
+	}
+
+	for _, peer := range peers {
+		dst := filepath.Join(peer, filepath.Base(imagePath))
+		if err := os.MkdirAll(dst, 0); err != nil {
+			return fmt.Errorf("replicate to %q: %w", peer, err)
+		}
+		for _, e := range entries {
+			if e.IsDir() {
+				continue
+			}
+			if err := copyFile(filepath.Join(imagePath, e.Name()), filepath.Join(dst, e.Name())); err != nil {
+				return fmt.Errorf("replicate to %q: %w", peer, err)
+			}
+		}
+		if err := verifyChecksumManifest(dst); err != nil {
+			return fmt.Errorf("replicate to %q: copy failed verification: %w", peer, err)
+		}
+	}
+	return nil
+}
+
+// fetchFromPeers pulls an image path on demand: it tries each peer in turn
+// for a same-named subdirectory, copies it into imagePath, and verifies the
+// copy against the checksum manifest, returning as soon as one peer
+// succeeds. It returns an error naming every peer tried if none have it.
+func fetchFromPeers(imagePath string, peers []string) error {
+	var errs []string
+	for _, peer := range peers {
+		src := filepath.Join(peer, filepath.Base(imagePath))
+		entries, err := ioutil.ReadDir(src)
+		if err != nil {
+			errs = append(errs, fmt.Sprintf("%s: %v", peer, err))
+			continue
+		}
+
+		if err := os.MkdirAll(imagePath, 0); err != nil {
+			return err
+		}
+		ok := true
+		for _, e := range entries {
+			if e.IsDir() {
+				continue
+			}
+			if err := copyFile(filepath.Join(src, e.Name()), filepath.Join(imagePath, e.Name())); err != nil {
+				errs = append(errs, fmt.Sprintf("%s: %v", peer, err))
+				ok = false
+				break
+			}
+		}
+		if !ok {
+			continue
+		}
+		if err := verifyChecksumManifest(imagePath); err != nil {
+			errs = append(errs, fmt.Sprintf("%s: %v", peer, err))
+			continue
+		}
+		return nil
+	}
+	return fmt.Errorf("fetch from peers failed: %s", strings.Join(errs, "; "))
+}
+
+func copyFile(src, dst string) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+
+	out, err := os.Create(dst)
+	if err != nil {
+		return err
+	}
+	defer out.Close()
+
+	_, err = io.Copy(out, in)
+	return err
+}
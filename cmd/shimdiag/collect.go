@@ -0,0 +1,103 @@
+package main
+
+import (
+	"archive/zip"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"github.com/Microsoft/hcsshim/internal/appargs"
+	"github.com/Microsoft/hcsshim/internal/hcs"
+	"github.com/Microsoft/hcsshim/internal/hns"
+	"github.com/Microsoft/hcsshim/internal/schema1"
+	"github.com/Microsoft/hcsshim/internal/shimdiag"
+	"github.com/urfave/cli"
+)
+
+var collectSupportBundleCommand = cli.Command{
+	Name:      "collect-support-bundle",
+	Usage:     "Gathers shim/guest stacks, HCS properties, and HNS endpoint state for a sandbox into a zip file",
+	ArgsUsage: "<shim name> <output zip path>",
+	Before:    appargs.Validate(appargs.String, appargs.String),
+	Action: func(c *cli.Context) error {
+		args := c.Args()
+		shimName := args[0]
+		outPath := args[1]
+
+		out, err := os.Create(outPath)
+		if err != nil {
+			return err
+		}
+		defer out.Close()
+
+		zw := zip.NewWriter(out)
+		defer zw.Close()
+
+		shim, err := getShim(shimName)
+		if err != nil {
+			return err
+		}
+		svc := shimdiag.NewShimDiagClient(shim)
+		resp, err := svc.DiagStacks(context.Background(), &shimdiag.StacksRequest{})
+		if err != nil {
+			fmt.Printf("warning: failed to collect stacks: %s\n", err)
+		} else {
+			if err := writeZipEntry(zw, "stacks.txt", resp.Stacks); err != nil {
+				return err
+			}
+			if resp.GuestStacks != "" {
+				if err := writeZipEntry(zw, "guest-stacks.txt", resp.GuestStacks); err != nil {
+					return err
+				}
+			}
+		}
+
+		// The shim name is the sandbox/container's compute system ID, so it
+		// doubles as the ID to look up HCS properties for.
+		system, err := hcs.OpenComputeSystem(context.Background(), shimName)
+		if err != nil {
+			fmt.Printf("warning: failed to open compute system %s: %s\n", shimName, err)
+		} else {
+			defer system.Close()
+			props, err := system.Properties(context.Background(), schema1.PropertyTypeStatistics, schema1.PropertyTypeProcessList)
+			if err != nil {
+				fmt.Printf("warning: failed to query HCS properties: %s\n", err)
+			} else if err := writeZipEntryJSON(zw, "hcs-properties.json", props); err != nil {
+				return err
+			}
+		}
+
+		// HNS has no API to scope endpoints to a single sandbox, so the full
+		// host-wide endpoint list is included for the operator to correlate
+		// against the sandbox's IP/MAC from the HCS properties above.
+		endpoints, err := hns.HNSListEndpointRequest()
+		if err != nil {
+			fmt.Printf("warning: failed to list HNS endpoints: %s\n", err)
+		} else if err := writeZipEntryJSON(zw, "hns-endpoints.json", endpoints); err != nil {
+			return err
+		}
+
+		fmt.Printf("Wrote support bundle for %s to %s\n", shimName, outPath)
+		return nil
+	},
+}
+
+func writeZipEntry(zw *zip.Writer, name, contents string) error {
+	w, err := zw.Create(name)
+	if err != nil {
+		return err
+	}
+	_, err = w.Write([]byte(contents))
+	return err
+}
+
+func writeZipEntryJSON(zw *zip.Writer, name string, v interface{}) error {
+	w, err := zw.Create(name)
+	if err != nil {
+		return err
+	}
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(v)
+}
@@ -0,0 +1,353 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"strconv"
+	"strings"
+
+	winio "github.com/Microsoft/go-winio"
+	"github.com/Microsoft/go-winio/pkg/guid"
+	"github.com/Microsoft/hcsshim/internal/appargs"
+	"github.com/Microsoft/hcsshim/internal/shimdiag"
+	"github.com/urfave/cli"
+)
+
+var copyFromGuest bool
+var copyResume bool
+
+var copyCommand = cli.Command{
+	Name:      "copy",
+	Usage:     "copies a file between the local machine and a shim's hosting utility VM",
+	ArgsUsage: "<shim name> <local-path> <guest-path>",
+	Description: `The copy command streams a file over the same host-process stdio pipes
+"shimdiag exec" uses, and compares a sha256 digest of what it sent (or
+received) against one taken inside the guest, so a truncated or corrupted
+transfer is caught instead of silently accepted.
+
+With "--resume", an interrupted transfer can be continued: the bytes already
+present at the destination are digested and compared against the matching
+prefix of the source, and only the remainder is sent if they agree.
+
+This only works against a Linux utility VM's /bin/sh -- a Windows guest's
+cmd.exe has no equivalent binary-safe way to write its stdin to a file, so
+copying into or out of a WCOW uVM isn't supported here.`,
+	Flags: []cli.Flag{
+		cli.BoolFlag{
+			Name:        "from-guest",
+			Usage:       "treat <guest-path> as the source and <local-path> as the destination",
+			Destination: &copyFromGuest,
+		},
+		cli.BoolFlag{
+			Name:        "resume",
+			Usage:       "skip the bytes already present (and digest-verified) at the destination",
+			Destination: &copyResume,
+		},
+	},
+	Before: appargs.Validate(appargs.String, appargs.String, appargs.String),
+	Action: func(clictx *cli.Context) error {
+		args := clictx.Args()
+		shim, err := getShim(args[0])
+		if err != nil {
+			return err
+		}
+		svc := shimdiag.NewShimDiagClient(shim)
+
+		ctx, cancel := context.WithCancel(context.Background())
+		ch := make(chan os.Signal, 1)
+		signal.Notify(ch, os.Interrupt)
+		go func() {
+			<-ch
+			cancel()
+		}()
+
+		localPath, guestPath := args[1], args[2]
+		if copyFromGuest {
+			return copyFromGuestToLocal(ctx, svc, guestPath, localPath)
+		}
+		return copyLocalToGuest(ctx, svc, localPath, guestPath)
+	},
+}
+
+func copyLocalToGuest(ctx context.Context, svc shimdiag.ShimDiagService, localPath, guestPath string) error {
+	f, err := os.Open(localPath)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	var offset int64
+	if copyResume {
+		size, err := guestFileSize(ctx, svc, guestPath)
+		if err != nil {
+			return err
+		}
+		if size > 0 {
+			localSum, err := localDigestRange(f, 0, size)
+			if err != nil {
+				return err
+			}
+			guestSum, err := guestDigestFrom(ctx, svc, guestPath, 0)
+			if err != nil {
+				return err
+			}
+			if localSum == guestSum {
+				offset = size
+			}
+		}
+	}
+	if _, err := f.Seek(offset, io.SeekStart); err != nil {
+		return err
+	}
+
+	redirect := ">"
+	if offset > 0 {
+		redirect = ">>"
+	}
+
+	h := sha256.New()
+	ec, err := runGuestShell(ctx, svc, fmt.Sprintf("cat %s %s", redirect, shellQuote(guestPath)), io.TeeReader(f, h), nil)
+	if err != nil {
+		return err
+	}
+	if ec != 0 {
+		return fmt.Errorf("guest cat exited with code %d", ec)
+	}
+
+	guestSum, err := guestDigestFrom(ctx, svc, guestPath, offset)
+	if err != nil {
+		return err
+	}
+	if hex.EncodeToString(h.Sum(nil)) != guestSum {
+		return fmt.Errorf("copy verification failed: digest of bytes sent does not match the guest's")
+	}
+	return nil
+}
+
+func copyFromGuestToLocal(ctx context.Context, svc shimdiag.ShimDiagService, guestPath, localPath string) error {
+	var offset int64
+	if copyResume {
+		if fi, err := os.Stat(localPath); err == nil && fi.Size() > 0 {
+			localSum, err := localDigestHead(localPath, fi.Size())
+			if err != nil {
+				return err
+			}
+			guestSum, err := guestDigestHead(ctx, svc, guestPath, fi.Size())
+			if err != nil {
+				return err
+			}
+			if localSum == guestSum {
+				offset = fi.Size()
+			}
+		}
+	}
+
+	flags := os.O_CREATE | os.O_WRONLY | os.O_TRUNC
+	script := fmt.Sprintf("cat -- %s", shellQuote(guestPath))
+	if offset > 0 {
+		flags = os.O_CREATE | os.O_WRONLY | os.O_APPEND
+		script = fmt.Sprintf("tail -c +%d -- %s", offset+1, shellQuote(guestPath))
+	}
+	out, err := os.OpenFile(localPath, flags, 0o644)
+	if err != nil {
+		return err
+	}
+	defer out.Close()
+
+	h := sha256.New()
+	ec, err := runGuestShell(ctx, svc, script, nil, io.MultiWriter(out, h))
+	if err != nil {
+		return err
+	}
+	if ec != 0 {
+		return fmt.Errorf("guest transfer command exited with code %d", ec)
+	}
+
+	guestSum, err := guestDigestFrom(ctx, svc, guestPath, offset)
+	if err != nil {
+		return err
+	}
+	if hex.EncodeToString(h.Sum(nil)) != guestSum {
+		return fmt.Errorf("copy verification failed: digest of bytes received does not match the guest's")
+	}
+	return nil
+}
+
+// guestFileSize returns guestPath's size in the guest, or 0 if it doesn't
+// exist there yet.
+func guestFileSize(ctx context.Context, svc shimdiag.ShimDiagService, path string) (int64, error) {
+	var out bytes.Buffer
+	ec, err := runGuestShell(ctx, svc, fmt.Sprintf("wc -c < %s 2>/dev/null || echo 0", shellQuote(path)), nil, &out)
+	if err != nil {
+		return 0, err
+	}
+	if ec != 0 {
+		return 0, fmt.Errorf("guest file size command exited with code %d", ec)
+	}
+	return strconv.ParseInt(strings.TrimSpace(out.String()), 10, 64)
+}
+
+// guestDigestFrom returns the sha256 digest, taken in the guest, of path's
+// bytes starting at offset (the whole file, if offset is 0).
+func guestDigestFrom(ctx context.Context, svc shimdiag.ShimDiagService, path string, offset int64) (string, error) {
+	script := fmt.Sprintf("sha256sum -- %s", shellQuote(path))
+	if offset > 0 {
+		script = fmt.Sprintf("tail -c +%d -- %s | sha256sum", offset+1, shellQuote(path))
+	}
+	return guestDigestOutput(ctx, svc, script)
+}
+
+// guestDigestHead returns the sha256 digest, taken in the guest, of path's
+// first n bytes.
+func guestDigestHead(ctx context.Context, svc shimdiag.ShimDiagService, path string, n int64) (string, error) {
+	return guestDigestOutput(ctx, svc, fmt.Sprintf("head -c %d -- %s | sha256sum", n, shellQuote(path)))
+}
+
+func guestDigestOutput(ctx context.Context, svc shimdiag.ShimDiagService, script string) (string, error) {
+	var out bytes.Buffer
+	ec, err := runGuestShell(ctx, svc, script, nil, &out)
+	if err != nil {
+		return "", err
+	}
+	if ec != 0 {
+		return "", fmt.Errorf("guest digest command exited with code %d", ec)
+	}
+	fields := strings.Fields(out.String())
+	if len(fields) == 0 {
+		return "", fmt.Errorf("guest produced no digest output")
+	}
+	return fields[0], nil
+}
+
+func localDigestRange(f *os.File, start, end int64) (string, error) {
+	if _, err := f.Seek(start, io.SeekStart); err != nil {
+		return "", err
+	}
+	h := sha256.New()
+	if _, err := io.CopyN(h, f, end-start); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+func localDigestHead(path string, n int64) (string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+	return localDigestRange(f, 0, n)
+}
+
+func shellQuote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
+
+// runGuestShell runs script via /bin/sh -c in the uVM hosting shim, streaming
+// stdin to it (if non-nil) and its stdout to stdout (if non-nil), and only
+// returns once both have been fully drained. Its stderr is not captured.
+func runGuestShell(ctx context.Context, svc shimdiag.ShimDiagService, script string, stdin io.Reader, stdout io.Writer) (int32, error) {
+	req := &shimdiag.ExecProcessRequest{Args: []string{"/bin/sh", "-c", script}}
+
+	var stdinDone, stdoutDone <-chan error
+	if stdin != nil {
+		pipe, done, err := namedPipeSource(stdin)
+		if err != nil {
+			return 0, err
+		}
+		req.Stdin = pipe
+		stdinDone = done
+	}
+	if stdout != nil {
+		pipe, done, err := namedPipeSink(stdout)
+		if err != nil {
+			return 0, err
+		}
+		req.Stdout = pipe
+		stdoutDone = done
+	}
+
+	resp, err := svc.DiagExecInHost(ctx, req)
+	if err != nil {
+		return 0, err
+	}
+
+	if stdinDone != nil {
+		if err := <-stdinDone; err != nil && err != io.EOF {
+			return 0, fmt.Errorf("streaming stdin to guest: %w", err)
+		}
+	}
+	if stdoutDone != nil {
+		if err := <-stdoutDone; err != nil && err != io.EOF {
+			return 0, fmt.Errorf("streaming stdout from guest: %w", err)
+		}
+	}
+	return resp.ExitCode, nil
+}
+
+func newPipeName() (string, error) {
+	g, err := guid.NewV4()
+	if err != nil {
+		return "", err
+	}
+	return `\\.\pipe\` + g.String(), nil
+}
+
+// namedPipeSource listens on a new named pipe, and once the peer named in
+// the ExecProcessRequest connects, copies r into it and signals done.
+func namedPipeSource(r io.Reader) (string, <-chan error, error) {
+	p, err := newPipeName()
+	if err != nil {
+		return "", nil, err
+	}
+	l, err := winio.ListenPipe(p, nil)
+	if err != nil {
+		return "", nil, err
+	}
+	done := make(chan error, 1)
+	go func() {
+		defer l.Close()
+		c, err := l.Accept()
+		if err != nil {
+			done <- err
+			return
+		}
+		defer c.Close()
+		_, err = io.Copy(c, r)
+		done <- err
+	}()
+	return p, done, nil
+}
+
+// namedPipeSink is namedPipeSource's counterpart for the guest's stdout:
+// once the peer connects, it copies the pipe's contents into w and signals
+// done.
+func namedPipeSink(w io.Writer) (string, <-chan error, error) {
+	p, err := newPipeName()
+	if err != nil {
+		return "", nil, err
+	}
+	l, err := winio.ListenPipe(p, nil)
+	if err != nil {
+		return "", nil, err
+	}
+	done := make(chan error, 1)
+	go func() {
+		defer l.Close()
+		c, err := l.Accept()
+		if err != nil {
+			done <- err
+			return
+		}
+		defer c.Close()
+		_, err = io.Copy(w, c)
+		done <- err
+	}()
+	return p, done, nil
+}
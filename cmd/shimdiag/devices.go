@@ -0,0 +1,41 @@
+package main
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/Microsoft/hcsshim/internal/appargs"
+	"github.com/Microsoft/hcsshim/internal/shimdiag"
+	"github.com/urfave/cli"
+)
+
+var listDevicesCommand = cli.Command{
+	Name:      "devices",
+	Usage:     "Lists the devices currently assigned to a shim's hosting utility VM",
+	ArgsUsage: "<shim name>",
+	Before:    appargs.Validate(appargs.String),
+	Action: func(c *cli.Context) error {
+		shimName := c.Args()[0]
+
+		shim, err := getShim(shimName)
+		if err != nil {
+			return err
+		}
+
+		svc := shimdiag.NewShimDiagClient(shim)
+		resp, err := svc.DiagListDevices(context.Background(), &shimdiag.DiagDevicesRequest{})
+		if err != nil {
+			return fmt.Errorf("failed to list devices for %s: %s", shimName, err)
+		}
+
+		for _, d := range resp.Devices {
+			switch d.Kind {
+			case "scsi":
+				fmt.Printf("scsi\tcontroller=%d\tlun=%d\t%s\n", d.Controller, d.Lun, d.BackingFile)
+			default:
+				fmt.Printf("%s\t%s\n", d.Kind, d.InstancePath)
+			}
+		}
+		return nil
+	},
+}
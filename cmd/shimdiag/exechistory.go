@@ -0,0 +1,51 @@
+package main
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/Microsoft/hcsshim/internal/appargs"
+	"github.com/Microsoft/hcsshim/internal/shimdiag"
+	"github.com/urfave/cli"
+)
+
+var execHistoryCommand = cli.Command{
+	Name:      "exec-history",
+	Usage:     "Shows recorded task/exec lifecycle transitions for a shim",
+	ArgsUsage: "<shim name>",
+	Flags: []cli.Flag{
+		cli.StringFlag{
+			Name:  "task-id,t",
+			Usage: "Restrict the result to this task id",
+		},
+		cli.StringFlag{
+			Name:  "exec-id,e",
+			Usage: "Restrict the result to this exec id",
+		},
+	},
+	Before: appargs.Validate(appargs.String),
+	Action: func(c *cli.Context) error {
+		shimName := c.Args()[0]
+
+		shim, err := getShim(shimName)
+		if err != nil {
+			return err
+		}
+
+		req := &shimdiag.DiagExecHistoryRequest{
+			TaskId: c.String("task-id"),
+			ExecId: c.String("exec-id"),
+		}
+
+		svc := shimdiag.NewShimDiagClient(shim)
+		resp, err := svc.DiagExecHistory(context.Background(), req)
+		if err != nil {
+			return fmt.Errorf("failed to get exec history for %s: %s", shimName, err)
+		}
+
+		for _, e := range resp.Entries {
+			fmt.Printf("%s\ttid=%s\teid=%s\t%s\texit=%d\t%s\n", e.Timestamp, e.TaskId, e.ExecId, e.Event, e.ExitStatus, e.Error)
+		}
+		return nil
+	},
+}
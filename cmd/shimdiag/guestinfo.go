@@ -0,0 +1,40 @@
+package main
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/Microsoft/hcsshim/internal/appargs"
+	"github.com/Microsoft/hcsshim/internal/shimdiag"
+	"github.com/urfave/cli"
+)
+
+var guestInfoCommand = cli.Command{
+	Name:      "guestinfo",
+	Usage:     "Shows what's known about a shim's hosting utility VM's connected guest",
+	ArgsUsage: "<shim name>",
+	Before:    appargs.Validate(appargs.String),
+	Action: func(c *cli.Context) error {
+		shimName := c.Args()[0]
+
+		shim, err := getShim(shimName)
+		if err != nil {
+			return err
+		}
+
+		svc := shimdiag.NewShimDiagClient(shim)
+		resp, err := svc.DiagGuestInfo(context.Background(), &shimdiag.DiagGuestInfoRequest{})
+		if err != nil {
+			return fmt.Errorf("failed to get guest info for %s: %s", shimName, err)
+		}
+
+		fmt.Printf("os\t%s\n", resp.Os)
+		fmt.Printf("protocol version\t%d\n", resp.ProtocolVersion)
+		fmt.Printf("signal process supported\t%t\n", resp.SignalProcessSupported)
+		fmt.Printf("dump stacks supported\t%t\n", resp.DumpStacksSupported)
+		fmt.Printf("delete container state supported\t%t\n", resp.DeleteContainerStateSupported)
+		fmt.Printf("update container supported\t%t\n", resp.UpdateContainerSupported)
+		fmt.Printf("namespace add request supported\t%t\n", resp.NamespaceAddRequestSupported)
+		return nil
+	},
+}
@@ -0,0 +1,53 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/Microsoft/hcsshim/internal/appargs"
+	"github.com/Microsoft/hcsshim/internal/shimdiag"
+	"github.com/urfave/cli"
+)
+
+var setLogLevelCommand = cli.Command{
+	Name:      "set-log-level",
+	Usage:     "Change the log level (and debug categories) of a shim's hosting utility VM guest",
+	ArgsUsage: "<shim name> <level>",
+	Flags: []cli.Flag{
+		cli.StringFlag{
+			Name:  "categories,c",
+			Usage: "Comma separated list of guest debug categories to enable at <level>",
+		},
+	},
+	Before: appargs.Validate(appargs.String, appargs.String),
+	Action: func(c *cli.Context) error {
+		args := c.Args()
+		shimName := args[0]
+		level := args[1]
+
+		shim, err := getShim(shimName)
+		if err != nil {
+			return err
+		}
+
+		var categories []string
+		if raw := c.String("categories"); raw != "" {
+			categories = strings.Split(raw, ",")
+		}
+
+		req := &shimdiag.SetLogLevelRequest{
+			Level:      level,
+			Categories: categories,
+		}
+
+		svc := shimdiag.NewShimDiagClient(shim)
+		_, err = svc.DiagSetLogLevel(context.Background(), req)
+		if err != nil {
+			return fmt.Errorf("failed to set guest log level for %s: %s", shimName, err)
+		}
+
+		fmt.Printf("Set guest log level for %s to %s\n", shimName, level)
+		return nil
+	},
+}
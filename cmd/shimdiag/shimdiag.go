@@ -25,8 +25,14 @@ func main() {
 	app.Commands = []cli.Command{
 		listCommand,
 		execCommand,
+		copyCommand,
 		stacksCommand,
 		shareCommand,
+		setLogLevelCommand,
+		listDevicesCommand,
+		guestInfoCommand,
+		execHistoryCommand,
+		collectSupportBundleCommand,
 	}
 	if err := app.Run(os.Args); err != nil {
 		fmt.Fprintln(os.Stderr, err)
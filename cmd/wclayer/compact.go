@@ -0,0 +1,31 @@
+package main
+
+import (
+	gocontext "context"
+	"fmt"
+
+	"github.com/Microsoft/hcsshim/computestorage"
+	"github.com/Microsoft/hcsshim/internal/appargs"
+	"github.com/urfave/cli"
+)
+
+var compactCommand = cli.Command{
+	Name:      "compact",
+	Usage:     "reclaims unused space in a scratch VHD/VHDX without changing its virtual size",
+	ArgsUsage: "<vhd path>",
+	Description: `compact is a maintenance operation for long-lived scratch VHDX files
+(e.g. sandbox.vhdx for a container that is recreated rarely), run manually or
+from an external scheduler while the container is known to be idle. The disk
+must not be attached anywhere when this is run.`,
+	Before: appargs.Validate(appargs.NonEmptyString),
+	Action: func(context *cli.Context) error {
+		path := context.Args().First()
+
+		if err := computestorage.CompactVHD(gocontext.Background(), path); err != nil {
+			return err
+		}
+
+		fmt.Printf("Compacted %s\n", path)
+		return nil
+	},
+}
@@ -0,0 +1,35 @@
+package main
+
+import (
+	gocontext "context"
+	"fmt"
+
+	"github.com/Microsoft/hcsshim/computestorage"
+	"github.com/Microsoft/hcsshim/internal/appargs"
+	"github.com/urfave/cli"
+)
+
+var inspectCommand = cli.Command{
+	Name:      "inspect",
+	Usage:     "reads the virtual size, block size, parent locator, and change-tracking state of a VHD/VHDX",
+	ArgsUsage: "<vhd path>",
+	Before:    appargs.Validate(appargs.NonEmptyString),
+	Action: func(context *cli.Context) error {
+		path := context.Args().First()
+
+		info, err := computestorage.GetVHDInfo(gocontext.Background(), path)
+		if err != nil {
+			return err
+		}
+
+		fmt.Printf("Virtual size: %d bytes\n", info.VirtualSize)
+		fmt.Printf("Physical size: %d bytes\n", info.PhysicalSize)
+		fmt.Printf("Block size: %d bytes\n", info.BlockSize)
+		fmt.Printf("Sector size: %d bytes\n", info.SectorSize)
+		if info.ParentPath != "" {
+			fmt.Printf("Parent: %s\n", info.ParentPath)
+		}
+		fmt.Printf("Change tracking enabled: %t\n", info.ChangeTrackingEnabled)
+		return nil
+	},
+}
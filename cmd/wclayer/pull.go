@@ -0,0 +1,29 @@
+package main
+
+import (
+	"errors"
+
+	"github.com/Microsoft/hcsshim/internal/appargs"
+	"github.com/urfave/cli"
+)
+
+var pullCommand = cli.Command{
+	Name:      "pull",
+	Usage:     "not available in this build: always returns an error, as wclayer has no OCI registry client",
+	ArgsUsage: "<image ref>",
+	Flags: []cli.Flag{
+		cli.StringFlag{
+			Name:  "layer-path, l",
+			Usage: "directory under which to store the pulled layers, one subdirectory per layer",
+		},
+	},
+	Before: appargs.Validate(appargs.NonEmptyString),
+	Action: func(context *cli.Context) error {
+		// Pulling requires resolving the reference against a registry and
+		// streaming its layer blobs, neither of which this build of wclayer
+		// has a client for. Until one is vendored, provision layers with
+		// `wclayer import` against tars obtained some other way (e.g.
+		// `ctr content fetch` plus `ctr images export`).
+		return errors.New("pull: no OCI registry client is available in this build of wclayer; use 'wclayer import' with a layer tar instead")
+	},
+}
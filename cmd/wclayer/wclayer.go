@@ -29,10 +29,13 @@ func main() {
 	app := cli.NewApp()
 	app.Name = "wclayer"
 	app.Commands = []cli.Command{
+		compactCommand,
 		createCommand,
 		exportCommand,
 		importCommand,
+		inspectCommand,
 		mountCommand,
+		pullCommand,
 		removeCommand,
 		unmountCommand,
 	}
@@ -0,0 +1,44 @@
+package computestorage
+
+import (
+	"context"
+	"syscall"
+
+	"github.com/Microsoft/go-winio/vhd"
+	"github.com/Microsoft/hcsshim/internal/oc"
+	"github.com/Microsoft/hcsshim/internal/winapi"
+	"github.com/pkg/errors"
+	"go.opencensus.io/trace"
+	"golang.org/x/sys/windows"
+)
+
+// CompactVHD reclaims unused space within the VHD/VHDX at `vhdPath` without
+// changing its logical (virtual) size, shrinking how much space it occupies
+// on its backing storage.
+//
+// This only compacts space the VHD/VHDX itself has already marked as free;
+// it does not by itself reclaim space a guest filesystem has deleted but not
+// yet told the virtual disk about. Getting guest-deleted blocks counted as
+// free requires the guest to issue a TRIM/UNMAP (e.g. via fstrim) against the
+// scratch disk first, which is outside the scope of this host-side API.
+//
+// The VHD/VHDX must not be attached anywhere when this is called.
+func CompactVHD(ctx context.Context, vhdPath string) (err error) {
+	title := "hcsshim.CompactVHD"
+	ctx, span := trace.StartSpan(ctx, title)
+	defer span.End()
+	defer func() { oc.SetSpanStatus(span, err) }()
+	span.AddAttributes(trace.StringAttribute("vhdPath", vhdPath))
+
+	handle, err := vhd.OpenVirtualDisk(vhdPath, vhd.VirtualDiskAccessAll, vhd.OpenVirtualDiskFlagNone)
+	if err != nil {
+		return errors.Wrap(err, "failed to open vhd for compaction")
+	}
+	defer syscall.CloseHandle(handle)
+
+	params := &winapi.CompactVirtualDiskParameters{Version: 1}
+	if err := winapi.CompactVirtualDisk(windows.Handle(handle), winapi.CompactVirtualDiskFlagNone, params, nil); err != nil {
+		return errors.Wrap(err, "failed to compact vhd")
+	}
+	return nil
+}
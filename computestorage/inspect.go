@@ -0,0 +1,117 @@
+package computestorage
+
+import (
+	"context"
+	"fmt"
+	"syscall"
+	"unsafe"
+
+	"github.com/Microsoft/go-winio/vhd"
+	"github.com/Microsoft/hcsshim/internal/oc"
+	"github.com/Microsoft/hcsshim/internal/winapi"
+	"github.com/pkg/errors"
+	"go.opencensus.io/trace"
+	"golang.org/x/sys/windows"
+)
+
+// VhdInfo is the metadata returned by GetVHDInfo.
+type VhdInfo struct {
+	// VirtualSize is the logical size of the VHD/VHDX, in bytes.
+	VirtualSize uint64
+	// PhysicalSize is the size the VHD/VHDX currently occupies on its
+	// backing storage, in bytes.
+	PhysicalSize uint64
+	// BlockSize is the size of an allocation block within the VHD/VHDX, in
+	// bytes. Zero for fixed disks.
+	BlockSize uint32
+	// SectorSize is the size of a virtual sector within the VHD/VHDX, in
+	// bytes.
+	SectorSize uint32
+	// ParentPath is the path of this VHD/VHDX's parent, if it's a
+	// differencing disk, and empty otherwise.
+	ParentPath string
+	// ChangeTrackingEnabled is true if resilient change tracking is enabled
+	// on this VHD/VHDX.
+	ChangeTrackingEnabled bool
+}
+
+// getVirtualDiskInformation calls GetVirtualDiskInformation for the given
+// info `version`, growing the output buffer and retrying until the call
+// succeeds or the buffer hits a sane upper bound. This is needed because the
+// two variable-length info versions (GetVirtualDiskInfoParentLocation,
+// GetVirtualDiskInfoChangeTrackingState) append a NUL-terminated string whose
+// length isn't known ahead of time.
+func getVirtualDiskInformation(handle windows.Handle, version uint32) ([]byte, error) {
+	size := uint32(winapi.VirtualDiskInfoVersionOffset) + 64
+	for {
+		buffer := make([]byte, size)
+		*(*uint32)(unsafe.Pointer(&buffer[0])) = version
+		used := size
+		err := winapi.GetVirtualDiskInformation(handle, &size, &buffer[0], &used)
+		if err == nil {
+			return buffer[:used], nil
+		}
+		if err != windows.ERROR_INSUFFICIENT_BUFFER || size > 1024*1024 {
+			return nil, err
+		}
+		// size has been updated in place with the required buffer size; retry.
+	}
+}
+
+// utf16BufferToString converts a NUL-terminated (or buffer-exhausting) UTF-16
+// byte buffer beginning at `buffer[offset:]` to a Go string.
+func utf16BufferToString(buffer []byte, offset int) string {
+	u16 := (*[1 << 20]uint16)(unsafe.Pointer(&buffer[offset]))[: (len(buffer)-offset)/2 : (len(buffer)-offset)/2]
+	return windows.UTF16ToString(u16)
+}
+
+// GetVHDInfo returns the virtual size, physical size, block size, sector
+// size, parent locator, and change-tracking state of the VHD/VHDX at
+// `vhdPath`, without attaching it.
+func GetVHDInfo(ctx context.Context, vhdPath string) (_ *VhdInfo, err error) {
+	title := "hcsshim.GetVHDInfo"
+	ctx, span := trace.StartSpan(ctx, title)
+	defer span.End()
+	defer func() { oc.SetSpanStatus(span, err) }()
+	span.AddAttributes(trace.StringAttribute("vhdPath", vhdPath))
+
+	handle, err := vhd.OpenVirtualDisk(vhdPath, vhd.VirtualDiskAccessGetInfo, vhd.OpenVirtualDiskFlagNone)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to open vhd for inspection")
+	}
+	defer syscall.CloseHandle(handle)
+
+	info := &VhdInfo{}
+
+	sizeBuf, err := getVirtualDiskInformation(windows.Handle(handle), winapi.GetVirtualDiskInfoSize)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get vhd size information: %s", err)
+	}
+	sizeData := (*winapi.GetVirtualDiskInfoSizeData)(unsafe.Pointer(&sizeBuf[winapi.VirtualDiskInfoVersionOffset]))
+	info.VirtualSize = sizeData.VirtualSize
+	info.PhysicalSize = sizeData.PhysicalSize
+	info.BlockSize = sizeData.BlockSize
+	info.SectorSize = sizeData.SectorSize
+
+	parentBuf, err := getVirtualDiskInformation(windows.Handle(handle), winapi.GetVirtualDiskInfoParentLocation)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get vhd parent location information: %s", err)
+	}
+	parentData := (*winapi.GetVirtualDiskInfoParentLocationData)(unsafe.Pointer(&parentBuf[winapi.VirtualDiskInfoVersionOffset]))
+	if parentData.ParentResolved != 0 {
+		info.ParentPath = utf16BufferToString(parentBuf, winapi.VirtualDiskInfoVersionOffset+int(unsafe.Sizeof(*parentData)))
+	}
+
+	ctBuf, err := getVirtualDiskInformation(windows.Handle(handle), winapi.GetVirtualDiskInfoChangeTrackingState)
+	if err != nil {
+		// Resilient change tracking requires VHDX; older VHD files (and
+		// some VHDX files created without RCT support) don't support this
+		// info version, so treat failure here as "not enabled" rather than
+		// a fatal error.
+		return info, nil
+	}
+	ctData := (*winapi.GetVirtualDiskInfoChangeTrackingStateData)(unsafe.Pointer(&ctBuf[winapi.VirtualDiskInfoVersionOffset]))
+	info.ChangeTrackingEnabled = ctData.Enabled != 0
+
+	return info, nil
+}
@@ -10,6 +10,7 @@ import (
 	"github.com/Microsoft/hcsshim/internal/hcs"
 	"github.com/Microsoft/hcsshim/internal/mergemaps"
 	"github.com/Microsoft/hcsshim/internal/schema1"
+	hcsschema "github.com/Microsoft/hcsshim/internal/schema2"
 )
 
 // ContainerProperties holds the properties for a container and the processes running in that container
@@ -53,6 +54,33 @@ const (
 // Supported resource types are Network and Request Types are Add/Remove
 type ResourceModificationRequestResponse = schema1.ResourceModificationRequestResponse
 
+// PropertyType is used when querying for V2 schema container/uVM properties
+// with PropertiesV2.
+type PropertyType = hcsschema.PropertyType
+
+// PropertyType consts for use with PropertiesV2.
+const (
+	PTMemory                      = hcsschema.PTMemory
+	PTGuestMemory                 = hcsschema.PTGuestMemory
+	PTStatistics                  = hcsschema.PTStatistics
+	PTProcessList                 = hcsschema.PTProcessList
+	PTTerminateOnLastHandleClosed = hcsschema.PTTerminateOnLastHandleClosed
+	PTSharedMemoryRegion          = hcsschema.PTSharedMemoryRegion
+	PTContainerCredentialGuard    = hcsschema.PTContainerCredentialGuard
+	PTGuestConnection             = hcsschema.PTGuestConnection
+	PTICHeartbeatStatus           = hcsschema.PTICHeartbeatStatus
+	PTProcessorTopology           = hcsschema.PTProcessorTopology
+	PTCPUGroup                    = hcsschema.PTCPUGroup
+)
+
+// PropertiesV2 is the structure returned by a PropertiesV2 call on a
+// container or uVM. Unlike the legacy Statistics/ProcessList/
+// MappedVirtualDisks calls, which each return one fragment of the V1 schema's
+// ContainerProperties, this is the full V2 schema Properties struct,
+// including fields such as GuestConnectionInfo and RuntimeId (the uVM's silo
+// GUID) that have no V1 equivalent.
+type PropertiesV2 = hcsschema.Properties
+
 type container struct {
 	system   *hcs.System
 	waitOnce sync.Once
@@ -194,6 +222,20 @@ func (container *container) MappedVirtualDisks() (map[int]MappedVirtualDiskContr
 	return properties.MappedVirtualDiskControllers, nil
 }
 
+// PropertiesV2 returns the requested V2 schema container/uVM properties,
+// such as GuestConnectionInfo, Statistics, or RuntimeId (the silo GUID), as
+// typed structs. Unlike Statistics/ProcessList/MappedVirtualDisks, this
+// targets a V2 schema container and can return any combination of property
+// types in a single call.
+func (container *container) PropertiesV2(types ...PropertyType) (*PropertiesV2, error) {
+	properties, err := container.system.PropertiesV2(context.Background(), types...)
+	if err != nil {
+		return nil, convertSystemError(err, container)
+	}
+
+	return properties, nil
+}
+
 // CreateProcess launches a new process within the container.
 func (container *container) CreateProcess(c *ProcessConfig) (Process, error) {
 	p, err := container.system.CreateProcess(context.Background(), c)
@@ -126,45 +126,53 @@ func createEndpoint(networkId string, endpointSettings string) (*HostComputeEndp
 	if err != nil {
 		return nil, errInvalidNetworkID
 	}
-	// Open network.
-	var networkHandle hcnNetwork
-	var resultBuffer *uint16
-	hr := hcnOpenNetwork(&networkGuid, &networkHandle, &resultBuffer)
-	if err := checkForErrors("hcnOpenNetwork", hr, resultBuffer); err != nil {
-		return nil, err
-	}
-	// Create endpoint.
-	endpointId := guid.GUID{}
-	var endpointHandle hcnEndpoint
-	hr = hcnCreateEndpoint(networkHandle, &endpointId, endpointSettings, &endpointHandle, &resultBuffer)
-	if err := checkForErrors("hcnCreateEndpoint", hr, resultBuffer); err != nil {
-		return nil, err
-	}
-	// Query endpoint.
-	hcnQuery := defaultQuery()
-	query, err := json.Marshal(hcnQuery)
-	if err != nil {
-		return nil, err
-	}
-	var propertiesBuffer *uint16
-	hr = hcnQueryEndpointProperties(endpointHandle, string(query), &propertiesBuffer, &resultBuffer)
-	if err := checkForErrors("hcnQueryEndpointProperties", hr, resultBuffer); err != nil {
-		return nil, err
-	}
-	properties := interop.ConvertAndFreeCoTaskMemString(propertiesBuffer)
-	// Close endpoint.
-	hr = hcnCloseEndpoint(endpointHandle)
-	if err := checkForErrors("hcnCloseEndpoint", hr, nil); err != nil {
-		return nil, err
-	}
-	// Close network.
-	hr = hcnCloseNetwork(networkHandle)
-	if err := checkForErrors("hcnCloseNetwork", hr, nil); err != nil {
-		return nil, err
-	}
-	// Convert output to HostComputeEndpoint
+	// Endpoint creates against the same network are a common source of
+	// RPC_E_DISCONNECTED/busy errors during pod start (e.g. several
+	// containers in a pod having endpoints created in parallel), so they are
+	// serialized and retried per network, same as the network-level calls in
+	// hcnnetwork.go.
 	var outputEndpoint HostComputeEndpoint
-	if err := json.Unmarshal([]byte(properties), &outputEndpoint); err != nil {
+	err = callWithNetworkThrottle(networkId, "hcnCreateEndpoint", func() error {
+		// Open network.
+		var networkHandle hcnNetwork
+		var resultBuffer *uint16
+		hr := hcnOpenNetwork(&networkGuid, &networkHandle, &resultBuffer)
+		if err := checkForErrors("hcnOpenNetwork", hr, resultBuffer); err != nil {
+			return err
+		}
+		// Create endpoint.
+		endpointId := guid.GUID{}
+		var endpointHandle hcnEndpoint
+		hr = hcnCreateEndpoint(networkHandle, &endpointId, endpointSettings, &endpointHandle, &resultBuffer)
+		if err := checkForErrors("hcnCreateEndpoint", hr, resultBuffer); err != nil {
+			return err
+		}
+		// Query endpoint.
+		hcnQuery := defaultQuery()
+		query, err := json.Marshal(hcnQuery)
+		if err != nil {
+			return err
+		}
+		var propertiesBuffer *uint16
+		hr = hcnQueryEndpointProperties(endpointHandle, string(query), &propertiesBuffer, &resultBuffer)
+		if err := checkForErrors("hcnQueryEndpointProperties", hr, resultBuffer); err != nil {
+			return err
+		}
+		properties := interop.ConvertAndFreeCoTaskMemString(propertiesBuffer)
+		// Close endpoint.
+		hr = hcnCloseEndpoint(endpointHandle)
+		if err := checkForErrors("hcnCloseEndpoint", hr, nil); err != nil {
+			return err
+		}
+		// Close network.
+		hr = hcnCloseNetwork(networkHandle)
+		if err := checkForErrors("hcnCloseNetwork", hr, nil); err != nil {
+			return err
+		}
+		// Convert output to HostComputeEndpoint
+		return json.Unmarshal([]byte(properties), &outputEndpoint)
+	})
+	if err != nil {
 		return nil, err
 	}
 	return &outputEndpoint, nil
@@ -110,27 +110,6 @@ type PolicyNetworkRequest struct {
 }
 
 func getNetwork(networkGuid guid.GUID, query string) (*HostComputeNetwork, error) {
-	// Open network.
-	var (
-		networkHandle    hcnNetwork
-		resultBuffer     *uint16
-		propertiesBuffer *uint16
-	)
-	hr := hcnOpenNetwork(&networkGuid, &networkHandle, &resultBuffer)
-	if err := checkForErrors("hcnOpenNetwork", hr, resultBuffer); err != nil {
-		return nil, err
-	}
-	// Query network.
-	hr = hcnQueryNetworkProperties(networkHandle, query, &propertiesBuffer, &resultBuffer)
-	if err := checkForErrors("hcnQueryNetworkProperties", hr, resultBuffer); err != nil {
-		return nil, err
-	}
-	properties := interop.ConvertAndFreeCoTaskMemString(propertiesBuffer)
-	// Close network.
-	hr = hcnCloseNetwork(networkHandle)
-	if err := checkForErrors("hcnCloseNetwork", hr, nil); err != nil {
-		return nil, err
-	}
 	// Convert output to HostComputeNetwork
 	var outputNetwork HostComputeNetwork
 
@@ -139,7 +118,31 @@ func getNetwork(networkGuid guid.GUID, query string) (*HostComputeNetwork, error
 	// unmarshaling the JSON blob.
 	outputNetwork.Type = NAT
 
-	if err := json.Unmarshal([]byte(properties), &outputNetwork); err != nil {
+	err := callWithNetworkThrottle(networkGuid.String(), "hcnOpenNetwork", func() error {
+		// Open network.
+		var (
+			networkHandle    hcnNetwork
+			resultBuffer     *uint16
+			propertiesBuffer *uint16
+		)
+		hr := hcnOpenNetwork(&networkGuid, &networkHandle, &resultBuffer)
+		if err := checkForErrors("hcnOpenNetwork", hr, resultBuffer); err != nil {
+			return err
+		}
+		// Query network.
+		hr = hcnQueryNetworkProperties(networkHandle, query, &propertiesBuffer, &resultBuffer)
+		if err := checkForErrors("hcnQueryNetworkProperties", hr, resultBuffer); err != nil {
+			return err
+		}
+		properties := interop.ConvertAndFreeCoTaskMemString(propertiesBuffer)
+		// Close network.
+		hr = hcnCloseNetwork(networkHandle)
+		if err := checkForErrors("hcnCloseNetwork", hr, nil); err != nil {
+			return err
+		}
+		return json.Unmarshal([]byte(properties), &outputNetwork)
+	})
+	if err != nil {
 		return nil, err
 	}
 	return &outputNetwork, nil
@@ -220,37 +223,6 @@ func modifyNetwork(networkId string, settings string) (*HostComputeNetwork, erro
 	if err != nil {
 		return nil, errInvalidNetworkID
 	}
-	// Open Network
-	var (
-		networkHandle    hcnNetwork
-		resultBuffer     *uint16
-		propertiesBuffer *uint16
-	)
-	hr := hcnOpenNetwork(&networkGuid, &networkHandle, &resultBuffer)
-	if err := checkForErrors("hcnOpenNetwork", hr, resultBuffer); err != nil {
-		return nil, err
-	}
-	// Modify Network
-	hr = hcnModifyNetwork(networkHandle, settings, &resultBuffer)
-	if err := checkForErrors("hcnModifyNetwork", hr, resultBuffer); err != nil {
-		return nil, err
-	}
-	// Query network.
-	hcnQuery := defaultQuery()
-	query, err := json.Marshal(hcnQuery)
-	if err != nil {
-		return nil, err
-	}
-	hr = hcnQueryNetworkProperties(networkHandle, string(query), &propertiesBuffer, &resultBuffer)
-	if err := checkForErrors("hcnQueryNetworkProperties", hr, resultBuffer); err != nil {
-		return nil, err
-	}
-	properties := interop.ConvertAndFreeCoTaskMemString(propertiesBuffer)
-	// Close network.
-	hr = hcnCloseNetwork(networkHandle)
-	if err := checkForErrors("hcnCloseNetwork", hr, nil); err != nil {
-		return nil, err
-	}
 	// Convert output to HostComputeNetwork
 	var outputNetwork HostComputeNetwork
 
@@ -259,7 +231,41 @@ func modifyNetwork(networkId string, settings string) (*HostComputeNetwork, erro
 	// unmarshaling the JSON blob.
 	outputNetwork.Type = NAT
 
-	if err := json.Unmarshal([]byte(properties), &outputNetwork); err != nil {
+	err = callWithNetworkThrottle(networkId, "hcnModifyNetwork", func() error {
+		// Open Network
+		var (
+			networkHandle    hcnNetwork
+			resultBuffer     *uint16
+			propertiesBuffer *uint16
+		)
+		hr := hcnOpenNetwork(&networkGuid, &networkHandle, &resultBuffer)
+		if err := checkForErrors("hcnOpenNetwork", hr, resultBuffer); err != nil {
+			return err
+		}
+		// Modify Network
+		hr = hcnModifyNetwork(networkHandle, settings, &resultBuffer)
+		if err := checkForErrors("hcnModifyNetwork", hr, resultBuffer); err != nil {
+			return err
+		}
+		// Query network.
+		hcnQuery := defaultQuery()
+		query, err := json.Marshal(hcnQuery)
+		if err != nil {
+			return err
+		}
+		hr = hcnQueryNetworkProperties(networkHandle, string(query), &propertiesBuffer, &resultBuffer)
+		if err := checkForErrors("hcnQueryNetworkProperties", hr, resultBuffer); err != nil {
+			return err
+		}
+		properties := interop.ConvertAndFreeCoTaskMemString(propertiesBuffer)
+		// Close network.
+		hr = hcnCloseNetwork(networkHandle)
+		if err := checkForErrors("hcnCloseNetwork", hr, nil); err != nil {
+			return err
+		}
+		return json.Unmarshal([]byte(properties), &outputNetwork)
+	})
+	if err != nil {
 		return nil, err
 	}
 	return &outputNetwork, nil
@@ -270,12 +276,11 @@ func deleteNetwork(networkId string) error {
 	if err != nil {
 		return errInvalidNetworkID
 	}
-	var resultBuffer *uint16
-	hr := hcnDeleteNetwork(&networkGuid, &resultBuffer)
-	if err := checkForErrors("hcnDeleteNetwork", hr, resultBuffer); err != nil {
-		return err
-	}
-	return nil
+	return callWithNetworkThrottle(networkId, "hcnDeleteNetwork", func() error {
+		var resultBuffer *uint16
+		hr := hcnDeleteNetwork(&networkGuid, &resultBuffer)
+		return checkForErrors("hcnDeleteNetwork", hr, resultBuffer)
+	})
 }
 
 // ListNetworks makes a call to list all available networks.
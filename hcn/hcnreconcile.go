@@ -0,0 +1,186 @@
+package hcn
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+
+	"github.com/sirupsen/logrus"
+)
+
+// ReconcileResult reports what Reconcile{Network,Endpoint} had to do to
+// bring an existing HNS object in line with a desired spec.
+type ReconcileResult string
+
+const (
+	// ReconcileCreated means no matching object existed, so one was created
+	// from the desired spec.
+	ReconcileCreated ReconcileResult = "created"
+	// ReconcileUnchanged means a matching object already existed and
+	// already matched the desired spec.
+	ReconcileUnchanged ReconcileResult = "unchanged"
+	// ReconcileUpdated means a matching object existed and had its
+	// Policies/Dns settings updated in place to match the desired spec.
+	ReconcileUpdated ReconcileResult = "updated"
+	// ReconcileRecreated means a matching object existed but differed in a
+	// field HNS won't let be changed in place, so it was deleted and
+	// recreated from the desired spec.
+	ReconcileRecreated ReconcileResult = "recreated"
+)
+
+// ReconcileNetwork creates a network matching desired's Name if none
+// exists, or adopts (and fixes drift on) a matching one if it does. It is
+// safe to call repeatedly with the same desired spec, which is what makes
+// it useful to a CNI plugin on startup: HNS doesn't remember what a caller
+// meant a network to look like across a service restart, only what's
+// actually configured, so a plugin has to reconcile its intent against
+// that each time rather than assuming a network it created earlier is
+// still configured the way it left it.
+//
+// HNS only allows a network's Policies and Dns settings to be changed in
+// place (see ModifyNetworkSettingRequest); Type, Ipams, and MacPool are
+// fixed at creation. If an existing network's Type, Ipams, or MacPool
+// differ from desired, ReconcileNetwork deletes and recreates it rather
+// than leaving the drift in place. That drops every endpoint on the
+// network, which the caller needs to reconcile again afterwards (e.g. via
+// ReconcileEndpoint).
+func ReconcileNetwork(desired *HostComputeNetwork) (*HostComputeNetwork, ReconcileResult, error) {
+	existing, err := GetNetworkByName(desired.Name)
+	if err != nil {
+		if !IsNotFoundError(err) {
+			return nil, "", err
+		}
+		created, err := desired.Create()
+		if err != nil {
+			return nil, "", err
+		}
+		return created, ReconcileCreated, nil
+	}
+
+	if networkNeedsRecreate(existing, desired) {
+		logrus.Debugf("hcn::ReconcileNetwork recreating %q: Type/Ipams/MacPool drifted", desired.Name)
+		if err := existing.Delete(); err != nil {
+			return nil, "", fmt.Errorf("deleting drifted network %q: %w", desired.Name, err)
+		}
+		desired.Id = ""
+		created, err := desired.Create()
+		if err != nil {
+			return nil, "", err
+		}
+		return created, ReconcileRecreated, nil
+	}
+
+	changed := false
+	if !reflect.DeepEqual(existing.Policies, desired.Policies) {
+		if len(existing.Policies) > 0 {
+			if err := existing.RemovePolicy(PolicyNetworkRequest{Policies: existing.Policies}); err != nil {
+				return nil, "", fmt.Errorf("removing drifted policies from network %q: %w", desired.Name, err)
+			}
+		}
+		if len(desired.Policies) > 0 {
+			if err := existing.AddPolicy(PolicyNetworkRequest{Policies: desired.Policies}); err != nil {
+				return nil, "", fmt.Errorf("applying desired policies to network %q: %w", desired.Name, err)
+			}
+		}
+		changed = true
+	}
+	if !reflect.DeepEqual(existing.Dns, desired.Dns) {
+		if err := updateNetworkDNS(existing, desired.Dns); err != nil {
+			return nil, "", fmt.Errorf("updating DNS settings on network %q: %w", desired.Name, err)
+		}
+		changed = true
+	}
+
+	if !changed {
+		return existing, ReconcileUnchanged, nil
+	}
+	refreshed, err := GetNetworkByID(existing.Id)
+	if err != nil {
+		return nil, "", err
+	}
+	return refreshed, ReconcileUpdated, nil
+}
+
+func networkNeedsRecreate(existing, desired *HostComputeNetwork) bool {
+	return existing.Type != desired.Type ||
+		!reflect.DeepEqual(existing.Ipams, desired.Ipams) ||
+		!reflect.DeepEqual(existing.MacPool, desired.MacPool)
+}
+
+func updateNetworkDNS(network *HostComputeNetwork, dns Dns) error {
+	settings, err := json.Marshal(dns)
+	if err != nil {
+		return err
+	}
+	return network.ModifyNetworkSettings(&ModifyNetworkSettingRequest{
+		ResourceType: NetworkResourceTypeDNS,
+		RequestType:  RequestTypeUpdate,
+		Settings:     settings,
+	})
+}
+
+// ReconcileEndpoint creates an endpoint matching desired's Name on network
+// if none exists, or adopts (and fixes drift on) a matching one if it
+// does. See ReconcileNetwork for why this is idempotent-by-design rather
+// than a one-shot create.
+//
+// Policies drift is fixed in place. IpConfigurations, MacAddress, and Routes
+// are fixed at creation, so drift in any of those deletes and recreates the
+// endpoint. Drift in an existing endpoint's Dns settings is neither detected
+// nor corrected.
+func ReconcileEndpoint(network *HostComputeNetwork, desired *HostComputeEndpoint) (*HostComputeEndpoint, ReconcileResult, error) {
+	existing, err := GetEndpointByName(desired.Name)
+	if err != nil {
+		if !IsNotFoundError(err) {
+			return nil, "", err
+		}
+		created, err := network.CreateEndpoint(desired)
+		if err != nil {
+			return nil, "", err
+		}
+		return created, ReconcileCreated, nil
+	}
+
+	if endpointNeedsRecreate(existing, desired) {
+		logrus.Debugf("hcn::ReconcileEndpoint recreating %q: IpConfigurations/MacAddress/Routes drifted", desired.Name)
+		if err := existing.Delete(); err != nil {
+			return nil, "", fmt.Errorf("deleting drifted endpoint %q: %w", desired.Name, err)
+		}
+		desired.Id = ""
+		created, err := network.CreateEndpoint(desired)
+		if err != nil {
+			return nil, "", err
+		}
+		return created, ReconcileRecreated, nil
+	}
+
+	changed := false
+	if !reflect.DeepEqual(existing.Policies, desired.Policies) {
+		if len(existing.Policies) > 0 {
+			if err := existing.ApplyPolicy(RequestTypeRemove, PolicyEndpointRequest{Policies: existing.Policies}); err != nil {
+				return nil, "", fmt.Errorf("removing drifted policies from endpoint %q: %w", desired.Name, err)
+			}
+		}
+		if len(desired.Policies) > 0 {
+			if err := existing.ApplyPolicy(RequestTypeAdd, PolicyEndpointRequest{Policies: desired.Policies}); err != nil {
+				return nil, "", fmt.Errorf("applying desired policies to endpoint %q: %w", desired.Name, err)
+			}
+		}
+		changed = true
+	}
+
+	if !changed {
+		return existing, ReconcileUnchanged, nil
+	}
+	refreshed, err := GetEndpointByID(existing.Id)
+	if err != nil {
+		return nil, "", err
+	}
+	return refreshed, ReconcileUpdated, nil
+}
+
+func endpointNeedsRecreate(existing, desired *HostComputeEndpoint) bool {
+	return !reflect.DeepEqual(existing.IpConfigurations, desired.IpConfigurations) ||
+		existing.MacAddress != desired.MacAddress ||
+		!reflect.DeepEqual(existing.Routes, desired.Routes)
+}
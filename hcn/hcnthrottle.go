@@ -0,0 +1,126 @@
+package hcn
+
+import (
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+// Win32/RPC error codes HNS/HCN is known to return when it is temporarily
+// unable to service a request for an object another call is already in
+// flight for, rather than a real failure of the requested operation.
+const (
+	RPC_E_DISCONNECTED    ErrorCode = 0x80010108
+	RPC_S_SERVER_TOO_BUSY ErrorCode = 0x8001011A
+	ERROR_BUSY            ErrorCode = 0xAA
+)
+
+// isTransientHnsError returns true if err looks like one of the known
+// transient HNS/RPC error codes, worth retrying rather than surfacing
+// straight to the caller.
+func isTransientHnsError(err error) bool {
+	for _, code := range [...]ErrorCode{RPC_E_DISCONNECTED, RPC_S_SERVER_TOO_BUSY, ERROR_BUSY} {
+		if CheckErrorWithCode(err, code) {
+			return true
+		}
+	}
+	return false
+}
+
+const (
+	throttleMaxAttempts  = 5
+	throttleInitialDelay = 100 * time.Millisecond
+	throttleMaxDelay     = 2 * time.Second
+)
+
+// NetworkThrottleMetrics is a snapshot of the per-network call queue/retry
+// activity tracked by callWithNetworkThrottle, useful for diagnosing flaky
+// pod starts caused by HNS concurrency limits.
+type NetworkThrottleMetrics struct {
+	// QueueDepth is the number of callers currently waiting for their turn to
+	// make an HNS call against this network.
+	QueueDepth int
+	// TotalCalls is the number of calls that have completed (successfully or
+	// not) against this network.
+	TotalCalls uint64
+	// TotalRetries is the number of retry attempts issued across all calls
+	// against this network.
+	TotalRetries uint64
+}
+
+// networkThrottle serializes and retries HNS calls for a single network. HNS
+// has historically had trouble servicing concurrent calls against the same
+// network object, which surfaces as RPC_E_DISCONNECTED/server-too-busy
+// errors during pod start when several endpoints are created in parallel.
+type networkThrottle struct {
+	mu sync.Mutex // held for the duration of a call (including its retries)
+
+	queueDepth   int32
+	totalCalls   uint64
+	totalRetries uint64
+}
+
+var (
+	networkThrottlesMu sync.Mutex
+	networkThrottles   = map[string]*networkThrottle{}
+)
+
+func getNetworkThrottle(networkID string) *networkThrottle {
+	networkThrottlesMu.Lock()
+	defer networkThrottlesMu.Unlock()
+	t, ok := networkThrottles[networkID]
+	if !ok {
+		t = &networkThrottle{}
+		networkThrottles[networkID] = t
+	}
+	return t
+}
+
+// GetNetworkThrottleMetrics returns a snapshot of the HNS call queue/retry
+// activity recorded for the given network ID. Networks that have never had a
+// throttled call made against them report a zero value.
+func GetNetworkThrottleMetrics(networkID string) NetworkThrottleMetrics {
+	networkThrottlesMu.Lock()
+	t, ok := networkThrottles[networkID]
+	networkThrottlesMu.Unlock()
+	if !ok {
+		return NetworkThrottleMetrics{}
+	}
+	return NetworkThrottleMetrics{
+		QueueDepth:   int(atomic.LoadInt32(&t.queueDepth)),
+		TotalCalls:   atomic.LoadUint64(&t.totalCalls),
+		TotalRetries: atomic.LoadUint64(&t.totalRetries),
+	}
+}
+
+// callWithNetworkThrottle serializes fn against every other call made with
+// the same networkID, and retries it with exponential backoff while it keeps
+// failing with a transient HNS error. methodName is used only for logging.
+func callWithNetworkThrottle(networkID string, methodName string, fn func() error) error {
+	t := getNetworkThrottle(networkID)
+
+	atomic.AddInt32(&t.queueDepth, 1)
+	t.mu.Lock()
+	atomic.AddInt32(&t.queueDepth, -1)
+	defer t.mu.Unlock()
+
+	delay := throttleInitialDelay
+	var err error
+	for attempt := 0; attempt < throttleMaxAttempts; attempt++ {
+		err = fn()
+		if err == nil || !isTransientHnsError(err) || attempt == throttleMaxAttempts-1 {
+			break
+		}
+		atomic.AddUint64(&t.totalRetries, 1)
+		logrus.Debugf("hcn::%s: retrying after transient error (attempt %d/%d): %s", methodName, attempt+1, throttleMaxAttempts, err)
+		time.Sleep(delay)
+		delay *= 2
+		if delay > throttleMaxDelay {
+			delay = throttleMaxDelay
+		}
+	}
+	atomic.AddUint64(&t.totalCalls, 1)
+	return err
+}
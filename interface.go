@@ -64,6 +64,9 @@ type Container interface {
 	// MappedVirtualDisks returns virtual disks mapped to a utility VM, indexed by controller
 	MappedVirtualDisks() (map[int]MappedVirtualDiskController, error)
 
+	// PropertiesV2 returns the requested V2 schema container/uVM properties.
+	PropertiesV2(types ...PropertyType) (*PropertiesV2, error)
+
 	// CreateProcess launches a new process within the container.
 	CreateProcess(c *ProcessConfig) (Process, error)
 
@@ -0,0 +1,59 @@
+// Package admission implements an optional pre-flight check, run before a
+// pod's utility VM is created, that rejects the request if the host doesn't
+// have the free memory/CPU headroom the caller asked for. Its purpose is to
+// stop a busy node from being driven further into overcommit by yet another
+// hypervisor-isolated pod, not to schedule or rank pods against each other.
+package admission
+
+import (
+	"context"
+	"time"
+
+	"github.com/Microsoft/hcsshim/internal/hostresources"
+	"github.com/Microsoft/hcsshim/internal/log"
+	"github.com/Microsoft/hcsshim/internal/oci"
+	"github.com/containerd/containerd/errdefs"
+	specs "github.com/opencontainers/runtime-spec/specs-go"
+	"github.com/pkg/errors"
+)
+
+// cpuSampleWindow is how long Check spends sampling host CPU idle time when
+// a minimum idle CPU percentage was requested. It is a tradeoff between
+// admission latency and sample accuracy.
+const cpuSampleWindow = 200 * time.Millisecond
+
+// Check is a no-op unless `s` opts in via `oci.SandboxAdmissionCheckResourcesEnabled`.
+// When opted in, it compares current host free memory and idle CPU against
+// the minimums configured via `oci.SandboxAdmissionMinimumFreeMemoryInMB` and
+// `oci.SandboxAdmissionMinimumIdleCPUPercent`, and returns an error wrapping
+// errdefs.ErrUnavailable if either isn't met. Callers should treat that as a
+// signal to reject or delay the pod, not retry immediately.
+func Check(ctx context.Context, s *specs.Spec) error {
+	if !oci.SandboxAdmissionCheckResourcesEnabled(ctx, s) {
+		return nil
+	}
+
+	if minFreeMB := oci.SandboxAdmissionMinimumFreeMemoryInMB(ctx, s); minFreeMB > 0 {
+		freeMB, err := hostresources.FreeMemoryMB()
+		if err != nil {
+			return errors.Wrap(err, "admission: checking host free memory")
+		}
+		log.G(ctx).WithField("freeMemoryMB", freeMB).Debug("admission: sampled host free memory")
+		if freeMB < minFreeMB {
+			return errors.Wrapf(errdefs.ErrUnavailable, "admission: host has %d MB free memory, below the required minimum of %d MB", freeMB, minFreeMB)
+		}
+	}
+
+	if minIdlePercent := oci.SandboxAdmissionMinimumIdleCPUPercent(ctx, s); minIdlePercent > 0 {
+		idlePercent, err := hostresources.CPUIdlePercent(ctx, cpuSampleWindow)
+		if err != nil {
+			return errors.Wrap(err, "admission: checking host CPU idle time")
+		}
+		log.G(ctx).WithField("idleCPUPercent", idlePercent).Debug("admission: sampled host CPU idle time")
+		if uint64(idlePercent) < minIdlePercent {
+			return errors.Wrapf(errdefs.ErrUnavailable, "admission: host has %.1f%% idle CPU, below the required minimum of %d%%", idlePercent, minIdlePercent)
+		}
+	}
+
+	return nil
+}
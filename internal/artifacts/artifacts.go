@@ -0,0 +1,136 @@
+// Package artifacts tracks on-disk files the shim creates outside a task's
+// bundle directory (layer-folder scratch VHDs, nested uVM working
+// directories, and similar) so they can be removed on every teardown path,
+// including the `delete` command's crash-recovery sweep when the shim that
+// created them never got a chance to release them itself.
+//
+// Artifacts that live under the bundle directory already get removed when
+// the bundle itself is (see cmd/containerd-shim-runhcs-v1/delete.go); this
+// package exists for the ones that don't. It doesn't cover named pipes or
+// other artifacts with no on-disk path -- those are cleaned up by the OS
+// when the owning process exits, so there is nothing for a future process to
+// recover.
+package artifacts
+
+import (
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	"github.com/Microsoft/hcsshim/internal/log"
+	"github.com/pkg/errors"
+)
+
+// fileName is the name of the artifact manifest file written under a task's
+// bundle directory.
+const fileName = "shim-artifacts.json"
+
+// manifest is the set of paths currently tracked for one bundle.
+type manifest struct {
+	Paths []string `json:"paths"`
+}
+
+func manifestPath(bundle string) string {
+	return filepath.Join(bundle, fileName)
+}
+
+func load(bundle string) (*manifest, error) {
+	b, err := ioutil.ReadFile(manifestPath(bundle))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return &manifest{}, nil
+		}
+		return nil, err
+	}
+	var m manifest
+	if err := json.Unmarshal(b, &m); err != nil {
+		return nil, errors.Wrap(err, "failed to unmarshal artifact manifest")
+	}
+	return &m, nil
+}
+
+// save writes m to the manifest file under bundle, replacing any previous
+// manifest. The write is atomic: readers of manifestPath(bundle) never
+// observe a partially written file.
+func save(bundle string, m *manifest) error {
+	b, err := json.Marshal(m)
+	if err != nil {
+		return errors.Wrap(err, "failed to marshal artifact manifest")
+	}
+	tmp, err := ioutil.TempFile(bundle, fileName+".tmp*")
+	if err != nil {
+		return errors.Wrap(err, "failed to create temp artifact manifest file")
+	}
+	if _, err := tmp.Write(b); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return errors.Wrap(err, "failed to write artifact manifest")
+	}
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmp.Name())
+		return errors.Wrap(err, "failed to close temp artifact manifest file")
+	}
+	if err := os.Rename(tmp.Name(), manifestPath(bundle)); err != nil {
+		os.Remove(tmp.Name())
+		return errors.Wrap(err, "failed to replace artifact manifest file")
+	}
+	return nil
+}
+
+// Track records that path was created for the task/POD at bundle and must be
+// removed before the bundle is considered fully torn down. It is safe to
+// call more than once for the same path.
+func Track(bundle, path string) error {
+	m, err := load(bundle)
+	if err != nil {
+		return err
+	}
+	for _, p := range m.Paths {
+		if p == path {
+			return nil
+		}
+	}
+	m.Paths = append(m.Paths, path)
+	return save(bundle, m)
+}
+
+// Untrack removes path from the tracked set for bundle, e.g. after the
+// caller has already removed it itself as part of normal resource release.
+// It is a no-op if path isn't tracked.
+func Untrack(bundle, path string) error {
+	m, err := load(bundle)
+	if err != nil {
+		return err
+	}
+	paths := m.Paths[:0]
+	for _, p := range m.Paths {
+		if p != path {
+			paths = append(paths, p)
+		}
+	}
+	m.Paths = paths
+	return save(bundle, m)
+}
+
+// CleanupAll removes every path still tracked for bundle, best effort and
+// continuing past individual failures, then removes the manifest itself.
+// Call this from any teardown path for bundle, including crash recovery,
+// so artifacts a prior shim process created but never released are still
+// cleaned up.
+func CleanupAll(ctx context.Context, bundle string) error {
+	m, err := load(bundle)
+	if err != nil {
+		return err
+	}
+	for _, p := range m.Paths {
+		if err := os.RemoveAll(p); err != nil && !os.IsNotExist(err) {
+			log.G(ctx).WithError(err).WithField("path", p).Warning("failed to remove tracked artifact")
+		}
+	}
+	if err := os.Remove(manifestPath(bundle)); err != nil && !os.IsNotExist(err) {
+		return errors.Wrap(err, "failed to remove artifact manifest")
+	}
+	return nil
+}
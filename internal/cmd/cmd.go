@@ -8,6 +8,7 @@ import (
 	"fmt"
 	"io"
 	"strings"
+	"sync"
 	"sync/atomic"
 	"time"
 
@@ -15,10 +16,29 @@ import (
 	hcsschema "github.com/Microsoft/hcsshim/internal/schema2"
 	specs "github.com/opencontainers/runtime-spec/specs-go"
 	"github.com/sirupsen/logrus"
-	"golang.org/x/sync/errgroup"
 	"golang.org/x/sys/windows"
 )
 
+// BackpressurePolicy controls what a Cmd's stdio relay does when Stdout or
+// Stderr falls behind the rate the process is writing at.
+type BackpressurePolicy int
+
+const (
+	// BackpressureBlock blocks the process's write to its stdio pipe until
+	// Stdout/Stderr catches up. This is the default, and matches historical
+	// behavior.
+	BackpressureBlock BackpressurePolicy = iota
+	// BackpressureDropWithCounter buffers up to DropBufferSizeBytes of
+	// unread output per stream and drops anything beyond that instead of
+	// blocking the process, incrementing DroppedStdoutBytes/
+	// DroppedStderrBytes for whatever is dropped.
+	BackpressureDropWithCounter
+)
+
+// defaultDropBufferSizeBytes is used when BackpressurePolicy is
+// BackpressureDropWithCounter and DropBufferSizeBytes is left at zero.
+const defaultDropBufferSizeBytes = 64 * 1024
+
 // Cmd represents a command being prepared or run in a process host.
 type Cmd struct {
 	// Host is the process host in which to launch the process.
@@ -45,15 +65,63 @@ type Cmd struct {
 	// exits and blocks the relay wait groups forever.
 	CopyAfterExitTimeout time.Duration
 
+	// BufferSizeBytes sets the buffer size used when relaying stdout/stderr.
+	// Zero uses io.Copy's default (32 KB).
+	BufferSizeBytes int
+
+	// BackpressurePolicy controls what happens when Stdout/Stderr falls
+	// behind the process's output. See BackpressurePolicy's docs.
+	BackpressurePolicy BackpressurePolicy
+
+	// DropBufferSizeBytes is the per-stream unread backlog allowed to
+	// accumulate before BackpressureDropWithCounter starts dropping output.
+	// Ignored unless BackpressurePolicy is BackpressureDropWithCounter. Zero
+	// uses defaultDropBufferSizeBytes.
+	DropBufferSizeBytes int
+
+	// DroppedStdoutBytes and DroppedStderrBytes count bytes dropped under
+	// BackpressureDropWithCounter. They are only meaningful after Wait
+	// returns.
+	DroppedStdoutBytes int64
+	DroppedStderrBytes int64
+
 	// Process is filled out after Start() returns.
 	Process cow.Process
 
 	// ExitState is filled out after Wait() (or Run() or Output()) completes.
 	ExitState *ExitState
 
-	iogrp     errgroup.Group
-	stdinErr  atomic.Value
-	allDoneCh chan struct{}
+	stdioWG     sync.WaitGroup
+	stdioErr    firstError
+	stdinErr    atomic.Value
+	allDoneCh   chan struct{}
+	dropWriters []*dropWriter
+}
+
+// firstError remembers the first non-nil error reported to it, discarding
+// the rest. It exists so the stdout/stderr relays -- which now run on
+// sharedRelayPool instead of an errgroup.Group -- can still report "the
+// first relay error" the way Cmd.Wait has always done.
+type firstError struct {
+	mu  sync.Mutex
+	err error
+}
+
+func (f *firstError) set(err error) {
+	if err == nil {
+		return
+	}
+	f.mu.Lock()
+	if f.err == nil {
+		f.err = err
+	}
+	f.mu.Unlock()
+}
+
+func (f *firstError) get() error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.err
 }
 
 // ExitState contains whether a process has exited and with which exit code.
@@ -120,8 +188,86 @@ func CommandContext(ctx context.Context, host cow.ProcessHost, name string, arg
 	return cmd
 }
 
-func copyAndLog(w io.Writer, r io.Reader, log *logrus.Entry, name string) (int64, error) {
-	n, err := io.Copy(w, r)
+// dropWriter buffers writes to an underlying io.Writer in a bounded,
+// mutex-guarded byte buffer serviced by a background goroutine, dropping and
+// counting anything past limit bytes of backlog instead of blocking the
+// caller. It exists so a stalled stdio consumer doesn't stall the container
+// process whose output is being relayed to it.
+type dropWriter struct {
+	w       io.Writer
+	mu      sync.Mutex
+	cond    *sync.Cond
+	buf     bytes.Buffer
+	limit   int
+	closed  bool
+	doneCh  chan struct{}
+	dropped *int64
+}
+
+func newDropWriter(w io.Writer, limit int, dropped *int64) *dropWriter {
+	dw := &dropWriter{w: w, limit: limit, doneCh: make(chan struct{}), dropped: dropped}
+	dw.cond = sync.NewCond(&dw.mu)
+	go dw.pump()
+	return dw
+}
+
+func (dw *dropWriter) Write(p []byte) (int, error) {
+	dw.mu.Lock()
+	defer dw.mu.Unlock()
+	room := dw.limit - dw.buf.Len()
+	if room <= 0 {
+		atomic.AddInt64(dw.dropped, int64(len(p)))
+		return len(p), nil
+	}
+	if len(p) > room {
+		atomic.AddInt64(dw.dropped, int64(len(p)-room))
+		p = p[:room]
+	}
+	dw.buf.Write(p)
+	dw.cond.Signal()
+	return len(p), nil
+}
+
+// Close stops new writes from being accepted and blocks until any data
+// already buffered has been flushed to the underlying writer.
+func (dw *dropWriter) Close() {
+	dw.mu.Lock()
+	dw.closed = true
+	dw.cond.Signal()
+	dw.mu.Unlock()
+	<-dw.doneCh
+}
+
+func (dw *dropWriter) pump() {
+	defer close(dw.doneCh)
+	for {
+		dw.mu.Lock()
+		for dw.buf.Len() == 0 && !dw.closed {
+			dw.cond.Wait()
+		}
+		if dw.buf.Len() == 0 && dw.closed {
+			dw.mu.Unlock()
+			return
+		}
+		chunk := append([]byte(nil), dw.buf.Bytes()...)
+		dw.buf.Reset()
+		dw.mu.Unlock()
+		dw.w.Write(chunk)
+	}
+}
+
+func copyAndLog(w io.Writer, r io.Reader, bufferSizeBytes int, log *logrus.Entry, name string) (int64, error) {
+	var (
+		n   int64
+		err error
+	)
+	if bufferSizeBytes > 0 && bufferSizeBytes != defaultRelayBufferSizeBytes {
+		n, err = io.CopyBuffer(w, r, make([]byte, bufferSizeBytes))
+	} else {
+		buf := getRelayBuffer()
+		n, err = io.CopyBuffer(w, r, *buf)
+		putRelayBuffer(buf)
+	}
 	if log != nil {
 		lvl := logrus.DebugLevel
 		log = log.WithFields(logrus.Fields{
@@ -137,6 +283,23 @@ func copyAndLog(w io.Writer, r io.Reader, log *logrus.Entry, name string) (int64
 	return n, err
 }
 
+// outputWriter returns the writer the stdout/stderr relay should copy into:
+// w itself under BackpressureBlock, or a dropWriter wrapping w that buffers
+// up to DropBufferSizeBytes (or defaultDropBufferSizeBytes) and drops the
+// rest, counting drops into dropped, under BackpressureDropWithCounter.
+func (c *Cmd) outputWriter(w io.Writer, dropped *int64) io.Writer {
+	if c.BackpressurePolicy != BackpressureDropWithCounter {
+		return w
+	}
+	limit := c.DropBufferSizeBytes
+	if limit <= 0 {
+		limit = defaultDropBufferSizeBytes
+	}
+	dw := newDropWriter(w, limit, dropped)
+	c.dropWriters = append(c.dropWriters, dw)
+	return dw
+}
+
 // Start starts a command. The caller must ensure that if Start succeeds,
 // Wait is eventually called to clean up resources.
 func (c *Cmd) Start() error {
@@ -203,11 +366,11 @@ func (c *Cmd) Start() error {
 	// Start relaying process IO.
 	stdin, stdout, stderr := p.Stdio()
 	if c.Stdin != nil {
-		// Do not make stdin part of the error group because there is no way for
-		// us or the caller to reliably unblock the c.Stdin read when the
+		// Do not wait for this relay in Wait because there is no way for us
+		// or the caller to reliably unblock the c.Stdin read when the
 		// process exits.
-		go func() {
-			_, err := copyAndLog(stdin, c.Stdin, c.Log, "stdin")
+		sharedRelayPool.submit(func() {
+			_, err := copyAndLog(stdin, c.Stdin, c.BufferSizeBytes, c.Log, "stdin")
 			// Report the stdin copy error. If the process has exited, then the
 			// caller may never see it, but if the error was due to a failure in
 			// stdin read, then it is likely the process is still running.
@@ -216,20 +379,26 @@ func (c *Cmd) Start() error {
 			}
 			// Notify the process that there is no more input.
 			p.CloseStdin(context.TODO())
-		}()
+		})
 	}
 
 	if c.Stdout != nil {
-		c.iogrp.Go(func() error {
-			_, err := copyAndLog(c.Stdout, stdout, c.Log, "stdout")
-			return err
+		w := c.outputWriter(c.Stdout, &c.DroppedStdoutBytes)
+		c.stdioWG.Add(1)
+		sharedRelayPool.submit(func() {
+			defer c.stdioWG.Done()
+			_, err := copyAndLog(w, stdout, c.BufferSizeBytes, c.Log, "stdout")
+			c.stdioErr.set(err)
 		})
 	}
 
 	if c.Stderr != nil {
-		c.iogrp.Go(func() error {
-			_, err := copyAndLog(c.Stderr, stderr, c.Log, "stderr")
-			return err
+		w := c.outputWriter(c.Stderr, &c.DroppedStderrBytes)
+		c.stdioWG.Add(1)
+		sharedRelayPool.submit(func() {
+			defer c.stdioWG.Done()
+			_, err := copyAndLog(w, stderr, c.BufferSizeBytes, c.Log, "stderr")
+			c.stdioErr.set(err)
 		})
 	}
 
@@ -275,10 +444,17 @@ func (c *Cmd) Wait() error {
 			}
 		}()
 	}
-	ioErr := c.iogrp.Wait()
+	c.stdioWG.Wait()
+	ioErr := c.stdioErr.get()
 	if ioErr == nil {
 		ioErr, _ = c.stdinErr.Load().(error)
 	}
+	// Flush any output still buffered in a dropWriter before returning, so
+	// callers that read Stdout/Stderr after Wait see everything that wasn't
+	// dropped.
+	for _, dw := range c.dropWriters {
+		dw.Close()
+	}
 	close(c.allDoneCh)
 	c.Process.Close()
 	c.ExitState = state
@@ -40,9 +40,10 @@ type UpstreamIO interface {
 	Terminal() bool
 }
 
-// NewUpstreamIO returns an UpstreamIO instance. Currently we only support named pipes and binary
-// logging driver for container IO. When using binary logger `stdout` and `stderr` are assumed to be
-// the same and the value of `stderr` is completely ignored.
+// NewUpstreamIO returns an UpstreamIO instance. Currently we support named pipes, the binary
+// logging driver, and the shim-managed "logfile" driver for container IO. When using binary
+// logger or the logfile driver, `stdout` and `stderr` are assumed to be the same and the value
+// of `stderr` is completely ignored.
 func NewUpstreamIO(ctx context.Context, id string, stdout string, stderr string, stdin string, terminal bool) (UpstreamIO, error) {
 	u, err := url.Parse(stdout)
 
@@ -51,10 +52,15 @@ func NewUpstreamIO(ctx context.Context, id string, stdout string, stderr string,
 		return NewNpipeIO(ctx, stdin, stdout, stderr, terminal)
 	}
 
-	// Create IO for binary logging driver.
-	if u.Scheme != "binary" {
-		return nil, errors.Errorf("scheme must be 'binary', got: '%s'", u.Scheme)
+	switch u.Scheme {
+	case "binary":
+		// Create IO for binary logging driver.
+		return NewBinaryIO(ctx, id, u)
+	case "logfile":
+		// Create IO that writes CRI-formatted, rotating log files directly
+		// from the shim, bypassing the extra pipe hop binary logging requires.
+		return NewLogFileIO(ctx, u)
+	default:
+		return nil, errors.Errorf("scheme must be 'binary' or 'logfile', got: '%s'", u.Scheme)
 	}
-
-	return NewBinaryIO(ctx, id, u)
 }
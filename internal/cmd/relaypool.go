@@ -0,0 +1,126 @@
+package cmd
+
+import (
+	"sync"
+	"sync/atomic"
+)
+
+// defaultRelayPoolSize bounds the number of goroutines kept around to relay
+// stdio for running execs. Without it, a pod that issues hundreds of
+// concurrent health-probe execs spawns (and tears down) up to three
+// goroutines and a copy buffer per exec, which shows up as steady shim
+// memory growth under churn.
+const defaultRelayPoolSize = 64
+
+// defaultRelayBufferSizeBytes is the capacity of a pooled relay buffer, and
+// matches io.Copy's own default so a Cmd with BufferSizeBytes left at zero
+// behaves exactly as before.
+const defaultRelayBufferSizeBytes = 32 * 1024
+
+var (
+	relayActiveGoroutines   int64
+	relayOverflowGoroutines int64
+	relayBuffersInUse       int64
+)
+
+// relayPool runs stdio relay jobs on a small, fixed set of reusable
+// goroutines instead of spawning one per relay. A relay job runs for as
+// long as its stream stays open, which for a long-running exec can be the
+// lifetime of the process, so a worker is unavailable for that whole time.
+// Because of that, submit never blocks waiting for a free worker: if none
+// is idle it falls back to a one-off goroutine, so correctness never
+// depends on having "enough" pooled workers. In the common case this
+// package is sized for -- many short-lived execs with small output, like
+// health probes -- workers free up quickly and most relays end up running
+// on the shared pool instead of a fresh goroutine.
+type relayPool struct {
+	jobs chan func()
+}
+
+func newRelayPool(size int) *relayPool {
+	p := &relayPool{jobs: make(chan func())}
+	for i := 0; i < size; i++ {
+		go p.worker()
+	}
+	return p
+}
+
+func (p *relayPool) worker() {
+	for job := range p.jobs {
+		job()
+	}
+}
+
+// submit runs job on an idle pooled worker if one is available right now,
+// or on a new one-off goroutine otherwise.
+func (p *relayPool) submit(job func()) {
+	wrapped := func() {
+		atomic.AddInt64(&relayActiveGoroutines, 1)
+		defer atomic.AddInt64(&relayActiveGoroutines, -1)
+		job()
+	}
+	select {
+	case p.jobs <- wrapped:
+	default:
+		atomic.AddInt64(&relayOverflowGoroutines, 1)
+		go func() {
+			defer atomic.AddInt64(&relayOverflowGoroutines, -1)
+			wrapped()
+		}()
+	}
+}
+
+var sharedRelayPool = newRelayPool(defaultRelayPoolSize)
+
+// relayBufferPool hands out fixed-size byte slices for use as io.CopyBuffer
+// scratch space, so relaying stdio doesn't allocate a new buffer per exec
+// per stream.
+var relayBufferPool = sync.Pool{
+	New: func() interface{} {
+		b := make([]byte, defaultRelayBufferSizeBytes)
+		return &b
+	},
+}
+
+// getRelayBuffer checks out a pooled buffer and records it in
+// RelayStats.PooledBuffersInUse. Callers must return it with
+// putRelayBuffer.
+func getRelayBuffer() *[]byte {
+	atomic.AddInt64(&relayBuffersInUse, 1)
+	return relayBufferPool.Get().(*[]byte)
+}
+
+func putRelayBuffer(buf *[]byte) {
+	atomic.AddInt64(&relayBuffersInUse, -1)
+	relayBufferPool.Put(buf)
+}
+
+// RelayMemStats reports this package's current stdio relay resource usage,
+// for diagnosing shim memory growth on pods that run many concurrent execs.
+type RelayMemStats struct {
+	// ActiveGoroutines is the number of relay goroutines -- pooled or
+	// overflow -- currently copying stdio for a running exec.
+	ActiveGoroutines int64
+	// OverflowGoroutines is how many of ActiveGoroutines are one-off
+	// goroutines spawned because every pooled worker was busy.
+	OverflowGoroutines int64
+	// PooledBuffersInUse is how many of the shared, fixed-size relay
+	// buffers are currently checked out of the pool.
+	PooledBuffersInUse int64
+	// PooledBufferBytes is the approximate memory currently held by
+	// PooledBuffersInUse; it does not count buffers the pool is holding
+	// onto but that are not checked out.
+	PooledBufferBytes int64
+}
+
+// RelayStats returns a snapshot of the package's shared relay goroutine
+// pool and buffer pool usage.
+func RelayStats() RelayMemStats {
+	inUse := atomic.LoadInt64(&relayBuffersInUse)
+	return RelayMemStats{
+		ActiveGoroutines:   atomic.LoadInt64(&relayActiveGoroutines),
+		OverflowGoroutines: atomic.LoadInt64(&relayOverflowGoroutines),
+		PooledBuffersInUse: inUse,
+		PooledBufferBytes:  inUse * int64(defaultRelayBufferSizeBytes),
+	}
+}
@@ -0,0 +1,50 @@
+// build +windows
+
+package cmd
+
+import (
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestRelayPoolOverflow(t *testing.T) {
+	p := newRelayPool(1)
+
+	block := make(chan struct{})
+	started := make(chan struct{})
+	p.submit(func() {
+		close(started)
+		<-block
+	})
+	<-started
+
+	// The single pooled worker is busy, so this job must run as overflow
+	// rather than waiting for the worker to free up.
+	var wg sync.WaitGroup
+	wg.Add(1)
+	ran := false
+	p.submit(func() {
+		ran = true
+		wg.Done()
+	})
+	wg.Wait()
+	if !ran {
+		t.Fatal("overflow job did not run")
+	}
+	close(block)
+}
+
+func TestRelayBufferPoolReuse(t *testing.T) {
+	before := RelayStats().PooledBuffersInUse
+	buf := getRelayBuffer()
+	if got := RelayStats().PooledBuffersInUse; got != before+1 {
+		t.Fatalf("expected PooledBuffersInUse to increase by 1, got %d -> %d", before, got)
+	}
+	putRelayBuffer(buf)
+	// give any concurrent test goroutines a moment to settle before asserting
+	time.Sleep(time.Millisecond)
+	if got := RelayStats().PooledBuffersInUse; got != before {
+		t.Fatalf("expected PooledBuffersInUse to return to %d, got %d", before, got)
+	}
+}
@@ -12,6 +12,18 @@ const (
 	cniKey  = "cfg"
 )
 
+// namespaceConfigMigrator is the regstate.Migrator for
+// PersistedNamespaceConfig. Like containerStateMigrator in runhcs, it is
+// currently a no-op chain: the migration framework landed before
+// PersistedNamespaceConfig's shape needed to change, so there is nothing to
+// upgrade a pre-envelope (version 0) record through yet.
+var namespaceConfigMigrator = &regstate.Migrator{
+	CurrentVersion: 1,
+	Migrations: []regstate.Migration{
+		func(old []byte) ([]byte, error) { return old, nil },
+	},
+}
+
 // PersistedNamespaceConfig is the registry version of the `NamespaceID` to UVM
 // map.
 type PersistedNamespaceConfig struct {
@@ -45,7 +57,7 @@ func LoadPersistedNamespaceConfig(namespaceID string) (*PersistedNamespaceConfig
 		namespaceID: namespaceID,
 		stored:      true,
 	}
-	if err := sk.Get(namespaceID, cniKey, &pnc); err != nil {
+	if err := sk.GetVersioned(namespaceID, cniKey, namespaceConfigMigrator, &pnc); err != nil {
 		return nil, err
 	}
 	return &pnc, nil
@@ -71,11 +83,11 @@ func (pnc *PersistedNamespaceConfig) Store() error {
 	defer sk.Close()
 
 	if pnc.stored {
-		if err := sk.Set(pnc.namespaceID, cniKey, pnc); err != nil {
+		if err := sk.SetVersioned(pnc.namespaceID, cniKey, namespaceConfigMigrator, pnc); err != nil {
 			return err
 		}
 	} else {
-		if err := sk.Create(pnc.namespaceID, cniKey, pnc); err != nil {
+		if err := sk.CreateVersioned(pnc.namespaceID, cniKey, namespaceConfigMigrator, pnc); err != nil {
 			return err
 		}
 	}
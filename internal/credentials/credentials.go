@@ -36,6 +36,11 @@ type CCGResource struct {
 	id string
 }
 
+// String returns a description of the credential guard instance for diagnostics.
+func (ccgResource *CCGResource) String() string {
+	return fmt.Sprintf("credential guard instance for container %s", ccgResource.id)
+}
+
 // Release calls into hcs to remove the ccg instance for the container matching CCGResource.id.
 // These do not get cleaned up automatically they MUST be explicitly removed with a call to
 // ModifyServiceSettings. The instances will persist unless vmcompute.exe exits or they are removed
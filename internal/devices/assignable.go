@@ -0,0 +1,107 @@
+// +build windows
+
+package devices
+
+import (
+	"strings"
+
+	"github.com/Microsoft/hcsshim/internal/windevice"
+	"github.com/pkg/errors"
+)
+
+// AssignableDevice describes a host device that is a candidate for VPCI
+// assignment to a UVM.
+type AssignableDevice struct {
+	InstanceID   string `json:"instanceId"`
+	LocationPath string `json:"locationPath"`
+	// IOMMUGroup identifies the set of devices that share an IOMMU
+	// translation context with this one and therefore must be assigned
+	// together. Windows does not expose IOMMU groups directly, so this is
+	// approximated as the device's parent location in its location path,
+	// which covers the common case of a multi-function device.
+	IOMMUGroup string `json:"iommuGroup"`
+	// Assigned is true if the device is currently dismounted from the host
+	// (no driver loaded), which is the state a device must be in before it
+	// can be assigned to a UVM.
+	Assigned bool `json:"assigned"`
+}
+
+// GetAssignableDevices enumerates the devices reported by enumerator (for
+// example "PCI") and returns the information needed to decide which of them
+// can be assigned to a UVM, and which others must be assigned alongside them
+// because they share an IOMMU group.
+func GetAssignableDevices(enumerator string) ([]AssignableDevice, error) {
+	ids, err := windevice.GetDeviceIDsByEnumerator(enumerator)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to enumerate %s devices", enumerator)
+	}
+	locationPaths, err := windevice.GetDeviceLocationPathsFromIDs(ids)
+	if err != nil {
+		return nil, err
+	}
+
+	result := make([]AssignableDevice, 0, len(ids))
+	for i, id := range ids {
+		started, err := windevice.IsDeviceStarted(id)
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, AssignableDevice{
+			InstanceID:   id,
+			LocationPath: locationPaths[i],
+			IOMMUGroup:   iommuGroup(locationPaths[i]),
+			Assigned:     !started,
+		})
+	}
+	return result, nil
+}
+
+// iommuGroup approximates a device's IOMMU group as the location path of its
+// immediate parent, since sibling functions of the same device share an
+// IOMMU translation context.
+func iommuGroup(locationPath string) string {
+	if i := strings.LastIndex(locationPath, "#"); i >= 0 {
+		return locationPath[:i]
+	}
+	return locationPath
+}
+
+// ValidateAssignment checks that ids forms one or more complete IOMMU
+// groups, returning an error naming any device that would be left behind
+// (and therefore inaccessible to the host) if only the given ids were
+// assigned to a UVM.
+func ValidateAssignment(enumerator string, ids []string) error {
+	all, err := GetAssignableDevices(enumerator)
+	if err != nil {
+		return err
+	}
+
+	requested := make(map[string]bool, len(ids))
+	for _, id := range ids {
+		requested[id] = true
+	}
+
+	groups := make(map[string][]string)
+	for _, d := range all {
+		groups[d.IOMMUGroup] = append(groups[d.IOMMUGroup], d.InstanceID)
+	}
+
+	for group, members := range groups {
+		anyRequested := false
+		for _, m := range members {
+			if requested[m] {
+				anyRequested = true
+				break
+			}
+		}
+		if !anyRequested {
+			continue
+		}
+		for _, m := range members {
+			if !requested[m] {
+				return errors.Errorf("device %s is in the same IOMMU group (%s) as a requested device but was not included in the assignment", m, group)
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,57 @@
+// +build windows
+
+package devices
+
+import (
+	"context"
+
+	winio "github.com/Microsoft/go-winio"
+	"github.com/Microsoft/hcsshim/internal/deviceplugin"
+	"github.com/containerd/ttrpc"
+	"github.com/pkg/errors"
+)
+
+// Resolver resolves the value of an `io.microsoft.container.device.<name>`
+// annotation into the (idType, deviceID) pair AddDevice expects, decoupling
+// hcsshim from whatever inventory or policy decided that "name" should map
+// to that particular host device.
+type Resolver interface {
+	Resolve(ctx context.Context, name, request string) (idType, deviceID string, err error)
+}
+
+// ttrpcResolver is a Resolver backed by an external plugin reachable over a
+// ttrpc connection on a named pipe, implementing the DeviceResolver service
+// defined in internal/deviceplugin.
+type ttrpcResolver struct {
+	pipeAddress string
+}
+
+// NewTTRPCResolver returns a Resolver that dials pipeAddress (a named pipe,
+// e.g. `\\.\pipe\containerd-device-plugin`) and resolves each request with
+// a fresh connection to the DeviceResolver ttrpc service listening there.
+//
+// A new connection is made per Resolve call rather than held open for the
+// UVM's lifetime, since device resolution only happens during container
+// creation and this keeps the plugin's lifecycle independent of any one
+// UVM's.
+func NewTTRPCResolver(pipeAddress string) Resolver {
+	return &ttrpcResolver{pipeAddress: pipeAddress}
+}
+
+func (r *ttrpcResolver) Resolve(ctx context.Context, name, request string) (idType, deviceID string, err error) {
+	conn, err := winio.DialPipeContext(ctx, r.pipeAddress)
+	if err != nil {
+		return "", "", errors.Wrapf(err, "failed to connect to device resolver plugin at %s", r.pipeAddress)
+	}
+	client := ttrpc.NewClient(conn, ttrpc.WithOnClose(func() { conn.Close() }))
+	defer client.Close()
+
+	resp, err := deviceplugin.NewDeviceResolverClient(client).ResolveDevice(ctx, &deviceplugin.ResolveDeviceRequest{
+		Name:    name,
+		Request: request,
+	})
+	if err != nil {
+		return "", "", errors.Wrapf(err, "device resolver plugin failed to resolve %q", name)
+	}
+	return resp.IdType, resp.DeviceId, nil
+}
@@ -0,0 +1,82 @@
+// Package errdefs defines a small taxonomy of typed, coded errors that are
+// common across hcs, uvm, and the shim, so that callers (in particular
+// orchestrators talking to the task API over CRI) can make retry decisions
+// by inspecting an error's class instead of string-matching its message.
+//
+// Use with errors.Wrap/Wrapf from github.com/pkg/errors to add context, and
+// ToGRPC to map an error to the appropriate grpc status code when returning
+// it from the task API. Errors that are not one of the classes defined here
+// fall through to github.com/containerd/containerd/errdefs.ToGRPC.
+package errdefs
+
+import (
+	"github.com/containerd/containerd/errdefs"
+	"github.com/pkg/errors"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+var (
+	// ErrResourceExhausted indicates an operation failed because a host or
+	// guest resource limit was hit (e.g. no free SCSI slots, memory, or CPU
+	// groups). Retrying without freeing the resource is expected to fail
+	// again.
+	ErrResourceExhausted = errors.New("resource exhausted")
+
+	// ErrGuestUnreachable indicates the GCS bridge or guest connection for a
+	// uVM could not be reached (e.g. the vsock/hvsocket connection failed or
+	// timed out). The uVM may be in the process of starting, hung, or gone.
+	ErrGuestUnreachable = errors.New("guest unreachable")
+
+	// ErrPolicyDenied indicates an operation was rejected by a host policy
+	// (e.g. HCS security policy, Credential Guard, or an admission hook)
+	// rather than failing due to a transient or resource condition.
+	ErrPolicyDenied = errors.New("denied by policy")
+
+	// ErrLayerCorrupt indicates a container or uVM layer (VHD, CimFS, or
+	// tar2ext4 image) failed validation and cannot be used as-is.
+	ErrLayerCorrupt = errors.New("layer corrupt")
+)
+
+// IsResourceExhausted returns true if the error is due to a resource limit.
+func IsResourceExhausted(err error) bool {
+	return errors.Cause(err) == ErrResourceExhausted
+}
+
+// IsGuestUnreachable returns true if the error is due to the guest
+// connection being unreachable.
+func IsGuestUnreachable(err error) bool {
+	return errors.Cause(err) == ErrGuestUnreachable
+}
+
+// IsPolicyDenied returns true if the error is due to a host policy
+// rejecting the operation.
+func IsPolicyDenied(err error) bool {
+	return errors.Cause(err) == ErrPolicyDenied
+}
+
+// IsLayerCorrupt returns true if the error is due to a corrupt layer image.
+func IsLayerCorrupt(err error) bool {
+	return errors.Cause(err) == ErrLayerCorrupt
+}
+
+// ToGRPC maps err to a grpc status error. Errors from this package are
+// mapped first; anything else is delegated to
+// github.com/containerd/containerd/errdefs.ToGRPC.
+func ToGRPC(err error) error {
+	if err == nil {
+		return nil
+	}
+	switch {
+	case IsResourceExhausted(err):
+		return status.Errorf(codes.ResourceExhausted, err.Error())
+	case IsGuestUnreachable(err):
+		return status.Errorf(codes.Unavailable, err.Error())
+	case IsPolicyDenied(err):
+		return status.Errorf(codes.PermissionDenied, err.Error())
+	case IsLayerCorrupt(err):
+		return status.Errorf(codes.DataLoss, err.Error())
+	default:
+		return errdefs.ToGRPC(err)
+	}
+}
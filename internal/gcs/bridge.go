@@ -11,10 +11,13 @@ import (
 	"io"
 	"net"
 	"os"
+	"strconv"
 	"sync"
+	"sync/atomic"
 	"syscall"
 	"time"
 
+	"github.com/Microsoft/hcsshim/internal/log"
 	"github.com/sirupsen/logrus"
 	"golang.org/x/sys/windows"
 )
@@ -39,6 +42,15 @@ type responseMessage interface {
 	Base() *responseBase
 }
 
+// rpcPriority selects which of the bridge's send queues an RPC is
+// dispatched through. See bridge.highRpcCh.
+type rpcPriority int
+
+const (
+	normalPriority rpcPriority = iota
+	highPriority
+)
+
 // rpc represents an outstanding rpc request to the guest
 type rpc struct {
 	proc    rpcProc
@@ -47,6 +59,12 @@ type rpc struct {
 	resp    responseMessage
 	brdgErr error // error encountered when sending the request or unmarshaling the result
 	ch      chan struct{}
+	// semAcquired is true if this call holds a slot in outstandingSem and
+	// must release it on completion. Tracked per-call, rather than inferred
+	// solely from outstandingSem being non-nil, because a high-priority call
+	// bypasses the bound entirely (see asyncRPC) and must not release a slot
+	// it never acquired.
+	semAcquired bool
 }
 
 // bridge represents a communcations bridge with the guest. It handles the
@@ -65,6 +83,34 @@ type bridge struct {
 	log     *logrus.Entry
 	brdgErr error
 	waitCh  chan struct{}
+
+	recvLimiter *log.Limiter
+
+	// highRpcCh carries RPCs that should jump ahead of any already-queued
+	// normal-priority RPC -- today just process signal/kill, where guest-side
+	// latency matters even while the bridge is busy sending a batch of, e.g.,
+	// container creates. sendLoop always drains highRpcCh before picking up
+	// from rpcCh.
+	highRpcCh chan *rpc
+
+	// outstandingSem, when non-nil, is acquired before a normal-priority RPC
+	// is queued for send and released once its response arrives (see
+	// completeOutstanding), bounding how many RPCs the guest is asked to
+	// work on at once. nil (the bridge's historical behavior) means no
+	// bound. See HCSSHIM_GCS_BRIDGE_MAX_OUTSTANDING_RPCS.
+	//
+	// High-priority RPCs (PriorityRPC) never acquire this -- a signal/kill
+	// needs to reach the guest precisely when the bridge is saturated with
+	// normal traffic, so it must not be made to wait behind the same bound
+	// that traffic is hitting.
+	outstandingSem chan struct{}
+
+	// queuedRPCs and outstandingRPCs are queue-depth metrics: queuedRPCs
+	// counts calls handed to AsyncRPC that sendLoop has not yet written to
+	// the wire, outstandingRPCs counts calls written but not yet responded
+	// to. Read via QueueDepth. Access via sync/atomic.
+	queuedRPCs      int32
+	outstandingRPCs int32
 }
 
 var (
@@ -76,21 +122,72 @@ const (
 	bridgeFailureTimeout = time.Minute * 5
 )
 
+// bridgeReceiveSampleRate controls how many "bridge receive" debug log
+// lines are emitted, as 1 in N, so a chatty guest connection does not flood
+// the log with a line per message. Set HCSSHIM_GCS_BRIDGE_LOG_SAMPLE_RATE
+// to override; a rate <= 1 logs every message.
+var bridgeReceiveSampleRate = envSampleRate("HCSSHIM_GCS_BRIDGE_LOG_SAMPLE_RATE", 1)
+
+// bridgeMaxOutstandingRPCs bounds how many RPCs the bridge will have sent to
+// the guest and not yet received a response for. 0 (the default) means no
+// bound, matching the bridge's historical behavior. A multi-container pod
+// issuing many RPCs against a single guest connection can otherwise pile up
+// an unbounded number of outstanding requests in the GCS; set
+// HCSSHIM_GCS_BRIDGE_MAX_OUTSTANDING_RPCS to cap that.
+var bridgeMaxOutstandingRPCs = envNonNegativeInt("HCSSHIM_GCS_BRIDGE_MAX_OUTSTANDING_RPCS", 0)
+
+func envSampleRate(name string, def int) int {
+	v := os.Getenv(name)
+	if v == "" {
+		return def
+	}
+	n, err := strconv.Atoi(v)
+	if err != nil || n < 1 {
+		return def
+	}
+	return n
+}
+
+func envNonNegativeInt(name string, def int) int {
+	v := os.Getenv(name)
+	if v == "" {
+		return def
+	}
+	n, err := strconv.Atoi(v)
+	if err != nil || n < 0 {
+		return def
+	}
+	return n
+}
+
 type notifyFunc func(*containerNotification) error
 
 // newBridge returns a bridge on `conn`. It calls `notify` when a
 // notification message arrives from the guest. It logs transport errors and
 // traces using `log`.
 func newBridge(conn io.ReadWriteCloser, notify notifyFunc, log *logrus.Entry) *bridge {
-	return &bridge{
-		conn:    conn,
-		rpcs:    make(map[int64]*rpc),
-		rpcCh:   make(chan *rpc),
-		waitCh:  make(chan struct{}),
-		notify:  notify,
-		log:     log,
-		Timeout: bridgeFailureTimeout,
+	brdg := &bridge{
+		conn:      conn,
+		rpcs:      make(map[int64]*rpc),
+		rpcCh:     make(chan *rpc),
+		highRpcCh: make(chan *rpc),
+		waitCh:    make(chan struct{}),
+		notify:    notify,
+		log:       log,
+		Timeout:   bridgeFailureTimeout,
+
+		recvLimiter: newBridgeReceiveLimiter(),
+	}
+	if bridgeMaxOutstandingRPCs > 0 {
+		brdg.outstandingSem = make(chan struct{}, bridgeMaxOutstandingRPCs)
 	}
+	return brdg
+}
+
+func newBridgeReceiveLimiter() *log.Limiter {
+	l := log.NewLimiter(0, 0)
+	l.SetSampleRate("bridge-receive", bridgeReceiveSampleRate)
+	return l
 }
 
 // Start begins the bridge send and receive goroutines.
@@ -140,6 +237,10 @@ func (brdg *bridge) Wait() error {
 // If the message cannot be sent before the context is done, then an error is
 // returned.
 func (brdg *bridge) AsyncRPC(ctx context.Context, proc rpcProc, req requestMessage, resp responseMessage) (*rpc, error) {
+	return brdg.asyncRPC(ctx, proc, req, resp, normalPriority)
+}
+
+func (brdg *bridge) asyncRPC(ctx context.Context, proc rpcProc, req requestMessage, resp responseMessage, pri rpcPriority) (*rpc, error) {
 	call := &rpc{
 		ch:   make(chan struct{}),
 		proc: proc,
@@ -149,21 +250,60 @@ func (brdg *bridge) AsyncRPC(ctx context.Context, proc rpcProc, req requestMessa
 	if err := ctx.Err(); err != nil {
 		return nil, err
 	}
+	if brdg.outstandingSem != nil && pri != highPriority {
+		select {
+		case brdg.outstandingSem <- struct{}{}:
+			call.semAcquired = true
+		case <-brdg.waitCh:
+			err := brdg.brdgErr
+			if err == nil {
+				err = errBridgeClosed
+			}
+			return nil, err
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+	ch := brdg.rpcCh
+	if pri == highPriority {
+		ch = brdg.highRpcCh
+	}
+	atomic.AddInt32(&brdg.queuedRPCs, 1)
 	// Send the request.
 	select {
-	case brdg.rpcCh <- call:
+	case ch <- call:
 		return call, nil
 	case <-brdg.waitCh:
+		atomic.AddInt32(&brdg.queuedRPCs, -1)
+		brdg.releaseOutstanding(call)
 		err := brdg.brdgErr
 		if err == nil {
 			err = errBridgeClosed
 		}
 		return nil, err
 	case <-ctx.Done():
+		atomic.AddInt32(&brdg.queuedRPCs, -1)
+		brdg.releaseOutstanding(call)
 		return nil, ctx.Err()
 	}
 }
 
+// releaseOutstanding releases call's slot in outstandingSem, if it holds
+// one. Safe to call for any call, including one that never acquired a slot
+// (no bound configured, or a high-priority call that bypassed the bound).
+func (brdg *bridge) releaseOutstanding(call *rpc) {
+	if call.semAcquired {
+		<-brdg.outstandingSem
+	}
+}
+
+// QueueDepth reports the bridge's current queue-depth metrics: queued is the
+// number of RPCs handed to AsyncRPC that have not yet been written to the
+// wire, outstanding is the number written but not yet responded to.
+func (brdg *bridge) QueueDepth() (queued, outstanding int32) {
+	return atomic.LoadInt32(&brdg.queuedRPCs), atomic.LoadInt32(&brdg.outstandingRPCs)
+}
+
 func (call *rpc) complete(err error) {
 	call.brdgErr = err
 	close(call.ch)
@@ -217,7 +357,17 @@ func (call *rpc) Wait() {
 // waiting for a response. Avoid this on messages that are not idempotent or
 // otherwise safe to ignore the response of.
 func (brdg *bridge) RPC(ctx context.Context, proc rpcProc, req requestMessage, resp responseMessage, allowCancel bool) error {
-	call, err := brdg.AsyncRPC(ctx, proc, req, resp)
+	return brdg.rpc(ctx, proc, req, resp, allowCancel, normalPriority)
+}
+
+// PriorityRPC is RPC for a latency-sensitive request (signal/kill) that
+// should jump ahead of already-queued normal-priority RPCs; see highRpcCh.
+func (brdg *bridge) PriorityRPC(ctx context.Context, proc rpcProc, req requestMessage, resp responseMessage, allowCancel bool) error {
+	return brdg.rpc(ctx, proc, req, resp, allowCancel, highPriority)
+}
+
+func (brdg *bridge) rpc(ctx context.Context, proc rpcProc, req requestMessage, resp responseMessage, allowCancel bool, pri rpcPriority) error {
+	call, err := brdg.asyncRPC(ctx, proc, req, resp, pri)
 	if err != nil {
 		return err
 	}
@@ -249,10 +399,21 @@ func (brdg *bridge) recvLoopRoutine() {
 	brdg.rpcs = nil
 	brdg.mu.Unlock()
 	for _, call := range rpcs {
-		call.complete(errBridgeClosed)
+		brdg.completeOutstanding(call, errBridgeClosed)
 	}
 }
 
+// completeOutstanding completes call and releases the bookkeeping (queue
+// depth metric, outstandingSem slot) held by an RPC that had already been
+// written to the wire and registered in brdg.rpcs -- i.e. every completion
+// except the "bridge is closing before send" case in sendRPC, which never
+// reaches that state.
+func (brdg *bridge) completeOutstanding(call *rpc, err error) {
+	atomic.AddInt32(&brdg.outstandingRPCs, -1)
+	brdg.releaseOutstanding(call)
+	call.complete(err)
+}
+
 func readMessage(r io.Reader) (int64, msgType, []byte, error) {
 	var h [hdrSize]byte
 	_, err := io.ReadFull(r, h[:])
@@ -296,10 +457,13 @@ func (brdg *bridge) recvLoop() error {
 			}
 			return fmt.Errorf("bridge read failed: %s", err)
 		}
-		brdg.log.WithFields(logrus.Fields{
-			"payload":    string(b),
-			"type":       typ,
-			"message-id": id}).Debug("bridge receive")
+		if ok, skipped := brdg.recvLimiter.Allow("bridge-receive"); ok {
+			brdg.log.WithFields(logrus.Fields{
+				"payload":    string(b),
+				"type":       typ,
+				"message-id": id,
+				"skipped":    skipped}).Debug("bridge receive")
+		}
 		switch typ & msgTypeMask {
 		case msgTypeResponse:
 			// Find the request associated with this response.
@@ -328,7 +492,7 @@ func (brdg *bridge) recvLoop() error {
 					}).Error("bridge RPC error record")
 				}
 			}
-			call.complete(err)
+			brdg.completeOutstanding(call, err)
 			if err != nil {
 				return err
 			}
@@ -357,17 +521,26 @@ func (brdg *bridge) sendLoop() {
 	var buf bytes.Buffer
 	enc := json.NewEncoder(&buf)
 	for {
+		// Drain any already-queued high-priority RPC before considering a
+		// normal-priority one, so a signal/kill waiting behind a burst of,
+		// e.g., container creates does not wait for all of them.
+		var call *rpc
 		select {
-		case <-brdg.waitCh:
-			// The bridge has been killed.
-			return
-		case call := <-brdg.rpcCh:
-			err := brdg.sendRPC(&buf, enc, call)
-			if err != nil {
-				brdg.kill(err)
+		case call = <-brdg.highRpcCh:
+		default:
+			select {
+			case <-brdg.waitCh:
+				// The bridge has been killed.
 				return
+			case call = <-brdg.highRpcCh:
+			case call = <-brdg.rpcCh:
 			}
 		}
+		err := brdg.sendRPC(&buf, enc, call)
+		if err != nil {
+			brdg.kill(err)
+			return
+		}
 	}
 }
 
@@ -396,10 +569,14 @@ func (brdg *bridge) writeMessage(buf *bytes.Buffer, enc *json.Encoder, typ msgTy
 }
 
 func (brdg *bridge) sendRPC(buf *bytes.Buffer, enc *json.Encoder, call *rpc) error {
+	// call is no longer merely queued -- sendLoop just read it off a channel.
+	atomic.AddInt32(&brdg.queuedRPCs, -1)
+
 	// Prepare the message for the response.
 	brdg.mu.Lock()
 	if brdg.rpcs == nil {
 		brdg.mu.Unlock()
+		brdg.releaseOutstanding(call)
 		call.complete(errBridgeClosed)
 		return nil
 	}
@@ -408,6 +585,7 @@ func (brdg *bridge) sendRPC(buf *bytes.Buffer, enc *json.Encoder, call *rpc) err
 	brdg.rpcs[id] = call
 	brdg.nextID++
 	brdg.mu.Unlock()
+	atomic.AddInt32(&brdg.outstandingRPCs, 1)
 	typ := msgType(call.proc) | msgTypeRequest
 	err := brdg.writeMessage(buf, enc, typ, id, call.req)
 	if err != nil {
@@ -419,7 +597,7 @@ func (brdg *bridge) sendRPC(buf *bytes.Buffer, enc *json.Encoder, call *rpc) err
 		delete(brdg.rpcs, id)
 		brdg.mu.Unlock()
 		if call != nil {
-			call.complete(err)
+			brdg.completeOutstanding(call, err)
 		} else {
 			brdg.log.WithError(err).Error("bridge write failed but call is already complete")
 		}
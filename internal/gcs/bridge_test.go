@@ -136,6 +136,35 @@ func TestBridgeRPCContextDoneNoCancel(t *testing.T) {
 	}
 }
 
+func TestBridgePriorityRPCBypassesOutstandingBound(t *testing.T) {
+	b := startReflectedBridge(t, 0)
+	defer b.Close()
+
+	// Saturate the outstanding-RPC bound, as if a normal-priority RPC were
+	// already in flight.
+	b.outstandingSem = make(chan struct{}, 1)
+	b.outstandingSem <- struct{}{}
+
+	// A normal-priority RPC must now wait for the bound to free up.
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	normalReq := testReq{X: 1}
+	var normalResp testResp
+	if err := b.RPC(ctx, rpcCreate, &normalReq, &normalResp, true); err != context.DeadlineExceeded {
+		t.Fatalf("expected a normal-priority RPC to block on the saturated bound, got %v", err)
+	}
+
+	// A high-priority RPC must not.
+	req := testReq{X: 5}
+	var resp testResp
+	if err := b.PriorityRPC(context.Background(), rpcCreate, &req, &resp, false); err != nil {
+		t.Fatalf("expected PriorityRPC to bypass the saturated bound, got %v", err)
+	}
+	if resp.X != req.X {
+		t.Fatalf("expected response to match request, got %+v", resp)
+	}
+}
+
 func TestBridgeRPCBridgeClosed(t *testing.T) {
 	b := startReflectedBridge(t, 0)
 	eerr := errors.New("forcibly terminated")
@@ -104,6 +104,14 @@ func (gc *GuestConnection) Protocol() uint32 {
 	return protocolVersion
 }
 
+// QueueDepth reports the underlying bridge's queue-depth metrics: queued is
+// the number of RPCs awaiting send, outstanding is the number sent but not
+// yet responded to. See bridge.QueueDepth and
+// HCSSHIM_GCS_BRIDGE_MAX_OUTSTANDING_RPCS.
+func (gc *GuestConnection) QueueDepth() (queued, outstanding int32) {
+	return gc.brdg.QueueDepth()
+}
+
 // connect establishes a GCS connection. It must not be called more than once.
 func (gc *GuestConnection) connect(ctx context.Context) (err error) {
 	req := negotiateProtocolRequest{
@@ -226,7 +226,11 @@ func (p *Process) Signal(ctx context.Context, options interface{}) (_ bool, err
 	var resp responseBase
 	// FUTURE: SIGKILL is idempotent and can safely be cancelled, but this interface
 	//		   does currently make it easy to determine what signal is being sent.
-	err = p.gc.brdg.RPC(ctx, rpcSignalProcess, &req, &resp, false)
+	//
+	// Signal/kill is latency-sensitive even when the bridge is busy with other
+	// work (e.g. a burst of container creates in the same pod), so it jumps
+	// the bridge's send queue; see bridge.PriorityRPC.
+	err = p.gc.brdg.PriorityRPC(ctx, rpcSignalProcess, &req, &resp, false)
 	if err != nil {
 		if uint32(resp.Result) != hrNotFound {
 			return false, err
@@ -78,8 +78,32 @@ const (
 	ResourceTypeVPMemDevice       ResourceType = "VPMemDevice"
 	ResourceTypeVPCIDevice        ResourceType = "VPCIDevice"
 	ResourceTypeHvSocket          ResourceType = "HvSocket"
+	ResourceTypeLogLevel          ResourceType = "LogLevel"
+	ResourceTypeFirewallRules     ResourceType = "FirewallRules"
 )
 
+// LogLevelRequest is the settings payload for a guest modify request of
+// type ResourceTypeLogLevel. It allows the guest's log verbosity to be
+// changed at runtime without restarting the uVM or rebuilding the rootfs
+// with a debug GCS.
+type LogLevelRequest struct {
+	// Level is the logrus level name (e.g. "debug", "info", "warning") the
+	// guest should log at.
+	Level string `json:"Level,omitempty"`
+	// Categories are additional guest-defined debug categories to enable at
+	// Level. An empty list leaves the guest's configured categories
+	// unchanged.
+	Categories []string `json:"Categories,omitempty"`
+}
+
+// FirewallRulesRequest is the settings payload for a guest modify request of
+// type ResourceTypeFirewallRules. Each rule is opaque to hcsshim -- GCS
+// applies it as a WFP filter condition (WCOW) or an nftables rule (LCOW) in
+// its own syntax. A request replaces any rules previously applied this way.
+type FirewallRulesRequest struct {
+	Rules []string `json:"Rules,omitempty"`
+}
+
 // GuestRequest is for modify commands passed to the guest.
 type GuestRequest struct {
 	RequestType  string       `json:"RequestType,omitempty"`
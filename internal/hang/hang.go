@@ -0,0 +1,84 @@
+// Package hang provides a ttrpc unary server interceptor that enforces a
+// per-method timeout policy and logs the stacks of all goroutines if a
+// request runs past its deadline, so a hung RPC shows up in the log instead
+// of just silently stalling the shim.
+package hang
+
+import (
+	"context"
+	"runtime"
+	"strings"
+	"time"
+
+	"github.com/Microsoft/hcsshim/internal/log"
+	"github.com/containerd/ttrpc"
+	"github.com/sirupsen/logrus"
+)
+
+// Policy maps ttrpc method names to the timeout that should be applied to
+// them. Methods with no entry use Default.
+type Policy struct {
+	// Default is the timeout applied to a method with no entry in Overrides.
+	// A zero value means no timeout is applied.
+	Default time.Duration
+	// Overrides holds timeouts for specific methods, keyed by the method
+	// name as it appears after the last `/` of the ttrpc full method (e.g.
+	// "Create", "Wait", "Stats").
+	Overrides map[string]time.Duration
+}
+
+func (p *Policy) timeoutFor(fullMethod string) time.Duration {
+	name := fullMethod
+	if i := strings.LastIndex(name, "/"); i >= 0 {
+		name = name[i+1:]
+	}
+	if d, ok := p.Overrides[name]; ok {
+		return d
+	}
+	return p.Default
+}
+
+// NewUnaryServerInterceptor returns a ttrpc.UnaryServerInterceptor that calls
+// `next` (typically another interceptor, e.g. octtrpc's, or
+// ttrpc's default passthrough) under the timeout `policy` assigns to the
+// request's method. If the call has not returned by the time the timeout
+// elapses, the stacks of every goroutine are written to the log at Warning
+// level, tagged with the method name, before the call is allowed to
+// continue running to completion (or to the context's own cancellation).
+//
+// A zero-value timeout from `policy` disables both the deadline and the hang
+// detection for that method.
+func NewUnaryServerInterceptor(policy *Policy, next ttrpc.UnaryServerInterceptor) ttrpc.UnaryServerInterceptor {
+	return func(ctx context.Context, unmarshal ttrpc.Unmarshaler, info *ttrpc.UnaryServerInfo, method ttrpc.Method) (interface{}, error) {
+		timeout := policy.timeoutFor(info.FullMethod)
+		if timeout <= 0 {
+			return next(ctx, unmarshal, info, method)
+		}
+
+		ctx, cancel := context.WithTimeout(ctx, timeout)
+		defer cancel()
+
+		timer := time.AfterFunc(timeout, func() {
+			dumpStacks(ctx, info.FullMethod, timeout)
+		})
+		defer timer.Stop()
+
+		return next(ctx, unmarshal, info, method)
+	}
+}
+
+func dumpStacks(ctx context.Context, fullMethod string, timeout time.Duration) {
+	buf := make([]byte, 4096)
+	for {
+		n := runtime.Stack(buf, true)
+		if n < len(buf) {
+			buf = buf[:n]
+			break
+		}
+		buf = make([]byte, 2*len(buf))
+	}
+	log.G(ctx).WithFields(logrus.Fields{
+		"method":  fullMethod,
+		"timeout": timeout.String(),
+	}).Warnf("ttrpc request exceeded timeout, dumping goroutine stacks:\n%s", buf)
+}
@@ -0,0 +1,121 @@
+package hcs
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Microsoft/hcsshim/internal/log"
+	"github.com/Microsoft/hcsshim/internal/logfields"
+)
+
+// auditLogPathEnvVar is the environment variable used to enable the HCS
+// document audit log. When set, every document passed to
+// HcsCreateComputeSystem or HcsModifyComputeSystem is recorded (with known
+// secret fields redacted) to the file it names, one JSON object per line.
+const auditLogPathEnvVar = "HCSSHIM_HCS_DOCUMENT_AUDIT_LOG_PATH"
+
+// auditRedactedKeys lists the document field names (case-insensitive,
+// matched against the JSON key, regardless of nesting) that are replaced
+// with "<redacted>" before a document is written to the audit log. This
+// keeps GMSA credential specs and plaintext passwords out of the sink while
+// preserving the rest of the document for diffing between hcsshim versions.
+var auditRedactedKeys = map[string]struct{}{
+	"credentialspec":    {},
+	"password":          {},
+	"plaintextpassword": {},
+	"ntlmpassword":      {},
+}
+
+var (
+	auditLogOnce sync.Once
+	auditLogFile *os.File
+)
+
+// auditLogWriter returns the file to append audit records to, or nil if the
+// audit log has not been enabled via auditLogPathEnvVar.
+func auditLogWriter(ctx context.Context) *os.File {
+	auditLogOnce.Do(func() {
+		path := os.Getenv(auditLogPathEnvVar)
+		if path == "" {
+			return
+		}
+		f, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0600)
+		if err != nil {
+			log.G(ctx).WithError(err).WithField(logfields.Field, path).Warning("failed to open HCS document audit log")
+			return
+		}
+		auditLogFile = f
+	})
+	return auditLogFile
+}
+
+type auditLogRecord struct {
+	Time      string      `json:"time"`
+	Operation string      `json:"operation"`
+	ID        string      `json:"id"`
+	Document  interface{} `json:"document"`
+}
+
+// auditDocument writes a redacted copy of documentJSON to the audit log if
+// one is configured. Failures to write are logged but otherwise ignored;
+// the audit log is a diagnostic aid and must never fail an HCS call.
+func auditDocument(ctx context.Context, operation, id, documentJSON string) {
+	w := auditLogWriter(ctx)
+	if w == nil {
+		return
+	}
+
+	var doc interface{}
+	if err := json.Unmarshal([]byte(documentJSON), &doc); err != nil {
+		// Not JSON (or empty) -- log the raw string so the record still
+		// reflects that a call was made.
+		doc = documentJSON
+	} else {
+		doc = redactAuditDocument(doc)
+	}
+
+	record := auditLogRecord{
+		Time:      time.Now().UTC().Format(time.RFC3339Nano),
+		Operation: operation,
+		ID:        id,
+		Document:  doc,
+	}
+	b, err := json.Marshal(record)
+	if err != nil {
+		return
+	}
+	b = append(b, '\n')
+	if _, err := w.Write(b); err != nil {
+		log.G(ctx).WithError(err).Warning("failed to write HCS document audit log record")
+	}
+}
+
+// redactAuditDocument returns a copy of v with any key in auditRedactedKeys
+// replaced by the string "<redacted>", recursing into nested maps and
+// slices.
+func redactAuditDocument(v interface{}) interface{} {
+	switch t := v.(type) {
+	case map[string]interface{}:
+		out := make(map[string]interface{}, len(t))
+		for k, val := range t {
+			if _, redact := auditRedactedKeys[strings.ToLower(k)]; redact {
+				out[k] = "<redacted>"
+				continue
+			}
+			out[k] = redactAuditDocument(val)
+		}
+		return out
+	case []interface{}:
+		out := make([]interface{}, len(t))
+		for i, val := range t {
+			out[i] = redactAuditDocument(val)
+		}
+		return out
+	default:
+		return v
+	}
+}
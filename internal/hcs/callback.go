@@ -93,6 +93,51 @@ type notifcationWatcherContext struct {
 
 	systemID  string
 	processID int
+
+	subscribersMu sync.Mutex
+	subscribers   []chan Event
+}
+
+// Event is a single HCS notification delivered to a channel registered via
+// System.Subscribe, for consumers that want a stream of every notification
+// rather than a one-shot wait on a specific one.
+type Event struct {
+	Type     string
+	SystemID string
+	Err      error
+}
+
+// subscribe registers a channel that receives a copy of every notification
+// raised for this context. The channel is closed when the owning system's
+// callback is unregistered.
+func (context *notifcationWatcherContext) subscribe() <-chan Event {
+	ch := make(chan Event, 16)
+	context.subscribersMu.Lock()
+	context.subscribers = append(context.subscribers, ch)
+	context.subscribersMu.Unlock()
+	return ch
+}
+
+func (context *notifcationWatcherContext) closeSubscribers() {
+	context.subscribersMu.Lock()
+	defer context.subscribersMu.Unlock()
+	for _, ch := range context.subscribers {
+		close(ch)
+	}
+	context.subscribers = nil
+}
+
+func (context *notifcationWatcherContext) publish(ev Event) {
+	context.subscribersMu.Lock()
+	defer context.subscribersMu.Unlock()
+	for _, ch := range context.subscribers {
+		select {
+		case ch <- ev:
+		default:
+			// Subscriber isn't keeping up; drop the event rather than block
+			// the callback that every internal waiter also depends on.
+		}
+	}
 }
 
 type notificationChannels map[hcsNotification]notificationChannel
@@ -156,5 +201,11 @@ func notificationWatcher(notificationType hcsNotification, callbackNumber uintpt
 		channel <- result
 	}
 
+	context.publish(Event{
+		Type:     notificationType.String(),
+		SystemID: context.systemID,
+		Err:      result,
+	})
+
 	return 0
 }
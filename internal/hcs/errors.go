@@ -78,6 +78,13 @@ var (
 
 	// ErrNotSupported is an error encountered when hcs doesn't support the request
 	ErrPlatformNotSupported = errors.New("unsupported platform request")
+
+	// ErrVmcomputeRPCServerUnavailable (RPC_S_SERVER_UNAVAILABLE) is returned
+	// by vmcompute calls when the RPC server isn't answering, which happens
+	// transiently while the Hyper-V host is being serviced. Calls that fail
+	// with this error are safe to retry. vmcompute reports Win32-facility
+	// HRESULTs (here 0x800706BA) as the bare Win32 code.
+	ErrVmcomputeRPCServerUnavailable = syscall.Errno(0x6BA)
 )
 
 type ErrorEvent struct {
@@ -0,0 +1,65 @@
+package hcs
+
+import (
+	"context"
+
+	"github.com/Microsoft/go-winio/pkg/guid"
+	"github.com/Microsoft/hcsshim/internal/schema1"
+)
+
+// ComputeSystemFilter narrows the results of GetComputeSystems beyond what
+// HcsEnumerateComputeSystems itself can express: the platform query filters
+// only by ID, Type, Name, and Owner, so filtering by RuntimeID or State has
+// to be applied client-side over the returned set.
+type ComputeSystemFilter struct {
+	// RuntimeID, if not the zero GUID, restricts results to compute systems
+	// with a matching RuntimeID.
+	RuntimeID guid.GUID
+	// States, if non-empty, restricts results to compute systems whose State
+	// matches one of the given values (e.g. "Running", "Paused").
+	States []string
+}
+
+func (f ComputeSystemFilter) isZero() bool {
+	return f.RuntimeID == (guid.GUID{}) && len(f.States) == 0
+}
+
+func (f ComputeSystemFilter) matches(p schema1.ContainerProperties) bool {
+	if f.RuntimeID != (guid.GUID{}) && p.RuntimeID != f.RuntimeID {
+		return false
+	}
+	if len(f.States) > 0 {
+		found := false
+		for _, s := range f.States {
+			if p.State == s {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	return true
+}
+
+// GetComputeSystemsFiltered gets the compute systems matching q, further
+// narrowed by filter's RuntimeID and States, if set. See ComputeSystemFilter
+// for why this filtering happens after, rather than as part of, the
+// HcsEnumerateComputeSystems call made by GetComputeSystems.
+func GetComputeSystemsFiltered(ctx context.Context, q schema1.ComputeSystemQuery, filter ComputeSystemFilter) ([]schema1.ContainerProperties, error) {
+	systems, err := GetComputeSystems(ctx, q)
+	if err != nil {
+		return nil, err
+	}
+	if filter.isZero() {
+		return systems, nil
+	}
+	filtered := make([]schema1.ContainerProperties, 0, len(systems))
+	for _, s := range systems {
+		if filter.matches(s) {
+			filtered = append(filtered, s)
+		}
+	}
+	return filtered, nil
+}
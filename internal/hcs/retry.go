@@ -0,0 +1,98 @@
+package hcs
+
+import (
+	"context"
+	"math/rand"
+	"sync"
+	"time"
+)
+
+// RetryPolicy decides whether a failed HCS call should be retried, and for
+// how long to wait before the next attempt.
+type RetryPolicy interface {
+	// NextBackoff is called after the Nth failed attempt (attempt starts at
+	// 1) with the error that attempt returned. It returns the delay before
+	// the next attempt, and whether a next attempt should be made at all.
+	NextBackoff(attempt int, err error) (time.Duration, bool)
+}
+
+// defaultRetryPolicy is installed unless SetRetryPolicy is called. It makes
+// up to 5 attempts in all (at most 4 retries) with jittered exponential
+// backoff, capped at 5 seconds.
+var defaultRetryPolicy RetryPolicy = &backoffRetryPolicy{
+	maxAttempts: 5,
+	baseDelay:   100 * time.Millisecond,
+	maxDelay:    5 * time.Second,
+}
+
+var (
+	retryPolicyLock sync.RWMutex
+	retryPolicy     = defaultRetryPolicy
+)
+
+// SetRetryPolicy installs the RetryPolicy used by HCS calls that retry on
+// transient vmcompute errors, such as System.Modify. Passing nil disables
+// retrying entirely. This affects every call in the process, not just calls
+// made after it returns, so it should generally be set once during startup.
+func SetRetryPolicy(p RetryPolicy) {
+	retryPolicyLock.Lock()
+	retryPolicy = p
+	retryPolicyLock.Unlock()
+}
+
+func getRetryPolicy() RetryPolicy {
+	retryPolicyLock.RLock()
+	defer retryPolicyLock.RUnlock()
+	return retryPolicy
+}
+
+// IsRetryableError returns true for vmcompute errors known to be transient,
+// such as the RPC server being briefly unreachable during host servicing.
+func IsRetryableError(err error) bool {
+	return err == ErrVmcomputeRPCServerUnavailable
+}
+
+// withRetry runs op, retrying it according to the active RetryPolicy for as
+// long as op's error is retryable and ctx has not been cancelled or timed
+// out in the meantime.
+func withRetry(ctx context.Context, op func() error) error {
+	var err error
+	for attempt := 1; ; attempt++ {
+		err = op()
+		if err == nil || !IsRetryableError(err) {
+			return err
+		}
+		policy := getRetryPolicy()
+		if policy == nil {
+			return err
+		}
+		delay, retry := policy.NextBackoff(attempt, err)
+		if !retry {
+			return err
+		}
+		select {
+		case <-time.After(delay):
+		case <-ctx.Done():
+			return err
+		}
+	}
+}
+
+type backoffRetryPolicy struct {
+	maxAttempts int
+	baseDelay   time.Duration
+	maxDelay    time.Duration
+}
+
+// NextBackoff returns a full-jitter exponential backoff delay, capped at
+// maxDelay, until maxAttempts is reached.
+func (p *backoffRetryPolicy) NextBackoff(attempt int, err error) (time.Duration, bool) {
+	if attempt >= p.maxAttempts {
+		return 0, false
+	}
+	delay := p.baseDelay << uint(attempt-1)
+	if delay <= 0 || delay > p.maxDelay {
+		delay = p.maxDelay
+	}
+	return time.Duration(rand.Int63n(int64(delay))), true
+}
@@ -0,0 +1,30 @@
+package hcs
+
+import (
+	hcsschema "github.com/Microsoft/hcsshim/internal/schema2"
+)
+
+// SaveType enumerates the known values for SaveOptions.SaveType.
+type SaveType string
+
+const (
+	// SaveTypeAsTemplate saves a compute system so its state can be cloned
+	// into new systems (via RestoreState.TemplateSystemId on the clone),
+	// in addition to being restored directly via RestoreState.SaveStateFilePath.
+	SaveTypeAsTemplate SaveType = "AsTemplate"
+)
+
+// NewSaveOptions builds the typed SaveOptions for System.Save, writing save
+// state to path.
+//
+// Note: hcsschema.VirtualMachine.RestoreState is how a saved uVM is resumed
+// on a later create, but the container document has no equivalent field, so
+// there is currently no restore path for process-isolated (Argon) container
+// saves beyond the uVM itself -- see runhcs restore's command help for the
+// user-facing statement of this limitation.
+func NewSaveOptions(saveType SaveType, path string) *hcsschema.SaveOptions {
+	return &hcsschema.SaveOptions{
+		SaveType:          string(saveType),
+		SaveStateFilePath: path,
+	}
+}
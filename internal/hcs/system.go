@@ -57,6 +57,7 @@ func CreateComputeSystem(ctx context.Context, id string, hcsDocumentInterface in
 	}
 
 	hcsDocument := string(hcsDocumentB)
+	auditDocument(ctx, operation, id, hcsDocument)
 
 	var (
 		identity    syscall.Handle
@@ -407,6 +408,42 @@ func (computeSystem *System) Resume(ctx context.Context) (err error) {
 	return nil
 }
 
+// Save saves the compute system's state to the file referenced by
+// options.SaveStateFilePath so that it can later be restored by passing the
+// same path as uvm.Options.RestoreStateFilePath. The compute system must be
+// paused before it is saved.
+func (computeSystem *System) Save(ctx context.Context, options *hcsschema.SaveOptions) (err error) {
+	operation := "hcsshim::System::Save"
+
+	// hcsSaveComputeSystemContext is an async operation. Start the outer span
+	// here to measure the full save time.
+	ctx, span := trace.StartSpan(ctx, operation)
+	defer span.End()
+	defer func() { oc.SetSpanStatus(span, err) }()
+	span.AddAttributes(trace.StringAttribute("cid", computeSystem.id))
+
+	computeSystem.handleLock.RLock()
+	defer computeSystem.handleLock.RUnlock()
+
+	if computeSystem.handle == 0 {
+		return makeSystemError(computeSystem, operation, "", ErrAlreadyClosed, nil)
+	}
+
+	optionsBytes, err := json.Marshal(options)
+	if err != nil {
+		return err
+	}
+	optionsJSON := string(optionsBytes)
+
+	resultJSON, err := vmcompute.HcsSaveComputeSystem(ctx, computeSystem.handle, optionsJSON)
+	events, err := processAsyncHcsResult(ctx, err, resultJSON, computeSystem.callbackNumber, hcsNotificationSystemSaveCompleted, &timeout.SystemSave)
+	if err != nil {
+		return makeSystemError(computeSystem, operation, optionsJSON, err, events)
+	}
+
+	return nil
+}
+
 func (computeSystem *System) createProcess(ctx context.Context, operation string, c interface{}) (*Process, *vmcompute.HcsProcessInformation, error) {
 	computeSystem.handleLock.RLock()
 	defer computeSystem.handleLock.RUnlock()
@@ -487,6 +524,38 @@ func (computeSystem *System) OpenProcess(ctx context.Context, pid int) (*Process
 	return process, nil
 }
 
+// ReattachProcess re-acquires a handle, callback registration, and stdio
+// pipes for a process that is still running in computeSystem but whose
+// previous *Process (and the handles it held) was lost, e.g. because the
+// shim that created it was restarted. It is OpenProcess followed by an
+// eager StdioLegacy, so the returned Process's Stdio is populated
+// immediately rather than lazily on first call.
+//
+// This only applies to processes hosted directly by HCS (host processes, and
+// WCOW container processes). There is no equivalent for LCOW processes
+// running inside the guest: the GCS bridge protocol has no RPC to reopen an
+// existing guest process's relay, so a restarted shim cannot recover stdio
+// for those.
+func (computeSystem *System) ReattachProcess(ctx context.Context, pid int) (*Process, error) {
+	process, err := computeSystem.OpenProcess(ctx, pid)
+	if err != nil {
+		return nil, err
+	}
+
+	stdin, stdout, stderr, err := process.StdioLegacy()
+	if err != nil {
+		process.Close()
+		return nil, err
+	}
+
+	process.stdioLock.Lock()
+	process.stdin, process.stdout, process.stderr = stdin, stdout, stderr
+	process.hasCachedStdio = true
+	process.stdioLock.Unlock()
+
+	return process, nil
+}
+
 // Close cleans up any state associated with the compute system but does not terminate or wait for it.
 func (computeSystem *System) Close() (err error) {
 	operation := "hcsshim::System::Close"
@@ -568,6 +637,7 @@ func (computeSystem *System) unregisterCallback(ctx context.Context) error {
 	}
 
 	closeChannels(callbackContext.channels)
+	callbackContext.closeSubscribers()
 
 	callbackMapLock.Lock()
 	delete(callbackMap, callbackNumber)
@@ -578,6 +648,27 @@ func (computeSystem *System) unregisterCallback(ctx context.Context) error {
 	return nil
 }
 
+// Events returns a channel that receives every HCS notification raised for
+// this compute system (exited, crashed, RDP enhanced mode state changes,
+// guest connection closed, and so on) for as long as it remains registered.
+// Unlike the internal wait paths, which each consume a single expected
+// notification, the returned channel is a fan-out: callers that don't keep up
+// will miss events rather than stall the callback other waiters depend on.
+// The channel is closed once the compute system's callback is unregistered,
+// e.g. by Close.
+func (computeSystem *System) Events() <-chan Event {
+	callbackMapLock.RLock()
+	callbackContext := callbackMap[computeSystem.callbackNumber]
+	callbackMapLock.RUnlock()
+
+	if callbackContext == nil {
+		ch := make(chan Event)
+		close(ch)
+		return ch
+	}
+	return callbackContext.subscribe()
+}
+
 // Modify the System by sending a request to HCS
 func (computeSystem *System) Modify(ctx context.Context, config interface{}) error {
 	computeSystem.handleLock.RLock()
@@ -595,8 +686,16 @@ func (computeSystem *System) Modify(ctx context.Context, config interface{}) err
 	}
 
 	requestJSON := string(requestBytes)
-	resultJSON, err := vmcompute.HcsModifyComputeSystem(ctx, computeSystem.handle, requestJSON)
-	events := processHcsResult(ctx, resultJSON)
+	auditDocument(ctx, operation, computeSystem.id, requestJSON)
+
+	var events []ErrorEvent
+	err = withRetry(ctx, func() error {
+		var resultJSON string
+		var opErr error
+		resultJSON, opErr = vmcompute.HcsModifyComputeSystem(ctx, computeSystem.handle, requestJSON)
+		events = processHcsResult(ctx, resultJSON)
+		return opErr
+	})
 	if err != nil {
 		return makeSystemError(computeSystem, operation, requestJSON, err, events)
 	}
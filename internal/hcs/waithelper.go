@@ -64,6 +64,11 @@ func waitForNotification(ctx context.Context, callbackNumber uintptr, expectedNo
 		return ErrUnexpectedProcessAbort
 	case <-c:
 		return ErrTimeout
+	case <-ctx.Done():
+		// The caller gave up on this operation. The notification channels stay
+		// registered on the callback so a late HCS notification still has
+		// somewhere to land, but the caller is unblocked immediately rather
+		// than waiting on a syscall that may never return.
+		return ctx.Err()
 	}
-	return nil
 }
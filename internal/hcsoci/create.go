@@ -67,24 +67,67 @@ type createOptionsInternal struct {
 	ccgState               *hcsschema.ContainerCredentialGuardState // Container Credential Guard information to be attached to HCS container document
 }
 
+// PreparedContainer is the output of Prepare: a validated spec with its
+// resources already staged (network namespace joined, VSMB/SCSI/Plan9/etc
+// allocated, HCS/GCS create document built), waiting to be handed to Commit
+// to actually issue the create call. Splitting CreateContainer into these two
+// phases lets an embedder (e.g. a custom shim) run its own steps -- policy
+// checks, device injection -- in between, against resources that are already
+// allocated but not yet visible to HCS/the guest.
+type PreparedContainer struct {
+	// Resources is always populated, even if Prepare returns an error,
+	// mirroring CreateContainer's own contract: it lets a caller that set
+	// CreateOptions.DoNotReleaseResourcesOnFailure inspect or release
+	// whatever was allocated before the failure.
+	Resources *resources.Resources
+
+	coi         *createOptionsInternal
+	hcsDocument interface{}
+	gcsDocument interface{}
+}
+
 // CreateContainer creates a container. It can cope with a  wide variety of
 // scenarios, including v1 HCS schema calls, as well as more complex v2 HCS schema
 // calls. Note we always return the resources that have been allocated, even in the
 // case of an error. This provides support for the debugging option not to
 // release the resources on failure, so that the client can make the necessary
 // call to release resources that have been allocated as part of calling this function.
+//
+// This is equivalent to calling Prepare followed by Commit; see those for
+// embedders that need to interleave their own steps between resource staging
+// and the actual HCS create call.
 func CreateContainer(ctx context.Context, createOptions *CreateOptions) (_ cow.Container, _ *resources.Resources, err error) {
+	pc, err := Prepare(ctx, createOptions)
+	if err != nil {
+		return nil, pc.Resources, err
+	}
+	c, err := Commit(ctx, pc)
+	return c, pc.Resources, err
+}
+
+// Prepare validates createOptions and stages every resource a container
+// needs (network namespace, VSMB/SCSI/Plan9/etc mounts) and builds the
+// HCS/GCS create document, but does not create the container itself -- call
+// Commit with the result to do that. As with CreateContainer, the returned
+// PreparedContainer.Resources is always populated, even on error, so a
+// caller with CreateOptions.DoNotReleaseResourcesOnFailure can inspect or
+// release what was staged.
+func Prepare(ctx context.Context, createOptions *CreateOptions) (_ *PreparedContainer, err error) {
 	coi := &createOptionsInternal{
 		CreateOptions: createOptions,
 		actualID:      createOptions.ID,
 		actualOwner:   createOptions.Owner,
 	}
+	pc := &PreparedContainer{
+		coi:       coi,
+		Resources: resources.NewContainerResources(createOptions.ID),
+	}
 
 	// Defaults if omitted by caller.
 	if coi.actualID == "" {
 		g, err := guid.NewV4()
 		if err != nil {
-			return nil, nil, err
+			return pc, err
 		}
 		coi.actualID = g.String()
 	}
@@ -93,7 +136,7 @@ func CreateContainer(ctx context.Context, createOptions *CreateOptions) (_ cow.C
 	}
 
 	if coi.Spec == nil {
-		return nil, nil, fmt.Errorf("Spec must be supplied")
+		return pc, fmt.Errorf("Spec must be supplied")
 	}
 
 	if coi.HostingSystem != nil {
@@ -108,7 +151,7 @@ func CreateContainer(ctx context.Context, createOptions *CreateOptions) (_ cow.C
 		"schema":  coi.actualSchemaVersion,
 	}).Debug("hcsshim::CreateContainer")
 
-	r := resources.NewContainerResources(createOptions.ID)
+	r := pc.Resources
 	defer func() {
 		if err != nil {
 			if !coi.DoNotReleaseResourcesOnFailure {
@@ -136,14 +179,14 @@ func CreateContainer(ctx context.Context, createOptions *CreateOptions) (_ cow.C
 		} else {
 			err := createNetworkNamespace(ctx, coi, r)
 			if err != nil {
-				return nil, r, err
+				return pc, err
 			}
 		}
 		coi.actualNetworkNamespace = r.NetNS()
 		if coi.HostingSystem != nil {
 			ct, _, err := oci.GetSandboxTypeAndID(coi.Spec.Annotations)
 			if err != nil {
-				return nil, r, err
+				return pc, err
 			}
 			// Only add the network namespace to a standalone or sandbox
 			// container but not a workload container in a sandbox that inherits
@@ -151,65 +194,64 @@ func CreateContainer(ctx context.Context, createOptions *CreateOptions) (_ cow.C
 			if ct == oci.KubernetesContainerTypeNone || ct == oci.KubernetesContainerTypeSandbox {
 				endpoints, err := GetNamespaceEndpoints(ctx, coi.actualNetworkNamespace)
 				if err != nil {
-					return nil, r, err
+					return pc, err
 				}
 				err = coi.HostingSystem.AddNetNS(ctx, coi.actualNetworkNamespace)
 				if err != nil {
-					return nil, r, err
+					return pc, err
 				}
 				err = coi.HostingSystem.AddEndpointsToNS(ctx, coi.actualNetworkNamespace, endpoints)
 				if err != nil {
 					// Best effort clean up the NS
 					coi.HostingSystem.RemoveNetNS(ctx, coi.actualNetworkNamespace)
-					return nil, r, err
+					return pc, err
 				}
 				r.SetAddedNetNSToVM(true)
 			}
 		}
 	}
 
-	var hcsDocument, gcsDocument interface{}
 	log.G(ctx).Debug("hcsshim::CreateContainer allocating resources")
 	if coi.Spec.Linux != nil {
 		if schemaversion.IsV10(coi.actualSchemaVersion) {
-			return nil, r, errors.New("LCOW v1 not supported")
+			return pc, errors.New("LCOW v1 not supported")
 		}
 		log.G(ctx).Debug("hcsshim::CreateContainer allocateLinuxResources")
 		err = allocateLinuxResources(ctx, coi, r)
 		if err != nil {
 			log.G(ctx).WithError(err).Debug("failed to allocateLinuxResources")
-			return nil, r, err
+			return pc, err
 		}
-		gcsDocument, err = createLinuxContainerDocument(ctx, coi, r.ContainerRootInUVM())
+		pc.gcsDocument, err = createLinuxContainerDocument(ctx, coi, r.ContainerRootInUVM())
 		if err != nil {
 			log.G(ctx).WithError(err).Debug("failed createHCSContainerDocument")
-			return nil, r, err
+			return pc, err
 		}
 	} else {
 		err = allocateWindowsResources(ctx, coi, r)
 		if err != nil {
 			log.G(ctx).WithError(err).Debug("failed to allocateWindowsResources")
-			return nil, r, err
+			return pc, err
 		}
 		log.G(ctx).Debug("hcsshim::CreateContainer creating container document")
 		v1, v2, err := createWindowsContainerDocument(ctx, coi)
 		if err != nil {
 			log.G(ctx).WithError(err).Debug("failed createHCSContainerDocument")
-			return nil, r, err
+			return pc, err
 		}
 
 		if schemaversion.IsV10(coi.actualSchemaVersion) {
 			// v1 Argon or Xenon. Pass the document directly to HCS.
-			hcsDocument = v1
+			pc.hcsDocument = v1
 		} else if coi.HostingSystem != nil {
 			// v2 Xenon. Pass the container object to the UVM.
-			gcsDocument = &hcsschema.HostedSystem{
+			pc.gcsDocument = &hcsschema.HostedSystem{
 				SchemaVersion: schemaversion.SchemaV21(),
 				Container:     v2,
 			}
 		} else {
 			// v2 Argon. Pass the container object to the HCS.
-			hcsDocument = &hcsschema.ComputeSystem{
+			pc.hcsDocument = &hcsschema.ComputeSystem{
 				Owner:                             coi.actualOwner,
 				SchemaVersion:                     schemaversion.SchemaV21(),
 				ShouldTerminateOnLastHandleClosed: true,
@@ -218,20 +260,38 @@ func CreateContainer(ctx context.Context, createOptions *CreateOptions) (_ cow.C
 		}
 	}
 
+	return pc, nil
+}
+
+// Commit issues the actual HCS (or, for a v2 Xenon, GCS) create call for a
+// container Prepare staged, and returns the resulting container. Its
+// resources stay in pc.Resources -- mirroring CreateContainer's own contract,
+// they remain there even on error so a caller with
+// CreateOptions.DoNotReleaseResourcesOnFailure can release them itself.
+func Commit(ctx context.Context, pc *PreparedContainer) (_ cow.Container, err error) {
+	coi := pc.coi
+	defer func() {
+		if err != nil {
+			if !coi.DoNotReleaseResourcesOnFailure {
+				resources.ReleaseResources(ctx, pc.Resources, coi.HostingSystem, true)
+			}
+		}
+	}()
+
 	log.G(ctx).Debug("hcsshim::CreateContainer creating compute system")
-	if gcsDocument != nil {
-		c, err := coi.HostingSystem.CreateContainer(ctx, coi.actualID, gcsDocument)
+	if pc.gcsDocument != nil {
+		c, err := coi.HostingSystem.CreateContainer(ctx, coi.actualID, pc.gcsDocument)
 		if err != nil {
-			return nil, r, err
+			return nil, err
 		}
-		return c, r, nil
+		return c, nil
 	}
 
-	system, err := hcs.CreateComputeSystem(ctx, coi.actualID, hcsDocument)
+	system, err := hcs.CreateComputeSystem(ctx, coi.actualID, pc.hcsDocument)
 	if err != nil {
-		return nil, r, err
+		return nil, err
 	}
-	return system, r, nil
+	return system, nil
 }
 
 // isV2Xenon returns true if the create options are for a HCS schema V2 xenon container
@@ -41,6 +41,40 @@ func getDeviceUtilHostPath() string {
 	return filepath.Join(filepath.Dir(os.Args[0]), deviceUtilExeName)
 }
 
+// resolveExternalDevices turns each `io.microsoft.container.device.<name>`
+// annotation into a specs.WindowsDevice by resolving it through the plugin
+// configured via oci.AnnotationAssignedDeviceResolverAddress, so those
+// devices get assigned alongside ones listed directly on the spec. Returns
+// nil without error if no such annotation is present.
+func resolveExternalDevices(ctx context.Context, annotations map[string]string) ([]specs.WindowsDevice, error) {
+	var names []string
+	for key := range annotations {
+		if strings.HasPrefix(key, oci.AnnotationContainerDeviceResolverPrefix) {
+			names = append(names, strings.TrimPrefix(key, oci.AnnotationContainerDeviceResolverPrefix))
+		}
+	}
+	if len(names) == 0 {
+		return nil, nil
+	}
+
+	address := annotations[oci.AnnotationAssignedDeviceResolverAddress]
+	if address == "" {
+		return nil, errors.Errorf("%d device(s) requested via %s annotations but %s is not set", len(names), oci.AnnotationContainerDeviceResolverPrefix, oci.AnnotationAssignedDeviceResolverAddress)
+	}
+	resolver := devices.NewTTRPCResolver(address)
+
+	result := make([]specs.WindowsDevice, 0, len(names))
+	for _, name := range names {
+		request := annotations[oci.AnnotationContainerDeviceResolverPrefix+name]
+		idType, deviceID, err := resolver.Resolve(ctx, name, request)
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to resolve device %q", name)
+		}
+		result = append(result, specs.WindowsDevice{IDType: idType, ID: deviceID})
+	}
+	return result, nil
+}
+
 // handleAssignedDevicesWindows does all of the work to setup the hosting UVM, assign in devices
 // specified on the spec, and install any necessary, specified kernel drivers into the UVM.
 //
@@ -61,6 +95,12 @@ func handleAssignedDevicesWindows(ctx context.Context, vm *uvm.UtilityVM, annota
 		}
 	}()
 
+	resolvedDevs, err := resolveExternalDevices(ctx, annotations)
+	if err != nil {
+		return nil, nil, err
+	}
+	specDevs = append(specDevs, resolvedDevs...)
+
 	// install the device util tool in the UVM
 	toolHostPath := getDeviceUtilHostPath()
 	options := vm.DefaultVSMBOptions(true)
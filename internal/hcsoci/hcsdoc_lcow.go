@@ -40,9 +40,18 @@ func createLCOWSpec(coi *createOptionsInternal) (*specs.Spec, error) {
 	if spec.Linux.Resources != nil {
 		spec.Linux.Resources.Devices = nil
 		spec.Linux.Resources.Pids = nil
-		spec.Linux.Resources.BlockIO = nil
 		spec.Linux.Resources.HugepageLimits = nil
 		spec.Linux.Resources.Network = nil
+		// BlockIO (weight/throttle limits, surfaced to the guest as io.max) is
+		// forwarded the same way Memory and CPU already are, so one container
+		// in a multi-container pod can't starve its neighbors' disk IO.
+		//
+		// Memory.Swap and Memory.Swappiness are likewise forwarded unmodified:
+		// GCS applies them to the container's cgroup the same way runc would.
+		// They only take effect if the guest actually has swap space, which
+		// the guest's cgroup accounting doesn't provide on its own -- see
+		// allocateLinuxResources' handling of Memory.Swap for how hcsshim
+		// hot-adds a SCSI-backed swap device sized to it.
 	}
 	spec.Linux.Seccomp = nil
 
@@ -1,3 +1,4 @@
+//go:build windows
 // +build windows
 
 package hcsoci
@@ -154,6 +155,12 @@ func createWindowsContainerDocument(ctx context.Context, coi *createOptionsInter
 			Maximum: cpuLimit,
 			Weight:  cpuWeight,
 		}
+		// Note: there is no Affinity field on this document (nor on the v1
+		// schema's Processor settings) for pinning a container to specific
+		// logical processors or processor groups. That kind of pinning is
+		// only available to a caller that owns the job object a container's
+		// process is placed in directly, via jobobject.SetAffinity; it can't
+		// be requested through container creation here.
 	}
 
 	// Memory Resources
@@ -211,7 +218,15 @@ func createWindowsContainerDocument(ctx context.Context, coi *createOptionsInter
 	}
 
 	if coi.Spec.Root.Readonly {
-		return nil, nil, fmt.Errorf(`invalid container spec - readonly is not supported for Windows containers`)
+		// Unlike LCOW, where omitting the scratch from the overlay gives a
+		// genuinely read-only root (see CombineLayersLCOW), neither the v1 nor
+		// the v2 HCS container schema has any flag for marking the combined
+		// storage of a Windows container read-only: Storage.Path (v2) and
+		// ContainerConfig.VolumePath/LayerFolderPath (v1) always point at a
+		// writable scratch that the Windows container filter itself relies on
+		// for bookkeeping, regardless of what the container ever writes to it.
+		// There is no supported way for hcsshim to honor this today.
+		return nil, nil, fmt.Errorf(`invalid container spec - readonly root is not supported for Windows containers`)
 	}
 
 	// Strip off the top-most RW/scratch layer as that's passed in separately to HCS for v1
@@ -0,0 +1,27 @@
+//go:build windows
+// +build windows
+
+package hcsoci
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/Microsoft/hcsshim/internal/layers"
+)
+
+// imageMount is the resources.ResourceCloser for a mount.Type == "image"
+// mount: it wraps the read-only ImageLayers set up by layers.MountImageLayers,
+// whose Release takes an extra "all" argument that doesn't fit the single
+// Release(ctx) method ResourceCloser requires.
+type imageMount struct {
+	layers *layers.ImageLayers
+}
+
+func (m *imageMount) Release(ctx context.Context) error {
+	return m.layers.Release(ctx, false)
+}
+
+func (m *imageMount) String() string {
+	return fmt.Sprintf("image mount %+v", m.layers)
+}
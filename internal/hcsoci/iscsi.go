@@ -0,0 +1,68 @@
+//go:build windows
+// +build windows
+
+package hcsoci
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/Microsoft/hcsshim/internal/iscsi"
+	"github.com/Microsoft/hcsshim/internal/uvm"
+)
+
+// iscsiDisk is the resources.ResourceCloser for a mount.Type == "iscsi"
+// mount: it ties together the SCSI attachment of the LUN and the iSCSI
+// session it came from, so releasing one releases both in the right order.
+type iscsiDisk struct {
+	scsiMount *uvm.SCSIMount
+	target    iscsi.TargetConfig
+}
+
+func (d *iscsiDisk) Release(ctx context.Context) error {
+	if err := d.scsiMount.Release(ctx); err != nil {
+		return fmt.Errorf("releasing iSCSI SCSI attachment: %s", err)
+	}
+	if err := iscsi.Logout(ctx, d.target); err != nil {
+		return fmt.Errorf("logging out of iSCSI target %s: %s", d.target.IQN, err)
+	}
+	return nil
+}
+
+func (d *iscsiDisk) String() string {
+	return fmt.Sprintf("iscsi disk %s (%s)", d.target.IQN, d.scsiMount)
+}
+
+// parseISCSIMountSource parses a mount.Source of the form
+// "iscsi://<portal>/<iqn>", mirroring the "sandbox://" prefix convention
+// already used for LCOW mounts in this package.
+func parseISCSIMountSource(source string) (portal, iqn string, err error) {
+	rest := strings.TrimPrefix(source, "iscsi://")
+	parts := strings.SplitN(rest, "/", 2)
+	if len(parts) != 2 || parts[0] == "" || parts[1] == "" {
+		return "", "", fmt.Errorf("invalid iscsi mount source %q, expected iscsi://<portal>/<iqn>", source)
+	}
+	return parts[0], parts[1], nil
+}
+
+// iscsiTargetConfigFromMountOptions builds a TargetConfig for the iSCSI
+// target identified by an "iscsi://<portal>/<iqn>" mount source, picking up
+// CHAP credentials from "chapuser=" / "chapsecret=" mount options if
+// present.
+func iscsiTargetConfigFromMountOptions(source string, options []string) (iscsi.TargetConfig, error) {
+	portal, iqn, err := parseISCSIMountSource(source)
+	if err != nil {
+		return iscsi.TargetConfig{}, err
+	}
+	tc := iscsi.TargetConfig{Portal: portal, IQN: iqn}
+	for _, o := range options {
+		switch {
+		case strings.HasPrefix(o, "chapuser="):
+			tc.CHAPUsername = strings.TrimPrefix(o, "chapuser=")
+		case strings.HasPrefix(o, "chapsecret="):
+			tc.CHAPSecret = strings.TrimPrefix(o, "chapsecret=")
+		}
+	}
+	return tc, nil
+}
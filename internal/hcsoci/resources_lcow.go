@@ -1,3 +1,4 @@
+//go:build windows
 // +build windows
 
 package hcsoci
@@ -12,13 +13,16 @@ import (
 	"path/filepath"
 	"strings"
 
+	"github.com/Microsoft/hcsshim/internal/iscsi"
 	"github.com/Microsoft/hcsshim/internal/layers"
+	"github.com/Microsoft/hcsshim/internal/lcow"
 	"github.com/Microsoft/hcsshim/internal/log"
 	"github.com/Microsoft/hcsshim/internal/oci"
 	"github.com/Microsoft/hcsshim/internal/resources"
 	"github.com/Microsoft/hcsshim/internal/uvm"
 	specs "github.com/opencontainers/runtime-spec/specs-go"
 	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
 )
 
 // getGPUVHDPath gets the gpu vhd path from the shim options or uses the default if no
@@ -64,11 +68,19 @@ func allocateLinuxResources(ctx context.Context, coi *createOptionsInternal, r *
 		return errors.New("must provide either Windows.LayerFolders or Root.Path")
 	}
 
+	if coi.Spec.Windows != nil && len(coi.Spec.Windows.LayerFolders) > 0 {
+		if err := allocateLinuxSwap(ctx, coi, r); err != nil {
+			return err
+		}
+	}
+
 	for i, mount := range coi.Spec.Mounts {
 		switch mount.Type {
 		case "bind":
 		case "physical-disk":
 		case "virtual-disk":
+		case "iscsi":
+		case "image":
 		default:
 			// Unknown mount type
 			continue
@@ -102,6 +114,29 @@ func allocateLinuxResources(ctx context.Context, coi *createOptionsInternal, r *
 				uvmPathForShare = scsiMount.UVMPath
 				r.Add(scsiMount)
 				coi.Spec.Mounts[i].Type = "none"
+			} else if mount.Type == "iscsi" {
+				l.Debug("hcsshim::allocateLinuxResources Logging into iSCSI target and hot-adding SCSI disk for OCI mount")
+				target, err := iscsiTargetConfigFromMountOptions(hostPath, mount.Options)
+				if err != nil {
+					return fmt.Errorf("invalid iscsi mount %+v: %s", mount, err)
+				}
+				diskNumber, err := iscsi.Login(ctx, target)
+				if err != nil {
+					return fmt.Errorf("logging into iscsi target for mount %+v: %s", mount, err)
+				}
+				uvmPathForShare = fmt.Sprintf(uvm.LCOWGlobalMountPrefix, coi.HostingSystem.UVMMountCounter())
+				scsiMount, err := coi.HostingSystem.AddSCSIPhysicalDiskByNumber(ctx, diskNumber, uvmPathForShare, readOnly)
+				if err != nil {
+					if logoutErr := iscsi.Logout(ctx, target); logoutErr != nil {
+						log.G(ctx).WithError(logoutErr).Warn("failed to log out of iscsi target after failed SCSI attach")
+					}
+					return fmt.Errorf("adding iscsi disk mount %+v: %s", mount, err)
+				}
+
+				uvmPathForFile = scsiMount.UVMPath
+				uvmPathForShare = scsiMount.UVMPath
+				r.Add(&iscsiDisk{scsiMount: scsiMount, target: target})
+				coi.Spec.Mounts[i].Type = "none"
 			} else if mount.Type == "virtual-disk" {
 				l.Debug("hcsshim::allocateLinuxResources Hot-adding SCSI virtual disk for OCI mount")
 				uvmPathForShare = fmt.Sprintf(uvm.LCOWGlobalMountPrefix, coi.HostingSystem.UVMMountCounter())
@@ -117,6 +152,21 @@ func allocateLinuxResources(ctx context.Context, coi *createOptionsInternal, r *
 				uvmPathForShare = scsiMount.UVMPath
 				r.Add(scsiMount)
 				coi.Spec.Mounts[i].Type = "none"
+			} else if mount.Type == "image" {
+				l.Debug("hcsshim::allocateLinuxResources Mounting image volume for OCI mount")
+				imageLayers := oci.ImageMountLayers(coi.Spec, hostPath)
+				if len(imageLayers) == 0 {
+					return fmt.Errorf("no layers found for image mount %+v (expected annotation %s%s)", mount, oci.AnnotationContainerImageMountPrefix, hostPath)
+				}
+				uvmPathForShare = fmt.Sprintf(uvm.LCOWGlobalMountPrefix, coi.HostingSystem.UVMMountCounter())
+				rootfsPath, err := layers.MountImageLayers(ctx, imageLayers, uvmPathForShare, coi.HostingSystem)
+				if err != nil {
+					return fmt.Errorf("mounting image volume %+v: %s", mount, err)
+				}
+				uvmPathForFile = rootfsPath
+				uvmPathForShare = rootfsPath
+				r.Add(&imageMount{layers: layers.NewReadOnlyImageLayers(coi.HostingSystem, uvmPathForShare, imageLayers)})
+				coi.Spec.Mounts[i].Type = "none"
 			} else if strings.HasPrefix(mount.Source, "sandbox://") {
 				// Mounts that map to a path in UVM are specified with 'sandbox://' prefix.
 				// example: sandbox:///a/dirInUvm destination:/b/dirInContainer
@@ -182,3 +232,39 @@ func allocateLinuxResources(ctx context.Context, coi *createOptionsInternal, r *
 	}
 	return nil
 }
+
+// allocateLinuxSwap hot-adds a SCSI-backed swap device sized to the gap
+// between the container's memory limit and swap limit (spec.Linux.Resources.
+// Memory.Swap - .Limit, rounded up to a whole GB by lcow.AddSwap), if both
+// are set and Swap is the larger of the two,
+// so the guest has somewhere to swap to instead of invoking the OOM killer
+// as soon as it hits Limit. It is a no-op if either field is unset, or if
+// Swap <= Limit (i.e. the caller asked for no additional swap).
+//
+// The backing VHDX is created alongside the container's scratch, in the
+// last entry of Windows.LayerFolders.
+func allocateLinuxSwap(ctx context.Context, coi *createOptionsInternal, r *resources.Resources) error {
+	if coi.Spec.Linux == nil || coi.Spec.Linux.Resources == nil {
+		return nil
+	}
+	mem := coi.Spec.Linux.Resources.Memory
+	if mem == nil || mem.Swap == nil || mem.Limit == nil || *mem.Swap <= *mem.Limit {
+		return nil
+	}
+	sizeMB := uint64(*mem.Swap-*mem.Limit) / (1024 * 1024)
+	if sizeMB == 0 {
+		sizeMB = 1
+	}
+	swapPath := filepath.Join(coi.Spec.Windows.LayerFolders[len(coi.Spec.Windows.LayerFolders)-1], "swap.vhdx")
+
+	log.G(ctx).WithFields(logrus.Fields{
+		"path":   swapPath,
+		"sizeMB": sizeMB,
+	}).Debug("hcsshim::allocateLinuxResources adding swap device")
+	scsiMount, device, err := lcow.AddSwap(ctx, coi.HostingSystem, swapPath, sizeMB)
+	if err != nil {
+		return fmt.Errorf("failed to add swap device: %s", err)
+	}
+	r.Add(&swapDisk{vm: coi.HostingSystem, scsiMount: scsiMount, device: device})
+	return nil
+}
@@ -1,3 +1,4 @@
+//go:build windows
 // +build windows
 
 package hcsoci
@@ -14,6 +15,7 @@ import (
 	"github.com/Microsoft/hcsshim/internal/credentials"
 	"github.com/Microsoft/hcsshim/internal/layers"
 	"github.com/Microsoft/hcsshim/internal/log"
+	"github.com/Microsoft/hcsshim/internal/oci"
 	"github.com/Microsoft/hcsshim/internal/resources"
 	"github.com/Microsoft/hcsshim/internal/schemaversion"
 	"github.com/Microsoft/hcsshim/internal/uvm"
@@ -21,27 +23,141 @@ import (
 	specs "github.com/opencontainers/runtime-spec/specs-go"
 )
 
+// parseComPortDestination parses a com-port mount's Destination, which must be
+// one of "COM1" through "COM4", into the zero-based port number AddComPort
+// expects (so "COM1" is 0, matching HCS's own ComPorts map keys).
+func parseComPortDestination(destination string) (uint8, error) {
+	switch destination {
+	case "COM1":
+		return 0, nil
+	case "COM2":
+		return 1, nil
+	case "COM3":
+		return 2, nil
+	case "COM4":
+		return 3, nil
+	default:
+		return 0, fmt.Errorf("destination must be one of COM1-COM4, got %q", destination)
+	}
+}
+
+// imageVolumeDirName turns a container destination path into a filesystem-safe
+// directory name, so distinct destinations never collide and the same
+// destination always maps to the same name.
+func imageVolumeDirName(destination string) string {
+	// "_" is escaped as "__" so the separator escapes below can't be confused
+	// with a literal underscore.
+	return strings.NewReplacer("_", "__", `\`, "_b", "/", "_f", ":", "_c").Replace(destination)
+}
+
+// addImageVolumeMounts applies oci.ImageVolumePolicy to the image-declared
+// volume paths listed via oci.ImageVolumePaths, by synthesizing a plain bind
+// mount for each one that isn't already covered by an explicit mount in
+// coi.Spec.Mounts, before the mounts loop below processes them the same way
+// it would a caller-provided one (hot-adding a VSMB share, since this is
+// only called for hypervisor-isolated containers).
+//
+// "ignore" (the default) leaves coi.Spec.Mounts untouched: the declared path
+// is left to fall inside the container's own writable layer, matching
+// behavior before this annotation existed.
+func addImageVolumeMounts(coi *createOptionsInternal, scratchLocation string) error {
+	paths := oci.ImageVolumePaths(coi.Spec)
+	if len(paths) == 0 {
+		return nil
+	}
+	policy := oci.ImageVolumePolicy(coi.Spec)
+	if policy == "ignore" {
+		return nil
+	}
+
+	var shareRoot string
+	switch policy {
+	case "scratch":
+		shareRoot = filepath.Join(scratchLocation, "volumes")
+	case "share":
+		shareRoot = oci.ImageVolumeSharePath(coi.Spec)
+		if shareRoot == "" {
+			return fmt.Errorf("%s is required when %s is \"share\"", oci.AnnotationContainerImageVolumeSharePath, oci.AnnotationContainerImageVolumePolicy)
+		}
+	default:
+		return fmt.Errorf("unsupported %s %q", oci.AnnotationContainerImageVolumePolicy, policy)
+	}
+
+	for _, destination := range paths {
+		alreadyMounted := false
+		for _, m := range coi.Spec.Mounts {
+			if m.Destination == destination {
+				alreadyMounted = true
+				break
+			}
+		}
+		if alreadyMounted {
+			continue
+		}
+		hostPath := filepath.Join(shareRoot, imageVolumeDirName(destination))
+		if err := os.MkdirAll(hostPath, 0777); err != nil {
+			return fmt.Errorf("failed to create image volume directory %s: %s", hostPath, err)
+		}
+		coi.Spec.Mounts = append(coi.Spec.Mounts, specs.Mount{
+			Source:      hostPath,
+			Destination: destination,
+		})
+	}
+	return nil
+}
+
 func allocateWindowsResources(ctx context.Context, coi *createOptionsInternal, r *resources.Resources) error {
 	if coi.Spec == nil || coi.Spec.Windows == nil || coi.Spec.Windows.LayerFolders == nil {
 		return fmt.Errorf("field 'Spec.Windows.Layerfolders' is not populated")
 	}
 
 	scratchFolder := coi.Spec.Windows.LayerFolders[len(coi.Spec.Windows.LayerFolders)-1]
+	// scratchLocation allows a container's writable layer to be placed on a
+	// different volume than the one its (read-only) layer folders live on, e.g.
+	// so containers in the same pod with very different disk needs don't have
+	// to share a volume.
+	scratchLocation := oci.ParseAnnotationsScratchLocation(coi.Spec, oci.AnnotationContainerScratchLocation, scratchFolder)
 
 	// TODO: Remove this code for auto-creation. Make the caller responsible.
 	// Create the directory for the RW scratch layer if it doesn't exist
-	if _, err := os.Stat(scratchFolder); os.IsNotExist(err) {
-		if err := os.MkdirAll(scratchFolder, 0777); err != nil {
-			return fmt.Errorf("failed to auto-create container scratch folder %s: %s", scratchFolder, err)
+	if _, err := os.Stat(scratchLocation); os.IsNotExist(err) {
+		if err := os.MkdirAll(scratchLocation, 0777); err != nil {
+			return fmt.Errorf("failed to auto-create container scratch folder %s: %s", scratchLocation, err)
 		}
 	}
 
+	scratchPath := filepath.Join(scratchLocation, "sandbox.vhdx")
 	// Create sandbox.vhdx if it doesn't exist in the scratch folder. It's called sandbox.vhdx
 	// rather than scratch.vhdx as in the v1 schema, it's hard-coded in HCS.
-	if _, err := os.Stat(filepath.Join(scratchFolder, "sandbox.vhdx")); os.IsNotExist(err) {
-		if err := wclayer.CreateScratchLayer(ctx, scratchFolder, coi.Spec.Windows.LayerFolders[:len(coi.Spec.Windows.LayerFolders)-1]); err != nil {
+	if _, err := os.Stat(scratchPath); os.IsNotExist(err) {
+		if err := wclayer.CreateScratchLayer(ctx, scratchLocation, coi.Spec.Windows.LayerFolders[:len(coi.Spec.Windows.LayerFolders)-1]); err != nil {
 			return fmt.Errorf("failed to CreateSandboxLayer %s", err)
 		}
+		if sizeInMB := oci.ParseAnnotationsScratchSize(ctx, coi.Spec, oci.AnnotationContainerScratchSizeInMB, 0); sizeInMB != 0 {
+			if err := wclayer.ExpandScratchSize(ctx, scratchPath, sizeInMB*1024*1024); err != nil {
+				return fmt.Errorf("failed to expand scratch size for container: %s", err)
+			}
+		}
+		if oci.ParseAnnotationsBool(ctx, coi.Spec, oci.AnnotationContainerScratchMemoryBacked, false) {
+			if err := wclayer.MarkScratchLayerMemoryBacked(scratchPath); err != nil {
+				return fmt.Errorf("failed to mark container scratch as memory-backed: %s", err)
+			}
+		}
+	}
+
+	if scratchLocation != scratchFolder {
+		// mountContainerLayers looks for sandbox.vhdx under the last entry of
+		// LayerFolders, resolving a symlink there the same way LCOW does to
+		// share a scratch space, so leave one pointing at the real location.
+		linkPath := filepath.Join(scratchFolder, "sandbox.vhdx")
+		if _, err := os.Lstat(linkPath); os.IsNotExist(err) {
+			if err := os.MkdirAll(scratchFolder, 0777); err != nil {
+				return fmt.Errorf("failed to auto-create container scratch folder %s: %s", scratchFolder, err)
+			}
+			if err := os.Symlink(scratchPath, linkPath); err != nil {
+				return fmt.Errorf("failed to link container scratch folder %s to %s: %s", scratchFolder, scratchLocation, err)
+			}
+		}
 	}
 
 	if coi.Spec.Root == nil {
@@ -60,9 +176,22 @@ func allocateWindowsResources(ctx context.Context, coi *createOptionsInternal, r
 		r.SetLayers(layers)
 	}
 
+	if coi.HostingSystem != nil {
+		if err := addImageVolumeMounts(coi, scratchLocation); err != nil {
+			return err
+		}
+	}
+
 	// Validate each of the mounts. If this is a V2 Xenon, we have to add them as
 	// VSMB shares to the utility VM. For V1 Xenon and Argons, there's nothing for
 	// us to do as it's done by HCS.
+	//
+	// com-port mounts are a separate case from the others: they hot-add a VM-level
+	// serial device rather than anything that appears as a directory or pipe inside
+	// the container, so the mounts that represent them are dropped from
+	// coi.Spec.Mounts entirely once handled, instead of just having their Type
+	// cleared like the SCSI cases below.
+	var comPortMountIndexes []int
 	for i, mount := range coi.Spec.Mounts {
 		if mount.Destination == "" || mount.Source == "" {
 			return fmt.Errorf("invalid OCI spec - a mount must have both source and a destination: %+v", mount)
@@ -71,6 +200,7 @@ func allocateWindowsResources(ctx context.Context, coi *createOptionsInternal, r
 		case "":
 		case "physical-disk":
 		case "virtual-disk":
+		case "com-port":
 		default:
 			return fmt.Errorf("invalid OCI spec - Type '%s' not supported", mount.Type)
 		}
@@ -78,10 +208,13 @@ func allocateWindowsResources(ctx context.Context, coi *createOptionsInternal, r
 		if coi.HostingSystem != nil && schemaversion.IsV21(coi.actualSchemaVersion) {
 			uvmPath := fmt.Sprintf(uvm.WCOWGlobalMountPrefix, coi.HostingSystem.UVMMountCounter())
 			readOnly := false
+			directMap := false
 			for _, o := range mount.Options {
-				if strings.ToLower(o) == "ro" {
+				switch strings.ToLower(o) {
+				case "ro":
 					readOnly = true
-					break
+				case "directmap":
+					directMap = true
 				}
 			}
 			l := log.G(ctx).WithField("mount", fmt.Sprintf("%+v", mount))
@@ -101,6 +234,18 @@ func allocateWindowsResources(ctx context.Context, coi *createOptionsInternal, r
 				}
 				coi.Spec.Mounts[i].Type = ""
 				r.Add(scsiMount)
+			} else if mount.Type == "com-port" {
+				l.Debug("hcsshim::allocateWindowsResources Hot-adding com port for OCI mount")
+				port, err := parseComPortDestination(mount.Destination)
+				if err != nil {
+					return fmt.Errorf("invalid com-port mount %+v: %s", mount, err)
+				}
+				comPort, err := coi.HostingSystem.AddComPort(ctx, port, mount.Source)
+				if err != nil {
+					return fmt.Errorf("adding com port mount %+v: %s", mount, err)
+				}
+				r.Add(comPort)
+				comPortMountIndexes = append(comPortMountIndexes, i)
 			} else {
 				if uvm.IsPipe(mount.Source) {
 					pipe, err := coi.HostingSystem.AddPipe(ctx, mount.Source)
@@ -111,6 +256,20 @@ func allocateWindowsResources(ctx context.Context, coi *createOptionsInternal, r
 				} else {
 					l.Debug("hcsshim::allocateWindowsResources Hot-adding VSMB share for OCI mount")
 					options := coi.HostingSystem.DefaultVSMBOptions(readOnly)
+					if directMap {
+						if !readOnly {
+							return fmt.Errorf("the directmap mount option is only supported on read-only mounts: %+v", mount)
+						}
+						ok, err := coi.HostingSystem.DirectMapVSMBSupported(mount.Source)
+						if err != nil {
+							return fmt.Errorf("checking directmap support for mount %+v: %s", mount, err)
+						}
+						if !ok {
+							return fmt.Errorf("the directmap mount option is not supported for %+v on this host", mount)
+						}
+						options.NoDirectmap = false
+						options.NonCacheIo = false
+					}
 					share, err := coi.HostingSystem.AddVSMB(ctx, mount.Source, options)
 					if err != nil {
 						return fmt.Errorf("failed to add VSMB share to utility VM for mount %+v: %s", mount, err)
@@ -118,20 +277,39 @@ func allocateWindowsResources(ctx context.Context, coi *createOptionsInternal, r
 					r.Add(share)
 				}
 			}
+		} else if mount.Type == "com-port" {
+			return fmt.Errorf("com-port mounts are only supported for hypervisor-isolated (v2 Xenon) WCOW containers: %+v", mount)
+		}
+	}
+
+	if len(comPortMountIndexes) > 0 {
+		skip := make(map[int]bool, len(comPortMountIndexes))
+		for _, idx := range comPortMountIndexes {
+			skip[idx] = true
+		}
+		filtered := coi.Spec.Mounts[:0]
+		for i, mount := range coi.Spec.Mounts {
+			if !skip[i] {
+				filtered = append(filtered, mount)
+			}
 		}
+		coi.Spec.Mounts = filtered
 	}
 
 	if cs, ok := coi.Spec.Windows.CredentialSpec.(string); ok {
 		// Only need to create a CCG instance for v2 containers
 		if schemaversion.IsV21(coi.actualSchemaVersion) {
 			hypervisorIsolated := coi.HostingSystem != nil
-			ccgInstance, ccgResource, err := credentials.CreateCredentialGuard(ctx, coi.actualID, cs, hypervisorIsolated)
-			if err != nil {
-				return err
-			}
-			coi.ccgState = ccgInstance.CredentialGuard
-			r.Add(ccgResource)
 			if hypervisorIsolated {
+				// Containers sharing this pod's uVM and credential spec share a
+				// single CCG instance, so that only the first container to ask
+				// for a given GMSA spec pays for its setup.
+				ccgInstance, err := coi.HostingSystem.AddCCGInstance(ctx, coi.actualID, cs)
+				if err != nil {
+					return err
+				}
+				coi.ccgState = ccgInstance.CredentialGuardState()
+				r.Add(ccgInstance)
 				// If hypervisor isolated we need to add an hvsocket service table entry
 				// By default HVSocket won't allow something inside the VM to connect
 				// back to a process on the host. We need to update the HVSocket service table
@@ -141,10 +319,17 @@ func allocateWindowsResources(ctx context.Context, coi *createOptionsInternal, r
 				// for the previous design requirement for CCG V2 where the service entry
 				// must be present in the UVM'S HCS document before being sent over as hot adding
 				// an HvSocket service was not possible.
-				hvSockConfig := ccgInstance.HvSocketConfig
+				hvSockConfig := ccgInstance.HvSocketConfig()
 				if err := coi.HostingSystem.UpdateHvSocketService(ctx, hvSockConfig.ServiceId, hvSockConfig.ServiceConfig); err != nil {
 					return fmt.Errorf("failed to update hvsocket service: %s", err)
 				}
+			} else {
+				ccgInstance, ccgResource, err := credentials.CreateCredentialGuard(ctx, coi.actualID, cs, false)
+				if err != nil {
+					return err
+				}
+				coi.ccgState = ccgInstance.CredentialGuard
+				r.Add(ccgResource)
 			}
 		}
 	}
@@ -0,0 +1,33 @@
+//go:build windows
+// +build windows
+
+package hcsoci
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/Microsoft/hcsshim/internal/lcow"
+	"github.com/Microsoft/hcsshim/internal/uvm"
+)
+
+// swapDisk is the resources.ResourceCloser for a SCSI-backed swap device
+// added via lcow.AddSwap: it ties together deactivating the swap space in
+// the guest and detaching/deleting its backing VHDX, so releasing one
+// releases both in the right order.
+type swapDisk struct {
+	vm        *uvm.UtilityVM
+	scsiMount *uvm.SCSIMount
+	device    string
+}
+
+func (d *swapDisk) Release(ctx context.Context) error {
+	if err := lcow.RemoveSwap(ctx, d.vm, d.scsiMount, d.device); err != nil {
+		return fmt.Errorf("releasing swap disk: %s", err)
+	}
+	return nil
+}
+
+func (d *swapDisk) String() string {
+	return fmt.Sprintf("swap disk %s (%s)", d.device, d.scsiMount)
+}
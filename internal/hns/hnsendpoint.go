@@ -32,7 +32,7 @@ type HNSEndpoint struct {
 	EncapOverhead      uint16            `json:",omitempty"`
 }
 
-//SystemType represents the type of the system on which actions are done
+// SystemType represents the type of the system on which actions are done
 type SystemType string
 
 // SystemType const
@@ -102,6 +102,33 @@ type endpointAttachInfo struct {
 	SharedContainers json.RawMessage `json:",omitempty"`
 }
 
+// EndpointStats represents the bandwidth and packet counters accumulated by
+// an endpoint since it was created.
+type EndpointStats struct {
+	BytesReceived          uint64 `json:"BytesReceived"`
+	BytesSent              uint64 `json:"BytesSent"`
+	DroppedPacketsIncoming uint64 `json:"DroppedPacketsIncoming"`
+	DroppedPacketsOutgoing uint64 `json:"DroppedPacketsOutgoing"`
+	PacketsReceived        uint64 `json:"PacketsReceived"`
+	PacketsSent            uint64 `json:"PacketsSent"`
+}
+
+// Statistics retrieves the bandwidth and packet counters for the endpoint
+// from HNS.
+func (endpoint *HNSEndpoint) Statistics() (EndpointStats, error) {
+	operation := "Statistics"
+	title := "hcsshim::HNSEndpoint::" + operation
+	logrus.Debugf(title+" id=%s", endpoint.Id)
+
+	var stats EndpointStats
+	err := hnsCall("GET", "/endpoints/"+endpoint.Id+"/stats", "", &stats)
+	if err != nil {
+		return EndpointStats{}, err
+	}
+
+	return stats, nil
+}
+
 func (endpoint *HNSEndpoint) IsAttached(vID string) (bool, error) {
 	attachInfo := endpointAttachInfo{}
 	err := hnsCall("GET", "/endpoints/"+endpoint.Id, "", &attachInfo)
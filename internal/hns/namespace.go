@@ -92,6 +92,30 @@ func GetNamespaceEndpoints(id string) ([]string, error) {
 	return endpoints, nil
 }
 
+// NamespaceStatistics returns the sum of the HNS endpoint statistics for
+// every endpoint currently in namespace `id`.
+func NamespaceStatistics(id string) (EndpointStats, error) {
+	endpoints, err := GetNamespaceEndpoints(id)
+	if err != nil {
+		return EndpointStats{}, err
+	}
+	var total EndpointStats
+	for _, endpointID := range endpoints {
+		endpoint := &HNSEndpoint{Id: endpointID}
+		s, err := endpoint.Statistics()
+		if err != nil {
+			return EndpointStats{}, fmt.Errorf("get statistics for endpoint %s: %s", endpointID, err)
+		}
+		total.BytesReceived += s.BytesReceived
+		total.BytesSent += s.BytesSent
+		total.DroppedPacketsIncoming += s.DroppedPacketsIncoming
+		total.DroppedPacketsOutgoing += s.DroppedPacketsOutgoing
+		total.PacketsReceived += s.PacketsReceived
+		total.PacketsSent += s.PacketsSent
+	}
+	return total, nil
+}
+
 func AddNamespaceEndpoint(id string, endpointID string) error {
 	resource := namespaceResourceRequest{
 		Type: "Endpoint",
@@ -0,0 +1,75 @@
+// Package hooks runs the OCI runtime spec's `Hooks` at the points in task
+// creation/start where they're meant to fire: `CreateRuntime` before the
+// compute system is created (pre-create), `CreateContainer` once it exists
+// (post-create), and `StartContainer` just before the init process is
+// started (pre-start). Each hook is handed the task's `specs.State` as JSON
+// on stdin, per the OCI runtime spec hook protocol.
+//
+// This is the integration point an NRI-style out-of-process plugin (device
+// injector, policy agent, ...) would hook into on Linux nodes: a plugin
+// registers itself as a `specs.Hook` pointing at a small relay binary, or is
+// invoked directly if it matches the Path/Args/Env shape of a hook. A richer
+// NRI ttrpc plugin protocol, where plugins stay connected and are handed the
+// full uVM info rather than just the OCI state, is not implemented here: it
+// requires generated ttrpc/proto types this module does not vendor.
+package hooks
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"os/exec"
+	"time"
+
+	"github.com/Microsoft/hcsshim/internal/log"
+	specs "github.com/opencontainers/runtime-spec/specs-go"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+)
+
+// defaultTimeout is used for a hook that does not specify its own Timeout.
+const defaultTimeout = 30 * time.Second
+
+// Run executes `hs` in order, each with `state` (marshaled to JSON) written
+// to its stdin. It stops and returns an error at the first hook that fails,
+// matching the OCI runtime spec's hook semantics.
+func Run(ctx context.Context, hs []specs.Hook, state *specs.State) error {
+	if len(hs) == 0 {
+		return nil
+	}
+	stateJSON, err := json.Marshal(state)
+	if err != nil {
+		return errors.Wrap(err, "failed to marshal hook state")
+	}
+	for _, h := range hs {
+		if err := runOne(ctx, h, stateJSON); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func runOne(ctx context.Context, h specs.Hook, stateJSON []byte) error {
+	timeout := defaultTimeout
+	if h.Timeout != nil {
+		timeout = time.Duration(*h.Timeout) * time.Second
+	}
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	cmd := exec.CommandContext(ctx, h.Path, h.Args...)
+	cmd.Env = h.Env
+	cmd.Stdin = bytes.NewReader(stateJSON)
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+
+	log.G(ctx).WithFields(logrus.Fields{
+		"path": h.Path,
+		"args": h.Args,
+	}).Debug("running OCI hook")
+
+	if err := cmd.Run(); err != nil {
+		return errors.Wrapf(err, "hook %q failed: %s", h.Path, stderr.String())
+	}
+	return nil
+}
@@ -0,0 +1,68 @@
+// Package hostresources queries host-wide (not per-process) memory and CPU
+// usage, for callers that need to reason about how much headroom the host
+// has left rather than how much a particular process or container is using.
+package hostresources
+
+import (
+	"context"
+	"fmt"
+	"time"
+	"unsafe"
+
+	"github.com/Microsoft/hcsshim/internal/winapi"
+	"golang.org/x/sys/windows"
+)
+
+// FreeMemoryMB returns the amount of free physical memory on the host, in
+// MB, as reported by GlobalMemoryStatusEx.
+func FreeMemoryMB() (uint64, error) {
+	var status winapi.MemoryStatusEx
+	status.Length = uint32(unsafe.Sizeof(status))
+	if err := winapi.GlobalMemoryStatusEx(&status); err != nil {
+		return 0, fmt.Errorf("getting host memory status: %w", err)
+	}
+	return status.AvailPhys / (1024 * 1024), nil
+}
+
+// CPUIdlePercent returns the percentage (0-100) of host CPU time, across all
+// logical processors, that was idle over the given window. It samples
+// GetSystemTimes, sleeps for window, then samples again and diffs the two.
+func CPUIdlePercent(ctx context.Context, window time.Duration) (float64, error) {
+	idle1, kernel1, user1, err := getSystemTimes()
+	if err != nil {
+		return 0, err
+	}
+
+	select {
+	case <-ctx.Done():
+		return 0, ctx.Err()
+	case <-time.After(window):
+	}
+
+	idle2, kernel2, user2, err := getSystemTimes()
+	if err != nil {
+		return 0, err
+	}
+
+	idleDelta := idle2 - idle1
+	totalDelta := (kernel2 - kernel1) + (user2 - user1)
+	if totalDelta == 0 {
+		return 0, nil
+	}
+	return float64(idleDelta) / float64(totalDelta) * 100, nil
+}
+
+// getSystemTimes returns the idle, kernel, and user cumulative host CPU time
+// as 100ns units, as reported by GetSystemTimes. Kernel time includes idle
+// time, matching the Win32 API's own convention.
+func getSystemTimes() (idle, kernel, user uint64, err error) {
+	var idleTime, kernelTime, userTime windows.Filetime
+	if err := winapi.GetSystemTimes(&idleTime, &kernelTime, &userTime); err != nil {
+		return 0, 0, 0, fmt.Errorf("getting host CPU times: %w", err)
+	}
+	return filetimeToUint64(idleTime), filetimeToUint64(kernelTime), filetimeToUint64(userTime), nil
+}
+
+func filetimeToUint64(ft windows.Filetime) uint64 {
+	return uint64(ft.HighDateTime)<<32 | uint64(ft.LowDateTime)
+}
@@ -0,0 +1,138 @@
+// Package iscsi logs into and out of iSCSI targets using the Windows iSCSI
+// Initiator's iscsicli.exe, and resolves the Windows disk number a target's
+// LUN is surfaced as once connected, so it can be SCSI-attached to a uVM the
+// same way a local physical disk would be.
+package iscsi
+
+import (
+	"context"
+	"fmt"
+	"os/exec"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// TargetConfig describes an iSCSI target to log into.
+type TargetConfig struct {
+	// Portal is the target portal address, e.g. "10.0.0.1:3260".
+	Portal string
+	// IQN is the target's iSCSI Qualified Name.
+	IQN string
+	// CHAPUsername and CHAPSecret configure one-way CHAP authentication.
+	// Leave both empty to log in without CHAP.
+	CHAPUsername string
+	CHAPSecret   string
+}
+
+// Login logs into tc's target portal and target, and returns the Windows
+// disk number (as in \\.\PhysicalDriveN) the target's first LUN was
+// surfaced as.
+func Login(ctx context.Context, tc TargetConfig) (uint32, error) {
+	if err := run(ctx, "AddTargetPortal", tc.Portal); err != nil {
+		return 0, fmt.Errorf("adding target portal %s: %s", tc.Portal, err)
+	}
+
+	// iscsicli's LoginTarget is positional: TargetName, ReportToPNP,
+	// TargetPortal (Initiator, PortNumber), SecurityFlags, LoginFlags,
+	// AuthType, Username, Password, ..., then KEY=VALUE pairs. "*" takes the
+	// default for a field we don't need to override.
+	loginArgs := []string{"LoginTarget", tc.IQN, "T", "*", "*", "*", "*", "*"}
+	if tc.CHAPUsername != "" {
+		loginArgs = append(loginArgs, "CHAP", tc.CHAPUsername, tc.CHAPSecret)
+	}
+	if err := run(ctx, loginArgs...); err != nil {
+		return 0, fmt.Errorf("logging into target %s: %s", tc.IQN, err)
+	}
+
+	diskNumber, err := diskNumberForTarget(ctx, tc.IQN)
+	if err != nil {
+		return 0, fmt.Errorf("resolving disk number for target %s: %s", tc.IQN, err)
+	}
+	return diskNumber, nil
+}
+
+// Logout logs out of tc's target.
+func Logout(ctx context.Context, tc TargetConfig) error {
+	sessionID, err := sessionIDForTarget(ctx, tc.IQN)
+	if err != nil {
+		return fmt.Errorf("resolving session for target %s: %s", tc.IQN, err)
+	}
+	return run(ctx, "LogoutTarget", sessionID)
+}
+
+var physicalDriveRe = regexp.MustCompile(`(?i)\\\\\.\\PHYSICALDRIVE(\d+)`)
+
+// diskNumberForTarget parses `iscsicli ReportTargetMappings` for the
+// \\.\PhysicalDriveN entry belonging to iqn.
+func diskNumberForTarget(ctx context.Context, iqn string) (uint32, error) {
+	out, err := output(ctx, "ReportTargetMappings")
+	if err != nil {
+		return 0, err
+	}
+	for _, block := range splitBlocks(out, "Target Name") {
+		if !strings.Contains(block, iqn) {
+			continue
+		}
+		if m := physicalDriveRe.FindStringSubmatch(block); m != nil {
+			n, err := strconv.ParseUint(m[1], 10, 32)
+			if err != nil {
+				return 0, fmt.Errorf("parsing disk number from %q: %s", m[0], err)
+			}
+			return uint32(n), nil
+		}
+	}
+	return 0, fmt.Errorf("no disk found for target %s in iscsicli output", iqn)
+}
+
+var sessionIDRe = regexp.MustCompile(`(?i)Session Id\s*:\s*([0-9a-fA-F]+-[0-9a-fA-F]+)`)
+
+// sessionIDForTarget parses `iscsicli SessionList` for the session ID logged
+// into iqn, which LogoutTarget requires (it doesn't accept an IQN directly).
+func sessionIDForTarget(ctx context.Context, iqn string) (string, error) {
+	out, err := output(ctx, "SessionList")
+	if err != nil {
+		return "", err
+	}
+	for _, block := range splitBlocks(out, "Session Id") {
+		if !strings.Contains(block, iqn) {
+			continue
+		}
+		if m := sessionIDRe.FindStringSubmatch(block); m != nil {
+			return m[1], nil
+		}
+	}
+	return "", fmt.Errorf("no session found for target %s in iscsicli output", iqn)
+}
+
+// splitBlocks splits iscsicli's report output into per-entry chunks, each
+// starting at a line containing marker (e.g. "Session Id" or "Target Name").
+func splitBlocks(output, marker string) []string {
+	lines := strings.Split(output, "\n")
+	var blocks []string
+	var cur []string
+	for _, line := range lines {
+		if strings.Contains(line, marker) && len(cur) > 0 {
+			blocks = append(blocks, strings.Join(cur, "\n"))
+			cur = nil
+		}
+		cur = append(cur, line)
+	}
+	if len(cur) > 0 {
+		blocks = append(blocks, strings.Join(cur, "\n"))
+	}
+	return blocks
+}
+
+func run(ctx context.Context, args ...string) error {
+	_, err := output(ctx, args...)
+	return err
+}
+
+func output(ctx context.Context, args ...string) (string, error) {
+	out, err := exec.CommandContext(ctx, "iscsicli.exe", args...).CombinedOutput()
+	if err != nil {
+		return "", fmt.Errorf("iscsicli %s: %s: %s", strings.Join(args, " "), err, strings.TrimSpace(string(out)))
+	}
+	return string(out), nil
+}
@@ -29,6 +29,12 @@ type MsgAllProcessesExited struct{}
 // This should not be treated as an error.
 type MsgUnimplemented struct{}
 
+// MsgNotificationLimit represents a JOB_OBJECT_MSG_NOTIFICATION_LIMIT
+// message: one of the job's notification-only limits (see
+// JobObject.SetNotificationLimit) was crossed. It carries no details of its
+// own; call JobObject.QueryLimitViolation to find out which limit.
+type MsgNotificationLimit struct{}
+
 // pollIOCP polls the io completion port forever.
 func pollIOCP(ctx context.Context, iocpHandle windows.Handle) {
 	var (
@@ -82,6 +88,8 @@ func parseMessage(code uint32, overlapped uintptr) (interface{}, error) {
 	switch code {
 	case winapi.JOB_OBJECT_MSG_ACTIVE_PROCESS_ZERO:
 		return MsgAllProcessesExited{}, nil
+	case winapi.JOB_OBJECT_MSG_NOTIFICATION_LIMIT:
+		return MsgNotificationLimit{}, nil
 	// Other messages for completeness and a check to make sure that if we fall
 	// into the default case that this is a code we don't know how to handle.
 	case winapi.JOB_OBJECT_MSG_END_OF_JOB_TIME:
@@ -92,7 +100,6 @@ func parseMessage(code uint32, overlapped uintptr) (interface{}, error) {
 	case winapi.JOB_OBJECT_MSG_ABNORMAL_EXIT_PROCESS:
 	case winapi.JOB_OBJECT_MSG_PROCESS_MEMORY_LIMIT:
 	case winapi.JOB_OBJECT_MSG_JOB_MEMORY_LIMIT:
-	case winapi.JOB_OBJECT_MSG_NOTIFICATION_LIMIT:
 	default:
 		return nil, fmt.Errorf("unknown job notification type: %d", code)
 	}
@@ -36,6 +36,20 @@ type JobLimits struct {
 	MemoryLimitInBytes uint64
 	MaxIOPS            int64
 	MaxBandwidth       int64
+	// GroupAffinities confines every process in the job to the processor
+	// groups and logical processors they describe, for hosts with more than
+	// 64 logical processors (and therefore more than one processor group)
+	// where pinning needs to span groups.
+	GroupAffinities []winapi.GROUP_AFFINITY
+	// NotifyMemoryLimitInBytes, if set, is a soft memory threshold below
+	// MemoryLimitInBytes: crossing it only generates a
+	// JOB_OBJECT_MSG_NOTIFICATION_LIMIT completion port message (see
+	// PollNotification), it does not terminate anything in the job. This is
+	// the job object equivalent of cgroup v2's memory.high -- an early
+	// warning before MemoryLimitInBytes (memory.max's equivalent) actually
+	// kills something. The job MUST have been created with
+	// `notifications == true` for the message to be deliverable.
+	NotifyMemoryLimitInBytes uint64
 }
 
 type CPURateControlType uint32
@@ -131,6 +145,12 @@ func (job *JobObject) SetResourceLimits(limits *JobLimits) error {
 		}
 	}
 
+	if limits.NotifyMemoryLimitInBytes != 0 {
+		if err := job.SetNotificationLimit(limits.NotifyMemoryLimitInBytes); err != nil {
+			return errors.Wrap(err, "failed to set job object notification memory limit")
+		}
+	}
+
 	if limits.CPULimit != 0 {
 		if err := job.SetCPULimit(RateBased, limits.CPULimit); err != nil {
 			return errors.Wrap(err, "failed to set job object cpu limit")
@@ -146,6 +166,12 @@ func (job *JobObject) SetResourceLimits(limits *JobLimits) error {
 			return errors.Wrap(err, "failed to set io limit on job object")
 		}
 	}
+
+	if len(limits.GroupAffinities) != 0 {
+		if err := job.SetAffinity(limits.GroupAffinities); err != nil {
+			return errors.Wrap(err, "failed to set group affinity on job object")
+		}
+	}
 	return nil
 }
 
@@ -202,6 +228,55 @@ func (job *JobObject) SetMemoryLimit(memoryLimitInBytes uint64) error {
 	return nil
 }
 
+// SetNotificationLimit sets a soft memory limit on the job object that only
+// generates a JOB_OBJECT_MSG_NOTIFICATION_LIMIT notification (see
+// PollNotification) when exceeded, rather than terminating a process in the
+// job the way SetMemoryLimit does.
+func (job *JobObject) SetNotificationLimit(memoryLimitInBytes uint64) error {
+	job.handleLock.RLock()
+	defer job.handleLock.RUnlock()
+
+	if job.handle == 0 {
+		return ErrAlreadyClosed
+	}
+
+	info := winapi.JOBOBJECT_NOTIFICATION_LIMIT_INFORMATION{
+		JobMemoryLimit: memoryLimitInBytes,
+		LimitFlags:     winapi.JOB_OBJECT_LIMIT_JOB_MEMORY_LOW,
+	}
+	_, err := windows.SetInformationJobObject(job.handle, winapi.JobObjectNotificationLimitInformation, uintptr(unsafe.Pointer(&info)), uint32(unsafe.Sizeof(info)))
+	if err != nil {
+		return fmt.Errorf("failed to set notification limit info on job object: %s", err)
+	}
+	return nil
+}
+
+// QueryLimitViolation returns which of the job's limits, if any, is
+// currently being violated. Meant to be called after receiving a
+// MsgNotificationLimit notification, to find out whether it was the memory
+// threshold set by SetNotificationLimit (as opposed to a CPU or IO rate
+// control tolerance) that was crossed.
+func (job *JobObject) QueryLimitViolation() (winapi.JOBOBJECT_LIMIT_VIOLATION_INFORMATION, error) {
+	job.handleLock.RLock()
+	defer job.handleLock.RUnlock()
+
+	if job.handle == 0 {
+		return winapi.JOBOBJECT_LIMIT_VIOLATION_INFORMATION{}, ErrAlreadyClosed
+	}
+
+	var info winapi.JOBOBJECT_LIMIT_VIOLATION_INFORMATION
+	if err := winapi.QueryInformationJobObject(
+		job.handle,
+		winapi.JobObjectLimitViolationInformation,
+		uintptr(unsafe.Pointer(&info)),
+		uint32(unsafe.Sizeof(info)),
+		nil,
+	); err != nil {
+		return winapi.JOBOBJECT_LIMIT_VIOLATION_INFORMATION{}, fmt.Errorf("failed to query limit violation info on job object: %s", err)
+	}
+	return info, nil
+}
+
 // SetIOLimit sets the IO limits specified on the job object.
 func (job *JobObject) SetIOLimit(maxBandwidth, maxIOPS int64) error {
 	job.handleLock.RLock()
@@ -227,6 +302,33 @@ func (job *JobObject) SetIOLimit(maxBandwidth, maxIOPS int64) error {
 	return nil
 }
 
+// SetAffinity confines every process in the job to the logical processors
+// described by `affinities`. More than one entry may be given to span more
+// than one processor group, which a single GROUP_AFFINITY can't do on its
+// own, for hosts with more than 64 logical processors.
+func (job *JobObject) SetAffinity(affinities []winapi.GROUP_AFFINITY) error {
+	job.handleLock.RLock()
+	defer job.handleLock.RUnlock()
+
+	if job.handle == 0 {
+		return ErrAlreadyClosed
+	}
+	if len(affinities) == 0 {
+		return errors.New("must specify at least one group affinity")
+	}
+
+	_, err := windows.SetInformationJobObject(
+		job.handle,
+		winapi.JobObjectGroupInformationEx,
+		uintptr(unsafe.Pointer(&affinities[0])),
+		uint32(len(affinities))*uint32(unsafe.Sizeof(affinities[0])),
+	)
+	if err != nil {
+		return fmt.Errorf("failed to set group affinity info on job object: %s", err)
+	}
+	return nil
+}
+
 // PollNotification will poll for a job object notification. This call should only be called once
 // per job (ideally in a goroutine loop) and will block if there is not a notification ready.
 // This call will return immediately with error `ErrNotRegistered` if the job was not registered
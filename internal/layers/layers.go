@@ -7,6 +7,7 @@ import (
 	"context"
 	"fmt"
 	"path/filepath"
+	"sync"
 
 	"github.com/Microsoft/hcsshim/internal/log"
 	"github.com/Microsoft/hcsshim/internal/ospath"
@@ -16,6 +17,7 @@ import (
 	"github.com/Microsoft/hcsshim/internal/wclayer"
 	"github.com/pkg/errors"
 	"github.com/sirupsen/logrus"
+	"golang.org/x/sync/errgroup"
 )
 
 // ImageLayers contains all the layers for an image.
@@ -23,6 +25,10 @@ type ImageLayers struct {
 	vm                 *uvm.UtilityVM
 	containerRootInUVM string
 	layers             []string
+	// readOnly is set for layers mounted via MountImageLayers: unlike a
+	// container's own rootfs, layers has no trailing scratch entry, so
+	// Release must skip the scratch-specific teardown below.
+	readOnly bool
 }
 
 func NewImageLayers(vm *uvm.UtilityVM, containerRootInUVM string, layers []string) *ImageLayers {
@@ -33,8 +39,23 @@ func NewImageLayers(vm *uvm.UtilityVM, containerRootInUVM string, layers []strin
 	}
 }
 
+// NewReadOnlyImageLayers is the ImageLayers constructor for layers mounted
+// via MountImageLayers rather than MountContainerLayers: see the readOnly
+// field on ImageLayers.
+func NewReadOnlyImageLayers(vm *uvm.UtilityVM, containerRootInUVM string, layers []string) *ImageLayers {
+	return &ImageLayers{
+		vm:                 vm,
+		containerRootInUVM: containerRootInUVM,
+		layers:             layers,
+		readOnly:           true,
+	}
+}
+
 // Release unmounts all of the layers located in the layers array.
 func (layers *ImageLayers) Release(ctx context.Context, all bool) error {
+	if layers.readOnly {
+		return layers.releaseReadOnly(ctx)
+	}
 	op := UnmountOperationSCSI
 	if layers.vm == nil || all {
 		op = UnmountOperationAll
@@ -51,6 +72,109 @@ func (layers *ImageLayers) Release(ctx context.Context, all bool) error {
 	return nil
 }
 
+// releaseReadOnly removes the combined layers set up by MountImageLayers and
+// each of its read-only layers, mirroring the tail of UnmountContainerLayers
+// but without the scratch removal a read-only mount never had.
+func (layers *ImageLayers) releaseReadOnly(ctx context.Context) error {
+	var retErr error
+	crp := ospath.Join(layers.vm.OS(), layers.containerRootInUVM)
+	if err := layers.vm.RemoveCombinedLayers(ctx, crp); err != nil {
+		log.G(ctx).WithError(err).Warn("failed guest request to remove combined layers")
+		retErr = err
+	}
+	for _, layerPath := range layers.layers {
+		hostPath := filepath.Join(layerPath, "layer.vhd")
+		if err := removeLCOWLayer(ctx, layers.vm, hostPath); err != nil {
+			log.G(ctx).WithError(err).Warn("remove layer failed")
+			if retErr == nil {
+				retErr = err
+			} else {
+				retErr = errors.Wrapf(retErr, err.Error())
+			}
+		}
+	}
+	layers.layers = nil
+	return retErr
+}
+
+// ReleaseAll releases multiple containers' layers concurrently rather than
+// one at a time, for callers tearing down every container of a many-container
+// pod at once (e.g. on pod deletion) where the latency of releasing each
+// container's layers in turn adds up.
+//
+// Each element of layerSets is released through its own Release call, which
+// already orders a single container's unmount correctly (scratch before the
+// shared read-only layers it depends on). Running multiple containers'
+// Release calls concurrently is safe on top of that: the read-only layers
+// they share are ref-counted by the underlying UtilityVM (see
+// UtilityVM.RemoveVSMB/RemoveVPMEM) and only actually removed once every
+// container sharing them has released its reference, regardless of the order
+// the concurrent calls land in.
+//
+// Every layer set is given a chance to release regardless of whether another
+// one fails, so a single container's teardown error doesn't leave every other
+// container's layers mounted. If any failed, the first error encountered is
+// returned, wrapped with the rest.
+func ReleaseAll(ctx context.Context, layerSets []*ImageLayers, all bool) error {
+	var (
+		wg      sync.WaitGroup
+		mu      sync.Mutex
+		retErrs error
+	)
+	wg.Add(len(layerSets))
+	for _, ls := range layerSets {
+		go func(ls *ImageLayers) {
+			defer wg.Done()
+			if err := ls.Release(ctx, all); err != nil {
+				mu.Lock()
+				defer mu.Unlock()
+				if retErrs == nil {
+					retErrs = err
+				} else {
+					retErrs = errors.Wrapf(retErrs, err.Error())
+				}
+			}
+		}(ls)
+	}
+	wg.Wait()
+	return retErrs
+}
+
+// PrefetchLayers begins attaching/verifying the read-only layers in each of
+// layerSets against vm, one goroutine per image, so that a later
+// MountContainerLayers call for the same layers finds them already resolved
+// (a VSMB share already ref-counted up for WCOW, or a VPMEM/SCSI slot
+// already claimed for LCOW) instead of doing that work on the CreateTask
+// path. It returns immediately; each image's layers are attached
+// independently of the others.
+//
+// This is a best-effort optimization run ahead of the workload container
+// (and its own error-reporting path) existing, so a failure here is only
+// logged: the later, real MountContainerLayers call for the same layers
+// just ends up doing the work that prefetch failed to do, instead of
+// skipping it.
+func PrefetchLayers(vm *uvmpkg.UtilityVM, layerSets [][]string) {
+	for _, layerSet := range layerSets {
+		go func(layerSet []string) {
+			ctx := context.Background()
+			for _, layerPath := range layerSet {
+				if vm.OS() == "windows" {
+					options := vm.DefaultVSMBOptions(true)
+					options.TakeBackupPrivilege = true
+					if _, err := vm.AddVSMB(ctx, layerPath, options); err != nil {
+						log.G(ctx).WithError(err).WithField("layerPath", layerPath).Warn("failed to prefetch wcow layer")
+					}
+				} else {
+					vhdPath := filepath.Join(layerPath, "layer.vhd")
+					if _, err := addLCOWLayer(ctx, vm, vhdPath); err != nil {
+						log.G(ctx).WithError(err).WithField("layerPath", layerPath).Warn("failed to prefetch lcow layer")
+					}
+				}
+			}
+		}(layerSet)
+	}
+}
+
 // MountContainerLayers is a helper for clients to hide all the complexity of layer mounting
 // Layer folder are in order: base, [rolayer1..rolayern,] scratch
 //
@@ -61,6 +185,14 @@ func (layers *ImageLayers) Release(ctx context.Context, all bool) error {
 //                    of the layers are the VSMB locations where the read-only layers are mounted.
 //
 // TODO dcantah: Keep better track of the layers that are added, don't simply discard the SCSI, VSMB, etc. resource types gotten inside.
+//
+// Read-only layers are attached concurrently (see the loop below) rather
+// than in one combined host-to-guest request, since that would require the
+// guest-side GCS daemon -- which lives outside this repository -- to accept
+// and verify a batch of device metadata in a single RPC. Issuing today's
+// per-layer attach RPCs concurrently instead of sequentially still removes
+// most of the serialized latency for images with many layers; it's the
+// guest-side batching/verification itself that would need to happen in GCS.
 func MountContainerLayers(ctx context.Context, layerFolders []string, guestRoot string, uvm *uvmpkg.UtilityVM) (_ string, err error) {
 	log.G(ctx).WithField("layerFolders", layerFolders).Debug("hcsshim::mountContainerLayers")
 
@@ -106,12 +238,18 @@ func MountContainerLayers(ctx context.Context, layerFolders []string, guestRoot
 		if err != nil {
 			if uvm.OS() == "windows" {
 				for _, l := range layersAdded {
+					if l == "" {
+						continue
+					}
 					if err := uvm.RemoveVSMB(ctx, l, true); err != nil {
 						log.G(ctx).WithError(err).Warn("failed to remove wcow layer on cleanup")
 					}
 				}
 			} else {
 				for _, l := range layersAdded {
+					if l == "" {
+						continue
+					}
 					if err := removeLCOWLayer(ctx, uvm, l); err != nil {
 						log.G(ctx).WithError(err).Warn("failed to remove lcow layer on cleanup")
 					}
@@ -120,27 +258,45 @@ func MountContainerLayers(ctx context.Context, layerFolders []string, guestRoot
 		}
 	}()
 
-	for _, layerPath := range layerFolders[:len(layerFolders)-1] {
-		log.G(ctx).WithField("layerPath", layerPath).Debug("mounting layer")
-		if uvm.OS() == "windows" {
-			options := uvm.DefaultVSMBOptions(true)
-			options.TakeBackupPrivilege = true
-			if _, err := uvm.AddVSMB(ctx, layerPath, options); err != nil {
-				return "", fmt.Errorf("failed to add VSMB layer: %s", err)
-			}
-			layersAdded = append(layersAdded, layerPath)
-		} else {
-			var (
-				layerPath = filepath.Join(layerPath, "layer.vhd")
-				uvmPath   string
-			)
-			uvmPath, err = addLCOWLayer(ctx, uvm, layerPath)
-			if err != nil {
-				return "", fmt.Errorf("failed to add LCOW layer: %s", err)
+	// Attach every read-only layer concurrently instead of one at a time:
+	// each is its own independent host-to-guest modify RPC (VSMB share or
+	// VPMem/SCSI attach), so for images with many layers this overlaps
+	// what would otherwise be dozens of sequential round trips. The guest
+	// only assembles the overlay once, below, after every layer is
+	// attached. The slices are pre-sized and written by index rather than
+	// appended to, so the base-to-top order CombineLayersLCOW/CombineLayersWCOW
+	// need is preserved regardless of which goroutine finishes first.
+	roLayerFolders := layerFolders[:len(layerFolders)-1]
+	layersAdded = make([]string, len(roLayerFolders))
+	if uvm.OS() != "windows" {
+		lcowUvmLayerPaths = make([]string, len(roLayerFolders))
+	}
+	g, gctx := errgroup.WithContext(ctx)
+	for i, layerPath := range roLayerFolders {
+		i, layerPath := i, layerPath
+		g.Go(func() error {
+			log.G(gctx).WithField("layerPath", layerPath).Debug("mounting layer")
+			if uvm.OS() == "windows" {
+				options := uvm.DefaultVSMBOptions(true)
+				options.TakeBackupPrivilege = true
+				if _, err := uvm.AddVSMB(gctx, layerPath, options); err != nil {
+					return fmt.Errorf("failed to add VSMB layer: %s", err)
+				}
+				layersAdded[i] = layerPath
+			} else {
+				vhdPath := filepath.Join(layerPath, "layer.vhd")
+				uvmPath, err := addLCOWLayer(gctx, uvm, vhdPath)
+				if err != nil {
+					return fmt.Errorf("failed to add LCOW layer: %s", err)
+				}
+				layersAdded[i] = vhdPath
+				lcowUvmLayerPaths[i] = uvmPath
 			}
-			layersAdded = append(layersAdded, layerPath)
-			lcowUvmLayerPaths = append(lcowUvmLayerPaths, uvmPath)
-		}
+			return nil
+		})
+	}
+	if err = g.Wait(); err != nil {
+		return "", err
 	}
 
 	containerScratchPathInUVM := ospath.Join(uvm.OS(), guestRoot)
@@ -185,6 +341,57 @@ func MountContainerLayers(ctx context.Context, layerFolders []string, guestRoot
 	return rootfs, nil
 }
 
+// MountImageLayers attaches layerFolders read-only and combines them into an
+// overlay at guestPath, without a scratch layer, so they can be mounted
+// alongside a container's own rootfs instead of as that container's rootfs
+// (e.g. a second image's contents exposed to the container as a read-only
+// "image volume" mount).
+//
+// Unlike MountContainerLayers, layerFolders must not include a trailing
+// scratch entry: the resulting overlay is read only, and release it with
+// the ImageLayers returned by NewReadOnlyImageLayers, not Release's regular
+// scratch-aware teardown.
+//
+// LCOW only: CombineLayersWCOW has no equivalent scratch-less overlay, and
+// WCOW image volumes are already served by a bind mount instead (see
+// oci.ImageVolumePaths).
+func MountImageLayers(ctx context.Context, layerFolders []string, guestPath string, uvm *uvmpkg.UtilityVM) (_ string, err error) {
+	if uvm == nil || uvm.OS() != "linux" {
+		return "", errors.New("MountImageLayers is only supported for LCOW")
+	}
+
+	log.G(ctx).WithField("layerFolders", layerFolders).Debug("hcsshim::mountImageLayers")
+
+	layersAdded := make([]string, 0, len(layerFolders))
+	defer func() {
+		if err != nil {
+			for _, l := range layersAdded {
+				if e := removeLCOWLayer(ctx, uvm, l); e != nil {
+					log.G(ctx).WithError(e).Warn("failed to remove lcow layer on cleanup")
+				}
+			}
+		}
+	}()
+
+	lcowUvmLayerPaths := make([]string, 0, len(layerFolders))
+	for _, layerPath := range layerFolders {
+		vhdPath := filepath.Join(layerPath, "layer.vhd")
+		uvmPath, err := addLCOWLayer(ctx, uvm, vhdPath)
+		if err != nil {
+			return "", fmt.Errorf("failed to add LCOW layer: %s", err)
+		}
+		layersAdded = append(layersAdded, vhdPath)
+		lcowUvmLayerPaths = append(lcowUvmLayerPaths, uvmPath)
+	}
+
+	rootfsPath := ospath.Join(uvm.OS(), guestPath)
+	if err := uvm.CombineLayersLCOW(ctx, lcowUvmLayerPaths, "", rootfsPath); err != nil {
+		return "", err
+	}
+	log.G(ctx).Debug("hcsshim::mountImageLayers Succeeded")
+	return rootfsPath, nil
+}
+
 func addLCOWLayer(ctx context.Context, uvm *uvmpkg.UtilityVM, layerPath string) (uvmPath string, err error) {
 	// don't try to add as vpmem when we want additional devices on the uvm to be fully physically backed
 	if !uvm.DevicesPhysicallyBacked() {
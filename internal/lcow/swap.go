@@ -0,0 +1,149 @@
+package lcow
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/Microsoft/go-winio/vhd"
+	cmdpkg "github.com/Microsoft/hcsshim/internal/cmd"
+	"github.com/Microsoft/hcsshim/internal/log"
+	"github.com/Microsoft/hcsshim/internal/timeout"
+	"github.com/Microsoft/hcsshim/internal/uvm"
+	"github.com/sirupsen/logrus"
+)
+
+// defaultSwapVhdxBlockSizeMB is the block-size for the swap VHDx's this
+// package can create.
+const defaultSwapVhdxBlockSizeMB = 1
+
+// AddSwap creates a blank VHDX at hostPath, sized to sizeMB rounded up to a
+// whole GB (the unit vhd.CreateVhdx takes), hot-adds it to lcowUVM over SCSI,
+// and formats and activates it as swap space in the guest with
+// mkswap/swapon, so the guest degrades to swapping under memory pressure
+// instead of immediately invoking the OOM killer.
+//
+// It mirrors CreateScratch's approach to finding and acting on the device
+// the SCSI attach surfaces in the guest, substituting mkswap/swapon for
+// mkfs.ext4. The returned *uvm.SCSIMount and guest device path identify the
+// attachment; the caller must pass both to RemoveSwap once the swap space
+// is no longer needed.
+func AddSwap(ctx context.Context, lcowUVM *uvm.UtilityVM, hostPath string, sizeMB uint64) (_ *uvm.SCSIMount, _ string, err error) {
+	if lcowUVM == nil {
+		return nil, "", fmt.Errorf("no uvm")
+	}
+	if lcowUVM.OS() != "linux" {
+		return nil, "", errors.New("lcow::AddSwap requires a linux utility VM to operate")
+	}
+
+	sizeGB := uint32((sizeMB + 1023) / 1024)
+	if sizeGB == 0 {
+		sizeGB = 1
+	}
+	log.G(ctx).WithFields(logrus.Fields{
+		"dest":   hostPath,
+		"sizeMB": sizeMB,
+	}).Debug("lcow::AddSwap opts")
+
+	if err := vhd.CreateVhdx(hostPath, sizeGB, defaultSwapVhdxBlockSizeMB); err != nil {
+		return nil, "", fmt.Errorf("failed to create swap VHDx %s: %s", hostPath, err)
+	}
+
+	scsiMount, err := lcowUVM.AddSCSI(ctx, hostPath, "", false, uvm.VMAccessTypeIndividual) // No destination as not formatted
+	if err != nil {
+		os.Remove(hostPath)
+		return nil, "", err
+	}
+	removeSCSI := true
+	defer func() {
+		if removeSCSI {
+			lcowUVM.RemoveSCSI(ctx, hostPath)
+			os.Remove(hostPath)
+		}
+	}()
+
+	log.G(ctx).WithFields(logrus.Fields{
+		"dest":       hostPath,
+		"controller": scsiMount.Controller,
+		"lun":        scsiMount.LUN,
+	}).Debug("lcow::AddSwap device attached")
+
+	// Validate /sys/bus/scsi/devices/C:0:0:L exists as a directory
+	devicePath := fmt.Sprintf("/sys/bus/scsi/devices/%d:0:0:%d/block", scsiMount.Controller, scsiMount.LUN)
+	testdCtx, cancel := context.WithTimeout(ctx, timeout.TestDRetryLoop)
+	defer cancel()
+	for {
+		cmd := cmdpkg.CommandContext(testdCtx, lcowUVM, "test", "-d", devicePath)
+		err := cmd.Run()
+		if err == nil {
+			break
+		}
+		if _, ok := err.(*cmdpkg.ExitError); !ok {
+			return nil, "", fmt.Errorf("failed to run %+v following hot-add %s to utility VM: %s", cmd.Spec.Args, hostPath, err)
+		}
+		time.Sleep(time.Millisecond * 10)
+	}
+	cancel()
+
+	// Get the device from under the block subdirectory by doing a simple ls. This will come back as (eg) `sda`
+	lsCtx, cancel := context.WithTimeout(ctx, timeout.ExternalCommandToStart)
+	cmd := cmdpkg.CommandContext(lsCtx, lcowUVM, "ls", devicePath)
+	lsOutput, err := cmd.Output()
+	cancel()
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to `%+v` following hot-add %s to utility VM: %s", cmd.Spec.Args, hostPath, err)
+	}
+	device := fmt.Sprintf(`/dev/%s`, bytes.TrimSpace(lsOutput))
+	log.G(ctx).WithFields(logrus.Fields{
+		"dest":   hostPath,
+		"device": device,
+	}).Debug("lcow::AddSwap device guest location")
+
+	mkswapCtx, cancel := context.WithTimeout(ctx, timeout.ExternalCommandToStart)
+	cmd = cmdpkg.CommandContext(mkswapCtx, lcowUVM, "mkswap", device)
+	var mkswapStderr bytes.Buffer
+	cmd.Stderr = &mkswapStderr
+	err = cmd.Run()
+	cancel()
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to `%+v` following hot-add %s to utility VM: %s: %s", cmd.Spec.Args, hostPath, err, mkswapStderr.String())
+	}
+
+	swaponCtx, cancel := context.WithTimeout(ctx, timeout.ExternalCommandToStart)
+	cmd = cmdpkg.CommandContext(swaponCtx, lcowUVM, "swapon", device)
+	var swaponStderr bytes.Buffer
+	cmd.Stderr = &swaponStderr
+	err = cmd.Run()
+	cancel()
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to `%+v` following hot-add %s to utility VM: %s: %s", cmd.Spec.Args, hostPath, err, swaponStderr.String())
+	}
+
+	removeSCSI = false
+	log.G(ctx).WithField("dest", hostPath).Debug("lcow::AddSwap activated")
+	return scsiMount, device, nil
+}
+
+// RemoveSwap deactivates the swap space at device (as returned by AddSwap),
+// detaches scsiMount, and deletes the backing VHDX from the host.
+func RemoveSwap(ctx context.Context, lcowUVM *uvm.UtilityVM, scsiMount *uvm.SCSIMount, device string) error {
+	swapoffCtx, cancel := context.WithTimeout(ctx, timeout.ExternalCommandToStart)
+	cmd := cmdpkg.CommandContext(swapoffCtx, lcowUVM, "swapoff", device)
+	err := cmd.Run()
+	cancel()
+	if err != nil {
+		return fmt.Errorf("failed to `%+v` prior to hot-remove of %s: %s", cmd.Spec.Args, scsiMount.HostPath, err)
+	}
+
+	if err := scsiMount.Release(ctx); err != nil {
+		return fmt.Errorf("failed to hot-remove swap disk: %s", err)
+	}
+
+	if err := os.Remove(scsiMount.HostPath); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("failed to remove swap VHDx %s: %s", scsiMount.HostPath, err)
+	}
+	return nil
+}
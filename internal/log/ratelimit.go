@@ -0,0 +1,93 @@
+package log
+
+import (
+	"sync"
+	"time"
+)
+
+// Limiter suppresses repetitive log lines so that a flapping uVM (e.g. a
+// reconnect loop) cannot fill the containerd log disk. Call sites that emit
+// a message repeatedly should gate the call with Allow, keyed by something
+// that identifies the repeating message (e.g. "gcs-reconnect").
+//
+// Limiter also supports sampling of high-volume debug logs: a key
+// registered with SetSampleRate is only let through once every N calls,
+// independent of the rate-limit window.
+type Limiter struct {
+	// Interval is the window over which Burst calls to Allow for the same
+	// key are allowed through. If zero, rate limiting is disabled and only
+	// sampling (if configured) applies.
+	Interval time.Duration
+	// Burst is the number of times a given key may fire within Interval
+	// before being suppressed.
+	Burst int
+
+	mu         sync.Mutex
+	windows    map[string]*window
+	sampleRate map[string]int
+	counters   map[string]uint64
+}
+
+type window struct {
+	start   time.Time
+	count   int
+	skipped int
+}
+
+// NewLimiter returns a Limiter that allows Burst occurrences of a key per
+// Interval.
+func NewLimiter(interval time.Duration, burst int) *Limiter {
+	return &Limiter{
+		Interval: interval,
+		Burst:    burst,
+		windows:  make(map[string]*window),
+	}
+}
+
+// SetSampleRate configures key to only let 1 in rate calls to Allow through.
+// A rate <= 1 disables sampling for key.
+func (l *Limiter) SetSampleRate(key string, rate int) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.sampleRate == nil {
+		l.sampleRate = make(map[string]int)
+		l.counters = make(map[string]uint64)
+	}
+	l.sampleRate[key] = rate
+}
+
+// Allow reports whether a log entry for key should be emitted. It returns
+// the number of entries that were suppressed for key since the last call
+// that returned true, so the caller can fold it into the eventual log line
+// (e.g. `log.G(ctx).WithField("skipped", n).Warn(...)`).
+func (l *Limiter) Allow(key string) (ok bool, skipped int) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if rate := l.sampleRate[key]; rate > 1 {
+		l.counters[key]++
+		if l.counters[key]%uint64(rate) != 0 {
+			return false, 0
+		}
+	}
+
+	if l.Interval <= 0 || l.Burst <= 0 {
+		return true, 0
+	}
+
+	now := time.Now()
+	w, ok := l.windows[key]
+	if !ok || now.Sub(w.start) >= l.Interval {
+		w = &window{start: now}
+		l.windows[key] = w
+	}
+
+	w.count++
+	if w.count > l.Burst {
+		w.skipped++
+		return false, 0
+	}
+	skipped = w.skipped
+	w.skipped = 0
+	return true, skipped
+}
@@ -0,0 +1,174 @@
+package oci
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/opencontainers/runtime-spec/specs-go"
+)
+
+// annotationValueType is the type an annotation's string value is expected to
+// parse as.
+type annotationValueType int
+
+const (
+	annotationValueTypeBool annotationValueType = iota
+	annotationValueTypeUint32
+	annotationValueTypeUint64
+	annotationValueTypeString
+)
+
+// annotationSchemaEntry describes one annotation recognized anywhere in this
+// package: its expected type and the range of values it allows.
+//
+// This is intentionally additive to, rather than a replacement for, the
+// individual `parseAnnotationsBool`/`parseAnnotationsUint64`/etc. helpers
+// used when actually reading a value: those remain permissive (falling back
+// to a default and logging a warning) since they run deep inside document
+// construction where returning an error is disruptive. ValidateAnnotations
+// runs this schema up front, at task creation, so a typo'd or out-of-range
+// annotation is rejected with a specific error instead of being silently
+// ignored.
+type annotationSchemaEntry struct {
+	typ annotationValueType
+	// min and max bound the parsed numeric value. Both zero means unbounded.
+	// A value of 0 is always valid regardless of min: the annotations given a
+	// min here (the processor limits) are read through ParseAnnotationsCPULimit,
+	// which treats a parsed 0 as "not set" and falls through to its next
+	// source/default, or ParseAnnotationsExecCPULimit, whose 0 applies no cap.
+	// Unused for bool/string types.
+	min, max uint64
+}
+
+var annotationSchema = map[string]annotationSchemaEntry{
+	AnnotationContainerMemorySizeInMB:             {typ: annotationValueTypeUint64},
+	AnnotationContainerProcessorCount:             {typ: annotationValueTypeUint64},
+	AnnotationContainerProcessorLimit:             {typ: annotationValueTypeUint64, min: 1, max: 10000},
+	AnnotationContainerProcessorWeight:            {typ: annotationValueTypeUint64, max: 10000},
+	AnnotationContainerStorageQoSBandwidthMaximum: {typ: annotationValueTypeUint64},
+	AnnotationContainerStorageQoSIopsMaximum:      {typ: annotationValueTypeUint64},
+	AnnotationGPUVHDPath:                          {typ: annotationValueTypeString},
+	AnnotationAssignedDeviceKernelDrivers:         {typ: annotationValueTypeString},
+	AnnotationAssignedDeviceResolverAddress:       {typ: annotationValueTypeString},
+	AnnotationContainerDeviceInterfaceClassGUIDs:  {typ: annotationValueTypeString},
+	AnnotationNetworkingMode:                      {typ: annotationValueTypeString},
+	AnnotationContainerScratchSizeInMB:            {typ: annotationValueTypeUint64},
+	AnnotationContainerScratchLocation:            {typ: annotationValueTypeString},
+	AnnotationContainerScratchMemoryBacked:        {typ: annotationValueTypeBool},
+	AnnotationContainerRestartPolicy:              {typ: annotationValueTypeString},
+	AnnotationContainerRestartMaxAttempts:         {typ: annotationValueTypeUint32},
+	AnnotationContainerRestartDelayInMs:           {typ: annotationValueTypeUint64},
+	AnnotationContainerExecMemoryLimitInMB:        {typ: annotationValueTypeUint64},
+	AnnotationContainerExecCPULimit:               {typ: annotationValueTypeUint64, min: 1, max: 10000},
+	AnnotationContainerExecMemoryNotifyLimitInMB:  {typ: annotationValueTypeUint64},
+
+	annotationAllowOvercommit:             {typ: annotationValueTypeBool},
+	annotationEnableDeferredCommit:        {typ: annotationValueTypeBool},
+	annotationEnableColdDiscardHint:       {typ: annotationValueTypeBool},
+	annotationMemorySizeInMB:              {typ: annotationValueTypeUint64},
+	annotationMemoryLowMMIOGapInMB:        {typ: annotationValueTypeUint64},
+	annotationMemoryHighMMIOBaseInMB:      {typ: annotationValueTypeUint64},
+	annotationMemoryHighMMIOGapInMB:       {typ: annotationValueTypeUint64},
+	annotationProcessorCount:              {typ: annotationValueTypeUint64},
+	annotationProcessorLimit:              {typ: annotationValueTypeUint64, min: 1, max: 100000},
+	annotationProcessorWeight:             {typ: annotationValueTypeUint64, max: 10000},
+	annotationProcessorReservation:        {typ: annotationValueTypeUint64, max: 100000},
+	annotationVPMemCount:                  {typ: annotationValueTypeUint32},
+	annotationVPMemSize:                   {typ: annotationValueTypeUint64},
+	annotationPreferredRootFSType:         {typ: annotationValueTypeString},
+	annotationBootFilesRootPath:           {typ: annotationValueTypeString},
+	annotationKernelDirectBoot:            {typ: annotationValueTypeBool},
+	annotationVPCIEnabled:                 {typ: annotationValueTypeBool},
+	annotationPlan9UseShareRootIdentity:   {typ: annotationValueTypeBool},
+	annotationStorageQoSBandwidthMaximum:  {typ: annotationValueTypeUint64},
+	annotationStorageQoSIopsMaximum:       {typ: annotationValueTypeUint64},
+	annotationFullyPhysicallyBacked:       {typ: annotationValueTypeBool},
+	annotationDisableCompartmentNamespace: {typ: annotationValueTypeBool},
+	annotationUseExternalGCSBridge:        {typ: annotationValueTypeBool},
+	annotationCPUGroupID:                  {typ: annotationValueTypeString},
+	annotationUVMSharingKey:               {typ: annotationValueTypeString},
+	AnnotationEncryptedScratchDisk:        {typ: annotationValueTypeBool},
+}
+
+// AnnotationValidationError is returned by ValidateAnnotations for a single
+// annotation that failed validation against its schema entry.
+type AnnotationValidationError struct {
+	Annotation string
+	Value      string
+	Reason     string
+}
+
+func (e *AnnotationValidationError) Error() string {
+	return fmt.Sprintf("annotation %q: value %q %s", e.Annotation, e.Value, e.Reason)
+}
+
+// AnnotationValidationErrors is returned by ValidateAnnotations when one or
+// more annotations fail validation. It reports every offending annotation
+// rather than just the first.
+type AnnotationValidationErrors []*AnnotationValidationError
+
+func (e AnnotationValidationErrors) Error() string {
+	msgs := make([]string, len(e))
+	for i, err := range e {
+		msgs[i] = err.Error()
+	}
+	return strings.Join(msgs, "; ")
+}
+
+// ValidateAnnotations checks every annotation on `s` that matches a known
+// hcsshim annotation against its expected type, range, or (in future) set of
+// allowed values, returning an AnnotationValidationErrors listing every
+// annotation that failed. Annotations not recognized by hcsshim (e.g. CRI's
+// own `io.kubernetes.cri.*` annotations) are ignored; this is validation of
+// hcsshim's own annotations, not a general allow-list of spec annotations.
+func ValidateAnnotations(s *specs.Spec) error {
+	var errs AnnotationValidationErrors
+	for key, value := range s.Annotations {
+		entry, ok := annotationSchema[key]
+		if !ok {
+			continue
+		}
+		if err := validateAnnotationValue(key, value, entry); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	if len(errs) > 0 {
+		return errs
+	}
+	return nil
+}
+
+func validateAnnotationValue(key, value string, entry annotationSchemaEntry) *AnnotationValidationError {
+	switch entry.typ {
+	case annotationValueTypeBool:
+		if _, err := strconv.ParseBool(value); err != nil {
+			return &AnnotationValidationError{Annotation: key, Value: value, Reason: "is not a valid bool"}
+		}
+	case annotationValueTypeUint32:
+		if _, err := strconv.ParseUint(value, 10, 32); err != nil {
+			return &AnnotationValidationError{Annotation: key, Value: value, Reason: "is not a valid 32 bit unsigned integer"}
+		}
+	case annotationValueTypeUint64:
+		n, err := strconv.ParseUint(value, 10, 64)
+		if err != nil {
+			return &AnnotationValidationError{Annotation: key, Value: value, Reason: "is not a valid 64 bit unsigned integer"}
+		}
+		// 0 always passes regardless of min: every annotation here with a
+		// min is read by a helper that treats a parsed 0 as "not set" (see
+		// annotationSchemaEntry), so 0 is never actually out of range.
+		if n != 0 {
+			if entry.min != 0 && n < entry.min {
+				return &AnnotationValidationError{Annotation: key, Value: value, Reason: fmt.Sprintf("is below the minimum of %d", entry.min)}
+			}
+			if entry.max != 0 && n > entry.max {
+				return &AnnotationValidationError{Annotation: key, Value: value, Reason: fmt.Sprintf("is above the maximum of %d", entry.max)}
+			}
+		}
+	case annotationValueTypeString:
+		// Any string is valid; the entry exists purely so the annotation is
+		// recognized (and so a range/enum can be added later without
+		// changing callers).
+	}
+	return nil
+}
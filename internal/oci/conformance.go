@@ -0,0 +1,128 @@
+package oci
+
+import (
+	"reflect"
+	"strings"
+
+	"github.com/opencontainers/runtime-spec/specs-go"
+)
+
+const (
+	platformLinux   = "linux"
+	platformWindows = "windows"
+)
+
+// ConformanceReport is the result of CheckConformance: every field set on a
+// spec that the isolation mode it actually selected will not act on. hcsshim
+// has always silently ignored these rather than rejecting the spec (a CRI
+// shim is expected to pass through a spec built for a generic OCI runtime,
+// not one hand-tailored to HCS); ConformanceReport exists so a caller can log
+// them instead, giving whoever built the spec something to act on.
+type ConformanceReport struct {
+	// Mode is the isolation mode the spec was checked against, "linux" or
+	// "windows".
+	Mode string
+	// Dropped is one entry per ignored field that was actually set in the
+	// spec, e.g. "Process.Capabilities".
+	Dropped []string
+}
+
+// HasDropped reports whether r found any unsupported fields. A nil report
+// (CheckConformance on a spec that is neither LCOW nor WCOW) never has any.
+func (r *ConformanceReport) HasDropped() bool {
+	return r != nil && len(r.Dropped) > 0
+}
+
+// CheckConformance inspects s for fields that are meaningful under a
+// different isolation mode than the one s actually selects -- e.g.
+// Process.Capabilities (Linux-only) on a WCOW spec, or Windows.CredentialSpec
+// (WCOW-only) on an LCOW spec. Returns nil for a spec that is neither LCOW
+// nor WCOW (see IsLCOW, IsWCOW).
+//
+// This only covers the crossover fields hcsshim's own create path is known
+// to never read for the opposite mode; it is not a general-purpose schema
+// validator and does not catch every field HCS itself might ignore for other
+// reasons (e.g. an HCS schema version too old for a field that's new in this
+// OCI runtime-spec version).
+func CheckConformance(s *specs.Spec) *ConformanceReport {
+	var mode string
+	switch {
+	case IsWCOW(s):
+		mode = platformWindows
+	case IsLCOW(s):
+		mode = platformLinux
+	default:
+		return nil
+	}
+
+	r := &ConformanceReport{Mode: mode}
+	if s.Process != nil {
+		r.Dropped = append(r.Dropped, droppedProcessFields(s.Process, mode)...)
+	}
+	switch mode {
+	case platformWindows:
+		if s.Linux != nil {
+			r.Dropped = append(r.Dropped, "Linux")
+		}
+	case platformLinux:
+		r.Dropped = append(r.Dropped, droppedWindowsOnlyFields(s.Windows)...)
+	}
+	return r
+}
+
+// droppedProcessFields reports the set Process fields tagged (in
+// runtime-spec's own specs.Process struct) for a platform other than mode,
+// e.g. Process.Capabilities is tagged `platform:"linux"` and so is reported
+// when mode is "windows".
+func droppedProcessFields(p *specs.Process, mode string) []string {
+	var dropped []string
+	v := reflect.ValueOf(p).Elem()
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		tag := t.Field(i).Tag.Get("platform")
+		if tag == "" {
+			// Not a platform-specific field (e.g. Args, Env, Cwd) -- common
+			// to every isolation mode.
+			continue
+		}
+		if fieldAppliesTo(tag, mode) {
+			continue
+		}
+		if !v.Field(i).IsZero() {
+			dropped = append(dropped, "Process."+t.Field(i).Name)
+		}
+	}
+	return dropped
+}
+
+func fieldAppliesTo(platformTag, mode string) bool {
+	for _, p := range strings.Split(platformTag, ",") {
+		if p == mode {
+			return true
+		}
+	}
+	return false
+}
+
+// windowsOnlyFields are the specs.Windows fields hcsshim's create path only
+// ever reads for a WCOW spec; see internal/hcsoci/hcsdoc_wcow.go and
+// resources_wcow.go. LayerFolders, Devices, HyperV and Network are also read
+// on the LCOW path, and Resources sizes an LCOW sandbox's uVM (see
+// SpecToUVMCreateOpts) -- an LCOW spec legitimately sets Windows for those --
+// so they are deliberately not reported here. Servicing is read for neither
+// mode and is not reported either.
+var windowsOnlyFields = []string{"CredentialSpec", "IgnoreFlushesDuringBoot"}
+
+func droppedWindowsOnlyFields(w *specs.Windows) []string {
+	if w == nil {
+		return nil
+	}
+	var dropped []string
+	v := reflect.ValueOf(w).Elem()
+	for _, name := range windowsOnlyFields {
+		if !v.FieldByName(name).IsZero() {
+			dropped = append(dropped, "Windows."+name)
+		}
+	}
+	return dropped
+}
@@ -0,0 +1,92 @@
+package oci
+
+import (
+	"testing"
+
+	"github.com/opencontainers/runtime-spec/specs-go"
+)
+
+func Test_CheckConformance_WCOW_DropsLinuxOnlyFields(t *testing.T) {
+	s := &specs.Spec{
+		Windows: &specs.Windows{},
+		Process: &specs.Process{
+			Capabilities: &specs.LinuxCapabilities{},
+			OOMScoreAdj:  intPtr(5),
+		},
+	}
+	r := CheckConformance(s)
+	if !r.HasDropped() {
+		t.Fatal("expected dropped fields")
+	}
+	if r.Mode != platformWindows {
+		t.Fatalf("expected mode %q, got %q", platformWindows, r.Mode)
+	}
+	assertContains(t, r.Dropped, "Process.Capabilities")
+	assertContains(t, r.Dropped, "Process.OOMScoreAdj")
+}
+
+func Test_CheckConformance_WCOW_IgnoresUnsetLinuxFields(t *testing.T) {
+	s := &specs.Spec{
+		Windows: &specs.Windows{},
+		Process: &specs.Process{
+			Args: []string{"cmd"},
+		},
+	}
+	r := CheckConformance(s)
+	if r.HasDropped() {
+		t.Fatalf("expected no dropped fields, got %v", r.Dropped)
+	}
+}
+
+func Test_CheckConformance_LCOW_DropsWindowsOnlyFields(t *testing.T) {
+	s := &specs.Spec{
+		Linux: &specs.Linux{},
+		Windows: &specs.Windows{
+			CredentialSpec: "some-gmsa-spec",
+		},
+	}
+	r := CheckConformance(s)
+	if !r.HasDropped() {
+		t.Fatal("expected dropped fields")
+	}
+	if r.Mode != platformLinux {
+		t.Fatalf("expected mode %q, got %q", platformLinux, r.Mode)
+	}
+	assertContains(t, r.Dropped, "Windows.CredentialSpec")
+}
+
+func Test_CheckConformance_LCOW_KeepsFieldsItActuallyReads(t *testing.T) {
+	s := &specs.Spec{
+		Linux: &specs.Linux{},
+		Windows: &specs.Windows{
+			LayerFolders: []string{"c:\\layer1"},
+			HyperV:       &specs.WindowsHyperV{},
+			Network:      &specs.WindowsNetwork{NetworkNamespace: "ns"},
+		},
+	}
+	r := CheckConformance(s)
+	if r.HasDropped() {
+		t.Fatalf("expected no dropped fields, got %v", r.Dropped)
+	}
+}
+
+func Test_CheckConformance_NeitherLCOWNorWCOW(t *testing.T) {
+	s := &specs.Spec{}
+	if r := CheckConformance(s); r != nil {
+		t.Fatalf("expected nil report, got %v", r)
+	}
+}
+
+func intPtr(i int) *int {
+	return &i
+}
+
+func assertContains(t *testing.T, haystack []string, needle string) {
+	t.Helper()
+	for _, s := range haystack {
+		if s == needle {
+			return
+		}
+	}
+	t.Fatalf("expected %q in %v", needle, haystack)
+}
@@ -0,0 +1,38 @@
+package oci
+
+import (
+	"fmt"
+
+	"github.com/opencontainers/runtime-spec/specs-go"
+)
+
+// AnnotationNetworkingModeNone requests that a sandbox be created with no
+// network namespace or endpoints at all, skipping HNS entirely. Set
+// AnnotationNetworkingMode to this value for batch jobs on nodes where HNS
+// is unstable or simply unneeded; hcsshim never calls into HNS for such a
+// sandbox. If the spec nonetheless requests network resources, creation
+// fails fast instead of silently falling back to creating them, so a
+// misconfigured caller finds out immediately rather than getting an
+// unexpectedly networked sandbox.
+const AnnotationNetworkingModeNone = "none"
+
+// AnnotationNetworkingMode selects the sandbox's networking mode. The only
+// currently recognized value is AnnotationNetworkingModeNone; any other
+// value (including unset) leaves networking exactly as it already works --
+// a network namespace is created only if the spec's Windows.Network is set.
+const AnnotationNetworkingMode = "io.microsoft.virtualmachine.networkingmode"
+
+// ValidateNetworkingModeNone returns an error if `s` sets
+// AnnotationNetworkingMode to AnnotationNetworkingModeNone but also
+// requests network resources via Windows.Network, since the two are
+// contradictory: the caller asked for no networking at all and also asked
+// for a network namespace or endpoints.
+func ValidateNetworkingModeNone(s *specs.Spec) error {
+	if s.Annotations[AnnotationNetworkingMode] != AnnotationNetworkingModeNone {
+		return nil
+	}
+	if s.Windows != nil && s.Windows.Network != nil {
+		return fmt.Errorf("%s is %q but the spec also requests network resources via Windows.Network", AnnotationNetworkingMode, AnnotationNetworkingModeNone)
+	}
+	return nil
+}
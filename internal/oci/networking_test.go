@@ -0,0 +1,32 @@
+package oci
+
+import (
+	"testing"
+
+	"github.com/opencontainers/runtime-spec/specs-go"
+)
+
+func Test_ValidateNetworkingModeNone(t *testing.T) {
+	s := &specs.Spec{
+		Annotations: map[string]string{
+			AnnotationNetworkingMode: AnnotationNetworkingModeNone,
+		},
+	}
+	if err := ValidateNetworkingModeNone(s); err != nil {
+		t.Fatalf("expected no error for a networkless spec, got %v", err)
+	}
+
+	s.Windows = &specs.Windows{Network: &specs.WindowsNetwork{}}
+	if err := ValidateNetworkingModeNone(s); err == nil {
+		t.Fatal("expected an error when Windows.Network is set alongside networking mode none")
+	}
+}
+
+func Test_ValidateNetworkingModeNone_Unset(t *testing.T) {
+	s := &specs.Spec{
+		Windows: &specs.Windows{Network: &specs.WindowsNetwork{}},
+	}
+	if err := ValidateNetworkingModeNone(s); err != nil {
+		t.Fatalf("expected no error when networking mode is not set, got %v", err)
+	}
+}
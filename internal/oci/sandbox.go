@@ -1,7 +1,11 @@
 package oci
 
 import (
+	"context"
 	"fmt"
+	"strings"
+
+	"github.com/opencontainers/runtime-spec/specs-go"
 )
 
 // KubernetesContainerTypeAnnotation is the annotation used by CRI to define the `ContainerType`.
@@ -57,3 +61,59 @@ func GetSandboxTypeAndID(specAnnotations map[string]string) (KubernetesContainer
 	}
 	return ct, id, nil
 }
+
+// SandboxUVMSharingKey returns the value of `annotationUVMSharingKey` set on
+// `s`, or "" if the pod did not opt in to UVM sharing.
+//
+// Pods that set the same non-empty key are placed in the same utility VM by
+// the caller instead of each getting their own, trading pod-level isolation
+// for lower per-pod overhead. It is the caller's responsibility to restrict
+// which pods may share a key (e.g. same tenant/namespace) via policy.
+func SandboxUVMSharingKey(s *specs.Spec) string {
+	return parseAnnotationsString(s.Annotations, annotationUVMSharingKey, "")
+}
+
+// SandboxPrefetchLayers returns the image layer hints set on the pod sandbox
+// via `annotationSandboxPrefetchLayers`, or nil if none were set.
+//
+// The annotation value is one or more images' worth of read-only layer
+// folders, in the same base-to-top order as `Spec.Windows.LayerFolders` but
+// without a trailing scratch entry (the scratch folder isn't known until the
+// matching workload container is actually created). Layer folders within one
+// image are comma separated; multiple images are semicolon separated, e.g.
+// `C:\layers\image1\1,C:\layers\image1\2;C:\layers\image2\1`.
+func SandboxPrefetchLayers(s *specs.Spec) [][]string {
+	v := parseAnnotationsString(s.Annotations, annotationSandboxPrefetchLayers, "")
+	if v == "" {
+		return nil
+	}
+	var hints [][]string
+	for _, image := range strings.Split(v, ";") {
+		if image == "" {
+			continue
+		}
+		hints = append(hints, strings.Split(image, ","))
+	}
+	return hints
+}
+
+// SandboxAdmissionCheckResourcesEnabled returns whether `s` opted its uVM
+// creation into the host resource admission check via
+// `annotationAdmissionCheckResources`. The default, if unset, is false.
+func SandboxAdmissionCheckResourcesEnabled(ctx context.Context, s *specs.Spec) bool {
+	return parseAnnotationsBool(ctx, s.Annotations, annotationAdmissionCheckResources, false)
+}
+
+// SandboxAdmissionMinimumFreeMemoryInMB returns the minimum free host memory,
+// in MB, required by the admission check set via
+// `annotationAdmissionMinimumFreeMemoryInMB`, or 0 if unset.
+func SandboxAdmissionMinimumFreeMemoryInMB(ctx context.Context, s *specs.Spec) uint64 {
+	return parseAnnotationsUint64(ctx, s.Annotations, annotationAdmissionMinimumFreeMemoryInMB, 0)
+}
+
+// SandboxAdmissionMinimumIdleCPUPercent returns the minimum idle host CPU
+// percentage required by the admission check set via
+// `annotationAdmissionMinimumIdleCPUPercent`, or 0 if unset.
+func SandboxAdmissionMinimumIdleCPUPercent(ctx context.Context, s *specs.Spec) uint64 {
+	return parseAnnotationsUint64(ctx, s.Annotations, annotationAdmissionMinimumIdleCPUPercent, 0)
+}
@@ -16,3 +16,10 @@ func IsWCOW(s *specs.Spec) bool {
 func IsIsolated(s *specs.Spec) bool {
 	return IsLCOW(s) || (s.Windows != nil && s.Windows.HyperV != nil)
 }
+
+// IsEphemeralContainer checks if `s` was created with
+// `AnnotationContainerEphemeral` set, marking it a debugging-only addition
+// to an already-running pod.
+func IsEphemeralContainer(s *specs.Spec) bool {
+	return s.Annotations[AnnotationContainerEphemeral] == "true"
+}
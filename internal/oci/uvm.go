@@ -3,8 +3,10 @@ package oci
 import (
 	"context"
 	"errors"
+	"fmt"
 	"strconv"
 	"strings"
+	"time"
 
 	runhcsopts "github.com/Microsoft/hcsshim/cmd/containerd-shim-runhcs-v1/options"
 	"github.com/Microsoft/hcsshim/internal/log"
@@ -85,6 +87,168 @@ const (
 	// files and information needed to install given driver(s). This may include .sys,
 	// .inf, .cer, and/or other files used during standard installation with pnputil.
 	AnnotationAssignedDeviceKernelDrivers = "io.microsoft.assigneddevice.kerneldrivers"
+	// AnnotationContainerDeviceResolverPrefix marks an annotation as a device
+	// request to be resolved by an external plugin rather than naming a host
+	// device directly: an annotation `io.microsoft.container.device.<name>`
+	// with any value is passed, name and value unchanged, to the plugin
+	// configured via AnnotationAssignedDeviceResolverAddress, and the
+	// (idType, deviceID) pair it returns is assigned exactly as if it had
+	// been listed in `Spec.Windows.Devices`. This is not registered in
+	// annotationSchema since the `<name>` suffix is caller-defined; hcsshim
+	// treats the value as opaque and leaves validating it to the plugin.
+	AnnotationContainerDeviceResolverPrefix = "io.microsoft.container.device."
+	// AnnotationAssignedDeviceResolverAddress is the named pipe address of
+	// the external plugin implementing the DeviceResolver ttrpc service
+	// (see internal/deviceplugin) used to resolve
+	// AnnotationContainerDeviceResolverPrefix annotations. Required if any
+	// such annotation is present; ignored otherwise. Deliberately outside
+	// the io.microsoft.container.device. prefix so it is never itself
+	// mistaken for a device request.
+	AnnotationAssignedDeviceResolverAddress = "io.microsoft.assigneddevice.resolveraddress"
+	// AnnotationContainerDeviceInterfaceClassGUIDs is a comma separated
+	// list of device interface class GUIDs to expose to the container
+	// wholesale: every device currently present, or that arrives later, in
+	// each class is passed through, equivalent to hand-authoring a
+	// `Spec.Windows.Devices` entry with IDType "vpci-class-guid" for each
+	// one. Meant for device classes like camera, sensor, and serial where
+	// enumerating individual device IDs up front is impractical. Each GUID
+	// is checked against the shim config's AllowedDeviceClasses allowlist,
+	// if one is set (see ValidateDeviceClassAllowlist).
+	AnnotationContainerDeviceInterfaceClassGUIDs = "io.microsoft.container.devices.interfaceclassguids"
+	// AnnotationContainerScratchSizeInMB overrides the size of the container's
+	// writable (scratch) layer, which otherwise defaults to whatever size the
+	// storage driver creates it at. This is separate from, and does not affect,
+	// the utility VM's own scratch.
+	AnnotationContainerScratchSizeInMB = "io.microsoft.container.storage.scratch.sizeinmb"
+	// AnnotationContainerScratchLocation overrides the host directory the
+	// container's writable (scratch) layer is created in, instead of the last
+	// entry of `Spec.Windows.LayerFolders`. This allows a single pod's
+	// containers to place their scratch space on different volumes.
+	AnnotationContainerScratchLocation = "io.microsoft.container.storage.scratch.location"
+	// AnnotationContainerScratchMemoryBacked marks a newly-created container
+	// scratch VHD as preferring to stay cache-resident rather than being
+	// written to disk, for workloads whose writable layer is throwaway and
+	// performance-sensitive (e.g. CI build pods). It has no effect on a
+	// scratch that already exists, and no LCOW equivalent: there the guest,
+	// not the host, owns the scratch's backing filesystem.
+	AnnotationContainerScratchMemoryBacked = "io.microsoft.container.storage.scratch.memorybacked"
+	// AnnotationContainerImageVolumes lists image-declared volume paths
+	// (container destination paths, e.g. Dockerfile VOLUME directives) that
+	// didn't get resolved to an explicit mount in the spec, comma
+	// separated. hcsshim has no visibility into image config, so it's the
+	// caller's responsibility to pass these through if it wants anything
+	// other than the default "ignore" policy applied to them. See
+	// AnnotationContainerImageVolumePolicy.
+	AnnotationContainerImageVolumes = "io.microsoft.container.imagevolumes"
+	// AnnotationContainerImageVolumePolicy selects how the paths listed in
+	// AnnotationContainerImageVolumes are handled for a WCOW container, one
+	// of:
+	//   - "ignore" (default): no special handling. The path is left to fall
+	//     wherever it lands in the container's own writable layer, matching
+	//     the engine's behavior before this annotation existed.
+	//   - "scratch": each path is backed by its own host directory, shared
+	//     in read-write and private to this container, so it survives
+	//     independently of (and isn't counted against the size of) the
+	//     container's scratch layer.
+	//   - "share": each path is backed by a host directory under
+	//     AnnotationContainerImageVolumeSharePath, so containers in the same
+	//     pod that declare the same path see the same contents -- the
+	//     Windows container engine equivalent of a named volume shared
+	//     between containers.
+	AnnotationContainerImageVolumePolicy = "io.microsoft.container.imagevolumes.policy"
+	// AnnotationContainerImageVolumeSharePath is the host directory
+	// "share"-policy image volumes are rooted under. Required when
+	// AnnotationContainerImageVolumePolicy is "share"; the caller is
+	// responsible for giving every container in a pod that wants to share a
+	// volume the same value.
+	AnnotationContainerImageVolumeSharePath = "io.microsoft.container.imagevolumes.sharepath"
+	// AnnotationContainerImageMountPrefix marks an annotation as naming an
+	// LCOW "image mount": an annotation `io.microsoft.container.imagemount.
+	// <name>` lists, comma separated, the read-only layer folders of a
+	// second image (base-to-top order, same as `Spec.Windows.LayerFolders`
+	// but without a trailing scratch entry) to resolve and attach
+	// alongside the container's own rootfs, for workloads that consume a
+	// model or dataset distributed as its own image rather than baked into
+	// their own. `<name>` is caller-defined and referenced by the
+	// `Spec.Mounts` entry that wants it: see ParseAnnotationsImageMounts.
+	AnnotationContainerImageMountPrefix = "io.microsoft.container.imagemount."
+	// AnnotationContainerRestartPolicy controls whether the shim restarts a
+	// container's init process in place (reusing the same uVM, layers, and
+	// network endpoints) after it exits instead of tearing the task down.
+	// One of "no" (default), "always", or "on-failure". See RestartPolicy.
+	AnnotationContainerRestartPolicy = "io.microsoft.container.restartpolicy"
+	// AnnotationContainerRestartMaxAttempts caps how many times
+	// AnnotationContainerRestartPolicy is allowed to restart the init
+	// process before giving up and reporting the exit normally. Defaults to
+	// 5.
+	AnnotationContainerRestartMaxAttempts = "io.microsoft.container.restartpolicy.maxattempts"
+	// AnnotationContainerRestartDelayInMs is the delay, in milliseconds,
+	// before the first restart attempt. Each subsequent attempt doubles it,
+	// up to a fixed cap. Defaults to 1000 (1s).
+	AnnotationContainerRestartDelayInMs = "io.microsoft.container.restartpolicy.delayinms"
+	// AnnotationContainerEphemeral marks a container as a short-lived,
+	// debugging-only addition to an already-running pod (the shim-level
+	// counterpart of a Kubernetes ephemeral container, e.g. one added via
+	// `kubectl debug`), set by the caller that creates it. The shim doesn't
+	// need this to create the container -- CreateTask already supports
+	// adding an arbitrary-image container to a running pod, sharing its uVM
+	// and namespaces, layers hot-added the same way any workload
+	// container's are -- but it uses the hint to avoid letting an unrelated
+	// debugging container hold up or be held up by the pod's own shutdown
+	// ordering. See pod.KillTask.
+	AnnotationContainerEphemeral = "io.microsoft.container.ephemeral"
+	// AnnotationContainerExecMemoryLimitInMB caps the memory a non-init exec
+	// (e.g. a troubleshooting `ctr task exec`) started against an already
+	// running container may use, on top of whatever the container's own
+	// limit already is. 0 (the default) applies no separate cap.
+	//
+	// Note: This annotation is in MB.
+	//
+	// This is read once, from the task's spec, at container create time: the
+	// containerd runtime v2 exec request carries no annotations of its own,
+	// so every exec started against the container is capped the same way --
+	// there is no way to give two execs of the same container different
+	// limits. It is also only enforced for process-isolated WCOW containers.
+	// A Hyper-V isolated container's execs run inside the guest, where the
+	// host has no job object handle to attach to, and LCOW has nothing
+	// resembling a job object at all: the guest's GCS owns its own cgroups
+	// hierarchy (see hcsdoc_lcow.go's CgroupsPath comment) and this repo
+	// vendors no GCS message that would let the host ask it to scope one
+	// exec'd process into a cgroup of its own, separate from the
+	// container's.
+	AnnotationContainerExecMemoryLimitInMB = "io.microsoft.container.exec.memory.sizeinmb"
+	// AnnotationContainerExecCPULimit is
+	// AnnotationContainerExecMemoryLimitInMB's CPU counterpart: 1-10,000,
+	// where 10,000 means 100% of one core. 0 (the default) applies no
+	// separate cap. See AnnotationContainerExecMemoryLimitInMB for the
+	// scope this is limited to.
+	AnnotationContainerExecCPULimit = "io.microsoft.container.exec.processor.limit"
+	// AnnotationContainerExecMemoryNotifyLimitInMB is a soft threshold,
+	// below AnnotationContainerExecMemoryLimitInMB, that only logs a
+	// warning (it does not kill anything) when the exec's job object
+	// crosses it -- the job object analogue of cgroup v2's memory.high, as
+	// an early warning before AnnotationContainerExecMemoryLimitInMB's hard
+	// kill. 0 (the default) sets no such threshold. Has no effect unless
+	// AnnotationContainerExecMemoryLimitInMB is also set, since a
+	// notify-only limit with nothing underneath it would never be followed
+	// by a kill and so isn't a useful warning.
+	//
+	// Note: This annotation is in MB.
+	AnnotationContainerExecMemoryNotifyLimitInMB = "io.microsoft.container.exec.memory.notifysizeinmb"
+	// AnnotationContainerReadinessProbeExec is a shell command the shim
+	// repeatedly execs inside the container (via `/bin/sh -c` for LCOW,
+	// `cmd /c` for WCOW) until it exits zero, at which point the container's
+	// init process is considered to have reached a user-defined readiness
+	// point and the shim publishes a readiness event; see
+	// internal/readiness.Wait. Unset (the default) runs no probe at all. Has
+	// no effect on an exec started against the container after its init
+	// process, only the init process itself.
+	AnnotationContainerReadinessProbeExec = "io.microsoft.container.readinessprobe.exec"
+	// AnnotationContainerReadinessProbeIntervalInMs is the delay, in
+	// milliseconds, between AnnotationContainerReadinessProbeExec attempts.
+	// Defaults to 1000 (1s). Has no effect unless
+	// AnnotationContainerReadinessProbeExec is also set.
+	AnnotationContainerReadinessProbeIntervalInMs = "io.microsoft.container.readinessprobe.intervalinms"
 
 	annotationAllowOvercommit       = "io.microsoft.virtualmachine.computetopology.memory.allowovercommit"
 	annotationEnableDeferredCommit  = "io.microsoft.virtualmachine.computetopology.memory.enabledeferredcommit"
@@ -120,13 +284,28 @@ const (
 	//
 	// Note: Unlike Windows process isolated container QoS Count/Limt/Weight on
 	// the UVM are not mutually exclusive and can be set together.
-	annotationProcessorWeight             = "io.microsoft.virtualmachine.computetopology.processor.weight"
+	annotationProcessorWeight = "io.microsoft.virtualmachine.computetopology.processor.weight"
+	// annotationProcessorReservation overrides the hypervisor isolated vCPU
+	// reservation set via the OCI spec.
+	//
+	// Reservation allows values 0 - 100,000 where 100,000 means the uVM is
+	// guaranteed all of a vCPU's host time. This is the guest-idle/wake-
+	// latency knob: a latency-critical pod that wants to avoid vCPU wake
+	// latency should reserve enough host CPU time that the scheduler keeps
+	// its vCPUs running instead of descheduling them to idle. HCS has no
+	// separate "disable guest idle" switch in this schema version; a
+	// reservation is the mechanism actually exposed for this.
+	//
+	// Reservation allows values 0 - 100,000. (0, meaning no reservation, is
+	// the default if omitted)
+	annotationProcessorReservation        = "io.microsoft.virtualmachine.computetopology.processor.reservation"
 	annotationVPMemCount                  = "io.microsoft.virtualmachine.devices.virtualpmem.maximumcount"
 	annotationVPMemSize                   = "io.microsoft.virtualmachine.devices.virtualpmem.maximumsizebytes"
 	annotationPreferredRootFSType         = "io.microsoft.virtualmachine.lcow.preferredrootfstype"
 	annotationBootFilesRootPath           = "io.microsoft.virtualmachine.lcow.bootfilesrootpath"
 	annotationKernelDirectBoot            = "io.microsoft.virtualmachine.lcow.kerneldirectboot"
 	annotationVPCIEnabled                 = "io.microsoft.virtualmachine.lcow.vpcienabled"
+	annotationPlan9UseShareRootIdentity   = "io.microsoft.virtualmachine.lcow.plan9.usesharerootidentity"
 	annotationStorageQoSBandwidthMaximum  = "io.microsoft.virtualmachine.storageqos.bandwidthmaximum"
 	annotationStorageQoSIopsMaximum       = "io.microsoft.virtualmachine.storageqos.iopsmaximum"
 	annotationFullyPhysicallyBacked       = "io.microsoft.virtualmachine.fullyphysicallybacked"
@@ -138,6 +317,68 @@ const (
 
 	// annotation used to specify the cpugroup ID that a UVM should be assigned to
 	annotationCPUGroupID = "io.microsoft.virtualmachine.cpugroup.id"
+
+	// AnnotationEncryptedScratchDisk requests that a WCOW UVM's scratch disk
+	// (sandbox.vhdx) be created encrypted-at-rest. Unset (the default) creates
+	// the usual unencrypted scratch disk. Set to true today, the UVM fails to
+	// create: see wcow.ErrEncryptedScratchDiskNotSupported for why this isn't
+	// implemented yet. Has no effect on LCOW.
+	AnnotationEncryptedScratchDisk = "io.microsoft.virtualmachine.wcow.scratch.encrypted"
+
+	// annotationUVMSharingKey opts a pod sandbox into reusing an existing
+	// utility VM rather than creating its own. All pods requesting the same
+	// key, from the same shim process, share one UVM; see
+	// oci.SandboxUVMSharingKey.
+	annotationUVMSharingKey = "io.microsoft.virtualmachine.sharing.key"
+
+	// annotationSandboxPrefetchLayers is a hint, set on the pod sandbox, of
+	// the read-only image layers that workload containers created in this
+	// pod are expected to use, so the shim can start attaching/verifying
+	// their layer VHDs (LCOW) or warming their VSMB shares (WCOW) against
+	// the uVM as soon as it starts instead of waiting for the matching
+	// CreateTask to arrive. See oci.SandboxPrefetchLayers for the value
+	// format.
+	annotationSandboxPrefetchLayers = "io.microsoft.virtualmachine.prefetchlayers"
+
+	// annotationAdmissionCheckResources opts a pod sandbox into the host
+	// resource admission check run before its uVM is created (see
+	// admission.Check): false (the default) runs no check at all.
+	annotationAdmissionCheckResources = "io.microsoft.virtualmachine.admission.checkresources"
+	// annotationAdmissionMinimumFreeMemoryInMB is the minimum free host
+	// physical memory, in MB, the admission check requires. 0 (the default)
+	// means the check doesn't consider memory.
+	annotationAdmissionMinimumFreeMemoryInMB = "io.microsoft.virtualmachine.admission.minimumfreememoryinmb"
+	// annotationAdmissionMinimumIdleCPUPercent is the minimum idle host CPU
+	// percentage (0-100, sampled across all logical processors) the
+	// admission check requires. 0 (the default) means the check doesn't
+	// consider CPU.
+	annotationAdmissionMinimumIdleCPUPercent = "io.microsoft.virtualmachine.admission.minimumidlecpupercent"
+
+	// annotationFirewallRules is a semicolon separated list of host-defined
+	// firewall rules to push into the uVM once it starts, for
+	// defense-in-depth beyond the HNS ACLs already applied to its external
+	// switch port. Each rule is opaque to hcsshim: it's passed to GCS
+	// unparsed, in the syntax the guest applies it with -- a WFP filter
+	// condition string for WCOW, an nftables rule for LCOW. See
+	// oci.FirewallRules.
+	annotationFirewallRules = "io.microsoft.virtualmachine.firewallrules"
+)
+
+// RestartPolicy controls whether hcsTask restarts a container's init
+// process in place after it exits, instead of tearing the container down.
+type RestartPolicy string
+
+const (
+	// RestartPolicyNo never restarts the init process; its exit always ends
+	// the task. This is the default.
+	RestartPolicyNo RestartPolicy = "no"
+	// RestartPolicyAlways restarts the init process regardless of its exit
+	// status, up to the configured maximum number of attempts.
+	RestartPolicyAlways RestartPolicy = "always"
+	// RestartPolicyOnFailure restarts the init process only when it exits
+	// with a non-zero status, up to the configured maximum number of
+	// attempts.
+	RestartPolicyOnFailure RestartPolicy = "on-failure"
 )
 
 // parseAnnotationsBool searches `a` for `key` and if found verifies that the
@@ -264,6 +505,147 @@ func ParseAnnotationsMemory(ctx context.Context, s *specs.Spec, annotation strin
 	return def
 }
 
+// ParseAnnotationsScratchSize searches `s.Annotations` for the scratch size
+// annotation. If not found returns `def`.
+//
+// Note: The returned value is in `MB`.
+func ParseAnnotationsScratchSize(ctx context.Context, s *specs.Spec, annotation string, def uint64) uint64 {
+	return parseAnnotationsUint64(ctx, s.Annotations, annotation, def)
+}
+
+// ParseAnnotationsScratchLocation searches `s.Annotations` for the scratch
+// location annotation. If not found returns `def`.
+func ParseAnnotationsScratchLocation(s *specs.Spec, annotation string, def string) string {
+	return parseAnnotationsString(s.Annotations, annotation, def)
+}
+
+// ImageVolumePaths returns the image-declared volume paths set via
+// `AnnotationContainerImageVolumes`, or nil if none were set.
+func ImageVolumePaths(s *specs.Spec) []string {
+	v := parseAnnotationsString(s.Annotations, AnnotationContainerImageVolumes, "")
+	if v == "" {
+		return nil
+	}
+	return strings.Split(v, ",")
+}
+
+// ImageVolumePolicy returns the policy set via
+// `AnnotationContainerImageVolumePolicy`, defaulting to "ignore".
+func ImageVolumePolicy(s *specs.Spec) string {
+	return parseAnnotationsString(s.Annotations, AnnotationContainerImageVolumePolicy, "ignore")
+}
+
+// ImageVolumeSharePath returns the host directory set via
+// `AnnotationContainerImageVolumeSharePath`, or "" if unset.
+func ImageVolumeSharePath(s *specs.Spec) string {
+	return parseAnnotationsString(s.Annotations, AnnotationContainerImageVolumeSharePath, "")
+}
+
+// ImageMountLayers returns the read-only layer folders listed via the
+// `AnnotationContainerImageMountPrefix+name` annotation, or nil if none was
+// set for that name.
+func ImageMountLayers(s *specs.Spec, name string) []string {
+	v := parseAnnotationsString(s.Annotations, AnnotationContainerImageMountPrefix+name, "")
+	if v == "" {
+		return nil
+	}
+	return strings.Split(v, ",")
+}
+
+// FirewallRules returns the uVM firewall rules set via
+// `annotationFirewallRules`, or nil if none were set. Each rule is a single
+// WFP filter condition string (WCOW) or nftables rule (LCOW) in GCS's
+// syntax; hcsshim neither parses nor validates them. See
+// uvm.UtilityVM.ApplyFirewallRules.
+func FirewallRules(s *specs.Spec) []string {
+	v := parseAnnotationsString(s.Annotations, annotationFirewallRules, "")
+	if v == "" {
+		return nil
+	}
+	return strings.Split(v, ";")
+}
+
+// ReadinessProbeExec returns the shell command set via
+// `AnnotationContainerReadinessProbeExec`, or "" if unset.
+func ReadinessProbeExec(s *specs.Spec) string {
+	return parseAnnotationsString(s.Annotations, AnnotationContainerReadinessProbeExec, "")
+}
+
+// ReadinessProbeInterval returns the probe interval set via
+// `AnnotationContainerReadinessProbeIntervalInMs`, or the 1s default if
+// unset.
+func ReadinessProbeInterval(ctx context.Context, s *specs.Spec) time.Duration {
+	ms := parseAnnotationsUint32(ctx, s.Annotations, AnnotationContainerReadinessProbeIntervalInMs, 1000)
+	return time.Duration(ms) * time.Millisecond
+}
+
+// ParseAnnotationsExecMemoryLimitInMB searches `s.Annotations` for the exec
+// memory limit annotation. If not found returns `def`.
+//
+// Note: The returned value is in `MB`.
+func ParseAnnotationsExecMemoryLimitInMB(ctx context.Context, s *specs.Spec, annotation string, def uint64) uint64 {
+	return parseAnnotationsUint64(ctx, s.Annotations, annotation, def)
+}
+
+// ParseAnnotationsExecCPULimit searches `s.Annotations` for the exec CPU
+// limit annotation. If not found returns `def`.
+func ParseAnnotationsExecCPULimit(ctx context.Context, s *specs.Spec, annotation string, def int32) int32 {
+	return int32(parseAnnotationsUint64(ctx, s.Annotations, annotation, uint64(def)))
+}
+
+// ParseAnnotationsExecMemoryNotifyLimitInMB searches `s.Annotations` for the
+// exec soft memory notification limit annotation. If not found returns
+// `def`.
+//
+// Note: The returned value is in `MB`.
+func ParseAnnotationsExecMemoryNotifyLimitInMB(ctx context.Context, s *specs.Spec, annotation string, def uint64) uint64 {
+	return parseAnnotationsUint64(ctx, s.Annotations, annotation, def)
+}
+
+// ParseAnnotationsBool searches `s.Annotations` for `annotation` and if found
+// verifies that the value is `true` or `false` in any case. If not found, or
+// it cannot be parsed, returns `def`.
+func ParseAnnotationsBool(ctx context.Context, s *specs.Spec, annotation string, def bool) bool {
+	return parseAnnotationsBool(ctx, s.Annotations, annotation, def)
+}
+
+// parseAnnotationsRestartPolicy searches `a` for `key` and verifies that the
+// value is one of the allowed RestartPolicy values. If `key` is not found,
+// or the value isn't recognized, returns `def`.
+func parseAnnotationsRestartPolicy(ctx context.Context, a map[string]string, key string, def RestartPolicy) RestartPolicy {
+	if v, ok := a[key]; ok {
+		switch RestartPolicy(v) {
+		case RestartPolicyNo, RestartPolicyAlways, RestartPolicyOnFailure:
+			return RestartPolicy(v)
+		default:
+			log.G(ctx).WithFields(logrus.Fields{
+				"annotation": key,
+				"value":      v,
+			}).Warn("annotation value must be 'no', 'always', or 'on-failure'")
+		}
+	}
+	return def
+}
+
+// ParseAnnotationsRestartPolicy searches `s.Annotations` for the restart
+// policy annotation. If not found, or the value isn't recognized, returns
+// `def`.
+func ParseAnnotationsRestartPolicy(ctx context.Context, s *specs.Spec, annotation string, def RestartPolicy) RestartPolicy {
+	return parseAnnotationsRestartPolicy(ctx, s.Annotations, annotation, def)
+}
+
+// ParseAnnotationsRestartMaxAttempts searches `s.Annotations` for the
+// restart max-attempts annotation. If not found returns `def`.
+func ParseAnnotationsRestartMaxAttempts(ctx context.Context, s *specs.Spec, annotation string, def uint32) uint32 {
+	return parseAnnotationsUint32(ctx, s.Annotations, annotation, def)
+}
+
+// ParseAnnotationsRestartDelayInMs searches `s.Annotations` for the restart
+// base-delay annotation, in milliseconds. If not found returns `def`.
+func ParseAnnotationsRestartDelayInMs(ctx context.Context, s *specs.Spec, annotation string, def uint64) uint64 {
+	return parseAnnotationsUint64(ctx, s.Annotations, annotation, def)
+}
+
 // parseAnnotationsPreferredRootFSType searches `a` for `key` and verifies that the
 // value is in the set of allowed values. If `key` is not found returns `def`.
 func parseAnnotationsPreferredRootFSType(ctx context.Context, a map[string]string, key string, def uvm.PreferredRootFSType) uvm.PreferredRootFSType {
@@ -387,14 +769,17 @@ func SpecToUVMCreateOpts(ctx context.Context, s *specs.Spec, id, owner string) (
 		lopts.ProcessorCount = ParseAnnotationsCPUCount(ctx, s, annotationProcessorCount, lopts.ProcessorCount)
 		lopts.ProcessorLimit = ParseAnnotationsCPULimit(ctx, s, annotationProcessorLimit, lopts.ProcessorLimit)
 		lopts.ProcessorWeight = ParseAnnotationsCPUWeight(ctx, s, annotationProcessorWeight, lopts.ProcessorWeight)
+		lopts.ProcessorReservation = parseAnnotationsUint64(ctx, s.Annotations, annotationProcessorReservation, lopts.ProcessorReservation)
 		lopts.VPMemDeviceCount = parseAnnotationsUint32(ctx, s.Annotations, annotationVPMemCount, lopts.VPMemDeviceCount)
 		lopts.VPMemSizeBytes = parseAnnotationsUint64(ctx, s.Annotations, annotationVPMemSize, lopts.VPMemSizeBytes)
 		lopts.StorageQoSBandwidthMaximum = ParseAnnotationsStorageBps(ctx, s, annotationStorageQoSBandwidthMaximum, lopts.StorageQoSBandwidthMaximum)
 		lopts.StorageQoSIopsMaximum = ParseAnnotationsStorageIops(ctx, s, annotationStorageQoSIopsMaximum, lopts.StorageQoSIopsMaximum)
 		lopts.VPCIEnabled = parseAnnotationsBool(ctx, s.Annotations, annotationVPCIEnabled, lopts.VPCIEnabled)
+		lopts.Plan9UseShareRootIdentity = parseAnnotationsBool(ctx, s.Annotations, annotationPlan9UseShareRootIdentity, lopts.Plan9UseShareRootIdentity)
 		lopts.BootFilesPath = parseAnnotationsString(s.Annotations, annotationBootFilesRootPath, lopts.BootFilesPath)
 		lopts.ExternalGuestConnection = parseAnnotationsBool(ctx, s.Annotations, annotationUseExternalGCSBridge, lopts.ExternalGuestConnection)
 		lopts.CPUGroupID = parseAnnotationsString(s.Annotations, annotationCPUGroupID, lopts.CPUGroupID)
+		lopts.FirewallRules = FirewallRules(s)
 		handleAnnotationPreferredRootFSType(ctx, s.Annotations, lopts)
 		handleAnnotationKernelDirectBoot(ctx, s.Annotations, lopts)
 
@@ -413,20 +798,23 @@ func SpecToUVMCreateOpts(ctx context.Context, s *specs.Spec, id, owner string) (
 		wopts.ProcessorCount = ParseAnnotationsCPUCount(ctx, s, annotationProcessorCount, wopts.ProcessorCount)
 		wopts.ProcessorLimit = ParseAnnotationsCPULimit(ctx, s, annotationProcessorLimit, wopts.ProcessorLimit)
 		wopts.ProcessorWeight = ParseAnnotationsCPUWeight(ctx, s, annotationProcessorWeight, wopts.ProcessorWeight)
+		wopts.ProcessorReservation = parseAnnotationsUint64(ctx, s.Annotations, annotationProcessorReservation, wopts.ProcessorReservation)
 		wopts.StorageQoSBandwidthMaximum = ParseAnnotationsStorageBps(ctx, s, annotationStorageQoSBandwidthMaximum, wopts.StorageQoSBandwidthMaximum)
 		wopts.StorageQoSIopsMaximum = ParseAnnotationsStorageIops(ctx, s, annotationStorageQoSIopsMaximum, wopts.StorageQoSIopsMaximum)
 		wopts.ExternalGuestConnection = parseAnnotationsBool(ctx, s.Annotations, annotationUseExternalGCSBridge, wopts.ExternalGuestConnection)
 		wopts.DisableCompartmentNamespace = parseAnnotationsBool(ctx, s.Annotations, annotationDisableCompartmentNamespace, wopts.DisableCompartmentNamespace)
 		wopts.CPUGroupID = parseAnnotationsString(s.Annotations, annotationCPUGroupID, wopts.CPUGroupID)
+		wopts.EncryptedScratchDisk = parseAnnotationsBool(ctx, s.Annotations, AnnotationEncryptedScratchDisk, wopts.EncryptedScratchDisk)
+		wopts.FirewallRules = FirewallRules(s)
 		handleAnnotationFullyPhysicallyBacked(ctx, s.Annotations, wopts)
 		return wopts, nil
 	}
 	return nil, errors.New("cannot create UVM opts spec is not LCOW or WCOW")
 }
 
-// UpdateSpecFromOptions sets extra annotations on the OCI spec based on the
-// `opts` struct.
-func UpdateSpecFromOptions(s specs.Spec, opts *runhcsopts.Options) specs.Spec {
+// UpdateSpecFromOptions sets extra annotations, environment variables, and
+// mounts on the OCI spec based on the `opts` struct.
+func UpdateSpecFromOptions(ctx context.Context, s specs.Spec, opts *runhcsopts.Options) specs.Spec {
 	if opts == nil {
 		return s
 	}
@@ -447,5 +835,134 @@ func UpdateSpecFromOptions(s specs.Spec, opts *runhcsopts.Options) specs.Spec {
 		s.Annotations[AnnotationGPUVHDPath] = opts.GPUVHDPath
 	}
 
+	for _, kv := range opts.DefaultEnvironmentVariables {
+		k := strings.SplitN(kv, "=", 2)[0]
+		found := false
+		for _, e := range s.Process.Env {
+			if strings.SplitN(e, "=", 2)[0] == k {
+				found = true
+				break
+			}
+		}
+		if !found {
+			s.Process.Env = append(s.Process.Env, kv)
+		}
+	}
+
+	for _, kv := range opts.ForcedAnnotations {
+		parts := strings.SplitN(kv, "=", 2)
+		if len(parts) != 2 {
+			continue
+		}
+		s.Annotations[parts[0]] = parts[1]
+	}
+
+	for _, m := range opts.DefaultMounts {
+		mnt, err := parseDefaultMount(m)
+		if err != nil {
+			log.G(ctx).WithFields(logrus.Fields{
+				logfields.OCIAnnotation: m,
+				logrus.ErrorKey:         err,
+			}).Warning("ignoring invalid default_mounts entry")
+			continue
+		}
+		claimed := false
+		for _, existing := range s.Mounts {
+			if existing.Destination == mnt.Destination {
+				claimed = true
+				break
+			}
+		}
+		if !claimed {
+			s.Mounts = append(s.Mounts, mnt)
+		}
+	}
+
 	return s
 }
+
+// parseDefaultMount parses a "source=destination[,ro]" entry from
+// `Options.default_mounts` into a specs.Mount. ':' is deliberately not used
+// as a separator since it appears in Windows drive-letter paths.
+func parseDefaultMount(entry string) (specs.Mount, error) {
+	eq := strings.SplitN(entry, "=", 2)
+	if len(eq) != 2 {
+		return specs.Mount{}, fmt.Errorf("invalid default mount %q: expected source=destination[,ro]", entry)
+	}
+	destAndOpt := strings.SplitN(eq[1], ",", 2)
+	m := specs.Mount{
+		Source:      eq[0],
+		Destination: destAndOpt[0],
+		Type:        "bind",
+	}
+	if len(destAndOpt) == 2 {
+		if destAndOpt[1] != "ro" {
+			return specs.Mount{}, fmt.Errorf("invalid default mount %q: unknown option %q", entry, destAndOpt[1])
+		}
+		m.Options = []string{"ro"}
+	}
+	return m, nil
+}
+
+// ParseAnnotationsDeviceInterfaceClassGUIDs appends a `specs.WindowsDevice`
+// with IDType uvm.VPCIClassGUIDType for every GUID in the comma separated
+// AnnotationContainerDeviceInterfaceClassGUIDs annotation on `s`, the same
+// way a hand-authored `Spec.Windows.Devices` entry would.
+func ParseAnnotationsDeviceInterfaceClassGUIDs(s *specs.Spec) {
+	if IsLCOW(s) {
+		return
+	}
+	v, ok := s.Annotations[AnnotationContainerDeviceInterfaceClassGUIDs]
+	if !ok || v == "" {
+		return
+	}
+	if s.Windows == nil {
+		s.Windows = &specs.Windows{}
+	}
+	for _, guid := range strings.Split(v, ",") {
+		guid = strings.TrimSpace(guid)
+		if guid == "" {
+			continue
+		}
+		s.Windows.Devices = append(s.Windows.Devices, specs.WindowsDevice{
+			IDType: uvm.VPCIClassGUIDType,
+			ID:     guid,
+		})
+	}
+}
+
+// ValidateDeviceClassAllowlist checks that every
+// AnnotationContainerDeviceResolverPrefix device request, and every device
+// interface class GUID assigned via AnnotationContainerDeviceInterfaceClassGUIDs
+// or directly on `s.Windows.Devices`, names a device class present in
+// `opts.AllowedDeviceClasses`. If that list is empty, every device class is
+// allowed.
+func ValidateDeviceClassAllowlist(s *specs.Spec, opts *runhcsopts.Options) error {
+	if opts == nil || len(opts.AllowedDeviceClasses) == 0 {
+		return nil
+	}
+	allowed := make(map[string]struct{})
+	for _, c := range opts.AllowedDeviceClasses {
+		allowed[c] = struct{}{}
+	}
+	for k := range s.Annotations {
+		if !strings.HasPrefix(k, AnnotationContainerDeviceResolverPrefix) {
+			continue
+		}
+		name := strings.TrimPrefix(k, AnnotationContainerDeviceResolverPrefix)
+		if _, ok := allowed[name]; !ok {
+			return fmt.Errorf("device class %q is not in the allowed_device_classes list for this runtime", name)
+		}
+	}
+	if s.Windows != nil {
+		for _, d := range s.Windows.Devices {
+			if d.IDType != uvm.VPCIClassGUIDType && d.IDType != uvm.VPCIClassGUIDTypeLegacy {
+				continue
+			}
+			if _, ok := allowed[d.ID]; !ok {
+				return fmt.Errorf("device class %q is not in the allowed_device_classes list for this runtime", d.ID)
+			}
+		}
+	}
+	return nil
+}
@@ -1,9 +1,11 @@
 package oci
 
 import (
+	"context"
 	"testing"
 
 	runhcsopts "github.com/Microsoft/hcsshim/cmd/containerd-shim-runhcs-v1/options"
+	"github.com/Microsoft/hcsshim/internal/uvm"
 	"github.com/opencontainers/runtime-spec/specs-go"
 )
 
@@ -18,7 +20,7 @@ func Test_SpecUpdate_MemorySize_WithAnnotation_WithOpts(t *testing.T) {
 			annotationMemorySizeInMB: "2048",
 		},
 	}
-	updatedSpec := UpdateSpecFromOptions(*s, opts)
+	updatedSpec := UpdateSpecFromOptions(context.Background(), *s, opts)
 
 	if updatedSpec.Annotations[annotationMemorySizeInMB] != "2048" {
 		t.Fatal("should not have updated annotation to default when annotation is provided in the spec")
@@ -34,7 +36,7 @@ func Test_SpecUpdate_MemorySize_NoAnnotation_WithOpts(t *testing.T) {
 		Linux:       &specs.Linux{},
 		Annotations: map[string]string{},
 	}
-	updatedSpec := UpdateSpecFromOptions(*s, opts)
+	updatedSpec := UpdateSpecFromOptions(context.Background(), *s, opts)
 
 	if updatedSpec.Annotations[annotationMemorySizeInMB] != "3072" {
 		t.Fatal("should have updated annotation to default when annotation is not provided in the spec")
@@ -52,7 +54,7 @@ func Test_SpecUpdate_ProcessorCount_WithAnnotation_WithOpts(t *testing.T) {
 			annotationProcessorCount: "8",
 		},
 	}
-	updatedSpec := UpdateSpecFromOptions(*s, opts)
+	updatedSpec := UpdateSpecFromOptions(context.Background(), *s, opts)
 
 	if updatedSpec.Annotations[annotationProcessorCount] != "8" {
 		t.Fatal("should not have updated annotation to default when annotation is provided in the spec")
@@ -68,9 +70,126 @@ func Test_SpecUpdate_ProcessorCount_NoAnnotation_WithOpts(t *testing.T) {
 		Linux:       &specs.Linux{},
 		Annotations: map[string]string{},
 	}
-	updatedSpec := UpdateSpecFromOptions(*s, opts)
+	updatedSpec := UpdateSpecFromOptions(context.Background(), *s, opts)
 
 	if updatedSpec.Annotations[annotationProcessorCount] != "4" {
 		t.Fatal("should have updated annotation to default when annotation is not provided in the spec")
 	}
 }
+
+func Test_SpecUpdate_DefaultEnvironmentVariables(t *testing.T) {
+	opts := &runhcsopts.Options{
+		DefaultEnvironmentVariables: []string{"FOO=1", "BAR=2"},
+	}
+	s := &specs.Spec{
+		Linux:       &specs.Linux{},
+		Annotations: map[string]string{},
+		Process: &specs.Process{
+			Env: []string{"FOO=0"},
+		},
+	}
+	updatedSpec := UpdateSpecFromOptions(context.Background(), *s, opts)
+
+	if len(updatedSpec.Process.Env) != 2 || updatedSpec.Process.Env[0] != "FOO=0" || updatedSpec.Process.Env[1] != "BAR=2" {
+		t.Fatalf("expected FOO to be left alone and BAR to be appended, got %v", updatedSpec.Process.Env)
+	}
+}
+
+func Test_SpecUpdate_ForcedAnnotations_Overrides(t *testing.T) {
+	opts := &runhcsopts.Options{
+		ForcedAnnotations: []string{"io.microsoft.test=forced"},
+	}
+	s := &specs.Spec{
+		Linux: &specs.Linux{},
+		Annotations: map[string]string{
+			"io.microsoft.test": "original",
+		},
+	}
+	updatedSpec := UpdateSpecFromOptions(context.Background(), *s, opts)
+
+	if updatedSpec.Annotations["io.microsoft.test"] != "forced" {
+		t.Fatal("forced_annotations should override a value already set on the spec")
+	}
+}
+
+func Test_SpecUpdate_DefaultMounts(t *testing.T) {
+	opts := &runhcsopts.Options{
+		DefaultMounts: []string{"C:\\host=C:\\container,ro", "C:\\other=C:\\existing"},
+	}
+	s := &specs.Spec{
+		Linux:       &specs.Linux{},
+		Annotations: map[string]string{},
+		Mounts: []specs.Mount{
+			{Source: "C:\\already", Destination: "C:\\existing"},
+		},
+	}
+	updatedSpec := UpdateSpecFromOptions(context.Background(), *s, opts)
+
+	if len(updatedSpec.Mounts) != 2 {
+		t.Fatalf("expected the already-mounted destination to be skipped, got %v", updatedSpec.Mounts)
+	}
+	if updatedSpec.Mounts[1].Destination != "C:\\container" || updatedSpec.Mounts[1].Options[0] != "ro" {
+		t.Fatalf("unexpected default mount added: %+v", updatedSpec.Mounts[1])
+	}
+}
+
+func Test_ParseAnnotationsDeviceInterfaceClassGUIDs(t *testing.T) {
+	s := &specs.Spec{
+		Windows: &specs.Windows{},
+		Annotations: map[string]string{
+			AnnotationContainerDeviceInterfaceClassGUIDs: "{guid-1}, {guid-2}",
+		},
+	}
+	ParseAnnotationsDeviceInterfaceClassGUIDs(s)
+
+	if len(s.Windows.Devices) != 2 {
+		t.Fatalf("expected 2 devices, got %+v", s.Windows.Devices)
+	}
+	for i, want := range []string{"{guid-1}", "{guid-2}"} {
+		if s.Windows.Devices[i].ID != want || s.Windows.Devices[i].IDType != uvm.VPCIClassGUIDType {
+			t.Fatalf("unexpected device at %d: %+v", i, s.Windows.Devices[i])
+		}
+	}
+}
+
+func Test_ValidateDeviceClassAllowlist_InterfaceClassGUIDs(t *testing.T) {
+	opts := &runhcsopts.Options{
+		AllowedDeviceClasses: []string{"{guid-allowed}"},
+	}
+	s := &specs.Spec{
+		Windows: &specs.Windows{
+			Devices: []specs.WindowsDevice{
+				{IDType: uvm.VPCIClassGUIDType, ID: "{guid-denied}"},
+			},
+		},
+	}
+	if err := ValidateDeviceClassAllowlist(s, opts); err == nil {
+		t.Fatal("expected an error for a device class GUID not in the allowlist")
+	}
+
+	s.Windows.Devices[0].ID = "{guid-allowed}"
+	if err := ValidateDeviceClassAllowlist(s, opts); err != nil {
+		t.Fatalf("expected no error for an allowed device class GUID, got %v", err)
+	}
+}
+
+func Test_ValidateDeviceClassAllowlist(t *testing.T) {
+	opts := &runhcsopts.Options{
+		AllowedDeviceClasses: []string{"gpu"},
+	}
+	s := &specs.Spec{
+		Annotations: map[string]string{
+			AnnotationContainerDeviceResolverPrefix + "usb": "some-value",
+		},
+	}
+	if err := ValidateDeviceClassAllowlist(s, opts); err == nil {
+		t.Fatal("expected an error for a device class not in the allowlist")
+	}
+
+	s.Annotations = map[string]string{
+		AnnotationContainerDeviceResolverPrefix + "gpu": "some-value",
+	}
+	if err := ValidateDeviceClassAllowlist(s, opts); err != nil {
+		t.Fatalf("expected no error for an allowed device class, got %v", err)
+	}
+}
@@ -0,0 +1,53 @@
+// Package readiness implements a host-driven readiness probe for a
+// container's init process: since this repo vendors no GCS message for the
+// guest to report readiness on its own, the shim instead repeatedly execs a
+// caller-supplied shell command inside the container (the same primitive
+// `ctr task exec` uses) until it exits zero.
+package readiness
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/Microsoft/hcsshim/internal/cmd"
+	"github.com/Microsoft/hcsshim/internal/cow"
+)
+
+// Wait repeatedly execs probeCmd (via `/bin/sh -c` on Linux, `cmd /c` on
+// Windows) inside host at interval until it exits zero, ctx is done, or host
+// itself reports an OS this package doesn't know how to build a shell
+// invocation for. A non-zero exit or exec failure is treated the same as
+// "not ready yet" and simply retried -- only ctx's error (or an unsupported
+// OS) is ever returned.
+func Wait(ctx context.Context, host cow.ProcessHost, probeCmd string, interval time.Duration) error {
+	args, err := shellArgs(host.OS(), probeCmd)
+	if err != nil {
+		return err
+	}
+
+	t := time.NewTicker(interval)
+	defer t.Stop()
+	for {
+		if err := cmd.CommandContext(ctx, host, args[0], args[1:]...).Run(); err == nil {
+			return nil
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-t.C:
+		}
+	}
+}
+
+func shellArgs(os, probeCmd string) ([]string, error) {
+	switch os {
+	case "linux":
+		return []string{"/bin/sh", "-c", probeCmd}, nil
+	case "windows":
+		return []string{"cmd", "/c", probeCmd}, nil
+	default:
+		return nil, fmt.Errorf("readiness: unsupported OS %q", os)
+	}
+}
@@ -0,0 +1,128 @@
+package regstate
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+// envelope wraps a persisted JSON value with the schema version it was
+// written at, so a later build of the code reading it back can tell an
+// old-shaped record apart from the current one and upgrade it, instead of
+// failing to unmarshal it (or silently dropping fields it no longer
+// recognizes).
+//
+// A record written before a Migrator existed for its key has no envelope at
+// all -- it is the bare JSON of whatever struct was passed to Set/Create.
+// Get/GetVersioned treats any such record as schema version 0.
+type envelope struct {
+	Version uint32          `json:"schemaVersion"`
+	Data    json.RawMessage `json:"data"`
+}
+
+// Migration upgrades the raw JSON of one schema version of a persisted value
+// to the next version's shape.
+type Migration func(old []byte) ([]byte, error)
+
+// Migrator describes how to bring a persisted value up to date: the schema
+// version new writes are made at, and the chain of Migrations needed to get
+// an older record there. Migrations[i] upgrades version i to version i+1, so
+// len(Migrations) must equal CurrentVersion.
+//
+// Callers define one Migrator per distinct kind of value stored under a
+// regstate key (e.g. runhcs's container `persistedState`, or a CNI
+// `PersistedNamespaceConfig`) and pass it to SetVersioned/CreateVersioned and
+// GetVersioned wherever that value is read or written.
+type Migrator struct {
+	CurrentVersion uint32
+	Migrations     []Migration
+}
+
+func (m *Migrator) upgrade(storedVersion uint32, raw []byte) ([]byte, error) {
+	if storedVersion > m.CurrentVersion {
+		return nil, fmt.Errorf("persisted schema version %d is newer than this build supports (%d)", storedVersion, m.CurrentVersion)
+	}
+	for v := storedVersion; v < m.CurrentVersion; v++ {
+		if int(v) >= len(m.Migrations) || m.Migrations[v] == nil {
+			return nil, fmt.Errorf("no migration registered to upgrade schema version %d to %d", v, v+1)
+		}
+		upgraded, err := m.Migrations[v](raw)
+		if err != nil {
+			return nil, fmt.Errorf("migrating schema version %d to %d: %w", v, v+1, err)
+		}
+		raw = upgraded
+	}
+	return raw, nil
+}
+
+// asEnvelope reports whether raw is an envelope written by SetVersioned, as
+// opposed to the bare JSON of a pre-Migrator record.
+func asEnvelope(raw []byte) (envelope, bool) {
+	var probe map[string]json.RawMessage
+	if err := json.Unmarshal(raw, &probe); err != nil {
+		return envelope{}, false
+	}
+	if _, ok := probe["schemaVersion"]; !ok {
+		return envelope{}, false
+	}
+	if _, ok := probe["data"]; !ok {
+		return envelope{}, false
+	}
+	var e envelope
+	if err := json.Unmarshal(raw, &e); err != nil {
+		return envelope{}, false
+	}
+	return e, true
+}
+
+// GetVersioned reads the value stored at id/key, upgrades it through m's
+// migrations if it was written at an older schema version (or before m
+// existed at all, in which case it is treated as version 0), and unmarshals
+// the result of that upgrade into state.
+func (k *Key) GetVersioned(id, key string, m *Migrator, state interface{}) error {
+	var raw json.RawMessage
+	if err := k.Get(id, key, &raw); err != nil {
+		return err
+	}
+
+	storedVersion := uint32(0)
+	data := []byte(raw)
+	if e, ok := asEnvelope(raw); ok {
+		storedVersion = e.Version
+		data = e.Data
+	}
+
+	upgraded, err := m.upgrade(storedVersion, data)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(upgraded, state)
+}
+
+// SetVersioned marshals state and writes it at id/key wrapped in an envelope
+// recording m.CurrentVersion, so a future Migrator with a higher
+// CurrentVersion knows how far it needs to upgrade the record.
+func (k *Key) SetVersioned(id, key string, m *Migrator, state interface{}) error {
+	env, err := newEnvelope(m, state)
+	if err != nil {
+		return err
+	}
+	return k.Set(id, key, env)
+}
+
+// CreateVersioned is SetVersioned's Create counterpart: it fails if id
+// already has a value stored at key.
+func (k *Key) CreateVersioned(id, key string, m *Migrator, state interface{}) error {
+	env, err := newEnvelope(m, state)
+	if err != nil {
+		return err
+	}
+	return k.Create(id, key, env)
+}
+
+func newEnvelope(m *Migrator, state interface{}) (*envelope, error) {
+	data, err := json.Marshal(state)
+	if err != nil {
+		return nil, err
+	}
+	return &envelope{Version: m.CurrentVersion, Data: data}, nil
+}
@@ -5,6 +5,7 @@ package resources
 import (
 	"context"
 	"errors"
+	"fmt"
 
 	"github.com/Microsoft/hcsshim/internal/credentials"
 	"github.com/Microsoft/hcsshim/internal/layers"
@@ -53,6 +54,22 @@ func (r *Resources) Add(newResources ...ResourceCloser) {
 	r.resources = append(r.resources, newResources...)
 }
 
+// Dump returns a description of every resource currently tracked, in the
+// order they were added. It's intended for diagnostics (e.g. logging what a
+// container still holds onto before/during a failed release), not for
+// programmatic use.
+func (r *Resources) Dump() []string {
+	descriptions := make([]string, 0, len(r.resources))
+	for _, res := range r.resources {
+		if s, ok := res.(fmt.Stringer); ok {
+			descriptions = append(descriptions, s.String())
+		} else {
+			descriptions = append(descriptions, fmt.Sprintf("%T", res))
+		}
+	}
+	return descriptions
+}
+
 // Resources is the structure returned as part of creating a container. It holds
 // nothing useful to clients, hence everything is lowercased. A client would use
 // it in a call to ReleaseResources to ensure everything is cleaned up when a
@@ -94,13 +111,19 @@ func NewContainerResources(id string) *Resources {
 
 // ReleaseResources releases/frees all of the resources associated with a container. This includes
 // Plan9 shares, vsmb mounts, pipe mounts, network endpoints, scsi mounts, vpci devices and layers.
+//
+// ReleaseResources is idempotent and safe to retry: any resource that fails to release is left in
+// r.resources so a later call (e.g. after the shim is restarted and ReleaseResources is invoked again
+// against the same Resources value) only re-attempts the ones that are still outstanding, rather than
+// erroring out on items that were already removed. Note that this idempotency is only as durable as
+// the Resources value itself -- nothing here is written to disk, so it does not survive the process
+// that holds r being killed and restarted from scratch with a fresh Resources.
 // TODO: make method on Resources struct.
 func ReleaseResources(ctx context.Context, r *Resources, vm *uvm.UtilityVM, all bool) error {
-	if vm != nil {
-		if r.addedNetNSToVM {
-			if err := vm.RemoveNetNS(ctx, r.netNS); err != nil {
-				log.G(ctx).Warn(err)
-			}
+	if vm != nil && r.addedNetNSToVM {
+		if err := vm.RemoveNetNS(ctx, r.netNS); err != nil {
+			log.G(ctx).Warn(err)
+		} else {
 			r.addedNetNSToVM = false
 		}
 	}
@@ -109,35 +132,58 @@ func ReleaseResources(ctx context.Context, r *Resources, vm *uvm.UtilityVM, all
 	// Release resources in reverse order so that the most recently
 	// added are cleaned up first. We don't return an error right away
 	// so that other resources still get cleaned up in the case of one
-	// or more failing.
+	// or more failing. Resources that fail to release are kept around in
+	// remaining so a retried call only re-attempts them.
+	remaining := make([]ResourceCloser, 0, len(r.resources))
 	for i := len(r.resources) - 1; i >= 0; i-- {
-		switch r.resources[i].(type) {
+		res := r.resources[i]
+		switch typed := res.(type) {
 		case *uvm.NetworkEndpoints:
 			if r.createdNetNS {
-				if err := r.resources[i].Release(ctx); err != nil {
+				if err := typed.Release(ctx); err != nil {
 					log.G(ctx).WithError(err).Error("failed to release container resource")
 					releaseErr = true
+					remaining = append(remaining, res)
+					continue
 				}
 				r.createdNetNS = false
 			}
 		case *credentials.CCGResource:
-			if err := r.resources[i].Release(ctx); err != nil {
+			if err := typed.Release(ctx); err != nil {
 				log.G(ctx).WithError(err).Error("failed to release container resource")
 				releaseErr = true
+				remaining = append(remaining, res)
+				continue
+			}
+		case *uvm.CCGInstance:
+			if err := typed.Release(ctx); err != nil {
+				log.G(ctx).WithError(err).Error("failed to release container resource")
+				releaseErr = true
+				remaining = append(remaining, res)
+				continue
 			}
 		default:
 			// Don't need to check if vm != nil here anymore as they wouldnt
 			// have been added in the first place. All resources have embedded
 			// vm they belong to.
 			if all {
-				if err := r.resources[i].Release(ctx); err != nil {
+				if err := res.Release(ctx); err != nil {
 					log.G(ctx).WithError(err).Error("failed to release container resource")
 					releaseErr = true
+					remaining = append(remaining, res)
+					continue
 				}
+			} else {
+				remaining = append(remaining, res)
 			}
 		}
 	}
-	r.resources = nil
+	// remaining was built newest-first (we walked r.resources in reverse); restore the
+	// original oldest-first ordering so a retried release keeps releasing newest-first.
+	for i, j := 0, len(remaining)-1; i < j; i, j = i+1, j-1 {
+		remaining[i], remaining[j] = remaining[j], remaining[i]
+	}
+	r.resources = remaining
 	if releaseErr {
 		return errors.New("failed to release one or more container resources")
 	}
@@ -46,4 +46,8 @@ type Memory2 struct {
 	// TODO: This is pre-release support in schema 2.3. Need to add build number
 	// docs when a public build with this is out.
 	HighMMIOGapInMB uint64 `json:"HighMmioGapInMB,omitempty"`
+
+	// VirtualNodeCount is the number of virtual NUMA nodes to expose to the
+	// VM. If `0`, HCS chooses the default topology (today, a single node).
+	VirtualNodeCount uint32 `json:"VirtualNodeCount,omitempty"`
 }
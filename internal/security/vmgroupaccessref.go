@@ -0,0 +1,130 @@
+//go:build windows
+// +build windows
+
+package security
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+	"golang.org/x/sys/windows"
+)
+
+// vmGroupAccessRefDir holds one small refcount file per path that has ever
+// had AcquireVmGroupAccess called on it, so the count survives the calling
+// process exiting. There is no long-running hcsshim service to hold this in
+// memory instead: every shim runs as its own process, and a base image
+// layer VHD with VM group access granted is routinely attached read-only by
+// many pods (and therefore many shim processes) at once.
+var vmGroupAccessRefDir = filepath.Join(os.Getenv("ProgramData"), "hcsshim", "vmgroupaccess")
+
+// vmGroupAccessRefKey turns an absolute path into a stable file name and
+// mutex name for its refcount state, since paths themselves can contain
+// characters that aren't valid in either.
+func vmGroupAccessRefKey(path string) string {
+	sum := sha256.Sum256([]byte(strings.ToLower(path)))
+	return hex.EncodeToString(sum[:])
+}
+
+// withVmGroupAccessRefLock runs fn while holding a named mutex scoped to
+// path's refcount key, so that concurrent Acquire/Release calls against the
+// same path, whether from this process or another one, serialize instead of
+// racing on the refcount file.
+func withVmGroupAccessRefLock(path string, fn func() error) error {
+	name, err := windows.UTF16PtrFromString(`Global\hcsshim-vmgroupaccess-` + vmGroupAccessRefKey(path))
+	if err != nil {
+		return err
+	}
+	mutex, err := windows.CreateMutex(nil, false, name)
+	if err != nil {
+		return errors.Wrap(err, "CreateMutex")
+	}
+	defer windows.CloseHandle(mutex)
+
+	if _, err := windows.WaitForSingleObject(mutex, windows.INFINITE); err != nil {
+		return errors.Wrap(err, "WaitForSingleObject")
+	}
+	defer windows.ReleaseMutex(mutex)
+
+	return fn()
+}
+
+func readVmGroupAccessRefCount(refPath string) (uint32, error) {
+	b, err := os.ReadFile(refPath)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return 0, nil
+		}
+		return 0, err
+	}
+	n, err := strconv.ParseUint(strings.TrimSpace(string(b)), 10, 32)
+	if err != nil {
+		return 0, errors.Wrapf(err, "parsing refcount in %s", refPath)
+	}
+	return uint32(n), nil
+}
+
+func writeVmGroupAccessRefCount(refPath string, count uint32) error {
+	if count == 0 {
+		err := os.Remove(refPath)
+		if err != nil && !os.IsNotExist(err) {
+			return err
+		}
+		return nil
+	}
+	return os.WriteFile(refPath, []byte(strconv.FormatUint(uint64(count), 10)), 0644)
+}
+
+// AcquireVmGroupAccess increments path's host-wide VM group access
+// reference count and, the first time it goes from zero to one across every
+// process on the host, grants VM group access to it. Every successful call
+// must be matched with a ReleaseVmGroupAccess once this process no longer
+// needs path attached.
+func AcquireVmGroupAccess(path string) error {
+	if err := os.MkdirAll(vmGroupAccessRefDir, 0700); err != nil {
+		return errors.Wrapf(err, "creating %s", vmGroupAccessRefDir)
+	}
+	refPath := filepath.Join(vmGroupAccessRefDir, vmGroupAccessRefKey(path))
+	return withVmGroupAccessRefLock(path, func() error {
+		count, err := readVmGroupAccessRefCount(refPath)
+		if err != nil {
+			return err
+		}
+		if count == 0 {
+			if err := GrantVmGroupAccess(path); err != nil {
+				return fmt.Errorf("granting vm group access to %s: %s", path, err)
+			}
+		}
+		return writeVmGroupAccessRefCount(refPath, count+1)
+	})
+}
+
+// ReleaseVmGroupAccess decrements path's host-wide VM group access
+// reference count and, if no process on the host still holds a reference,
+// revokes VM group access to it.
+func ReleaseVmGroupAccess(path string) error {
+	refPath := filepath.Join(vmGroupAccessRefDir, vmGroupAccessRefKey(path))
+	return withVmGroupAccessRefLock(path, func() error {
+		count, err := readVmGroupAccessRefCount(refPath)
+		if err != nil {
+			return err
+		}
+		if count == 0 {
+			// Nothing left to release; avoid underflowing into a bogus count.
+			return nil
+		}
+		count--
+		if count == 0 {
+			if err := RevokeVmGroupAccess(path); err != nil {
+				return fmt.Errorf("revoking vm group access to %s: %s", path, err)
+			}
+		}
+		return writeVmGroupAccessRefCount(refPath, count)
+	})
+}
@@ -0,0 +1,91 @@
+// Package shimstate persists the bookkeeping a containerd-shim-runhcs-v1
+// process needs to identify what it was serving, so that information
+// survives a process restart.
+//
+// NOTE: this only covers the bookkeeping half of a hot-upgrade handoff (a new
+// shim binary taking over the ttrpc socket of an old one without draining the
+// pod). The other half -- reattaching to the running UVM's compute system by
+// ID and re-establishing its GCS bridge from the new process -- has no
+// support in this package or elsewhere in hcsshim today: hcs.OpenComputeSystem
+// can reopen the host-side handle for termination/diagnostics (see
+// cmd/containerd-shim-runhcs-v1/delete.go), but nothing reconstructs a usable
+// *uvm.UtilityVM with a live GCS bridge from an already-running compute
+// system. A new shim can load the Snapshot left by its predecessor to learn
+// what it is expected to serve, but cannot yet resume serving it.
+package shimstate
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	"github.com/pkg/errors"
+)
+
+// fileName is the name of the state file written under a task's bundle
+// directory.
+const fileName = "shim-state.json"
+
+// Snapshot is the bookkeeping a shim process tracks for the task or POD it
+// is serving, as needed to identify it again after a restart.
+type Snapshot struct {
+	// TID is the original task id the shim was started to serve. See
+	// `service.tid`.
+	TID string `json:"tid"`
+	// IsSandbox is `true` if `TID` identifies a POD sandbox rather than a
+	// standalone task. See `service.isSandbox`.
+	IsSandbox bool `json:"isSandbox"`
+	// HostID is the compute system ID of the task's hosting UVM, or `""` if
+	// the task is not hypervisor isolated.
+	HostID string `json:"hostId,omitempty"`
+}
+
+// Path returns the path of the state file for a task with bundle directory
+// `bundle`.
+func Path(bundle string) string {
+	return filepath.Join(bundle, fileName)
+}
+
+// Save writes `s` to the state file under `bundle`, replacing any previous
+// snapshot. The write is atomic: readers of Path(bundle) never observe a
+// partially written file.
+func Save(bundle string, s *Snapshot) error {
+	b, err := json.Marshal(s)
+	if err != nil {
+		return errors.Wrap(err, "failed to marshal shim state")
+	}
+	tmp, err := ioutil.TempFile(bundle, fileName+".tmp*")
+	if err != nil {
+		return errors.Wrap(err, "failed to create temp shim state file")
+	}
+	if _, err := tmp.Write(b); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return errors.Wrap(err, "failed to write shim state")
+	}
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmp.Name())
+		return errors.Wrap(err, "failed to close temp shim state file")
+	}
+	if err := os.Rename(tmp.Name(), Path(bundle)); err != nil {
+		os.Remove(tmp.Name())
+		return errors.Wrap(err, "failed to replace shim state file")
+	}
+	return nil
+}
+
+// Load reads back the snapshot previously written by Save for `bundle`. It
+// returns `os.IsNotExist(err) == true` if no shim has saved state for this
+// bundle yet.
+func Load(bundle string) (*Snapshot, error) {
+	b, err := ioutil.ReadFile(Path(bundle))
+	if err != nil {
+		return nil, err
+	}
+	var s Snapshot
+	if err := json.Unmarshal(b, &s); err != nil {
+		return nil, errors.Wrap(err, "failed to unmarshal shim state")
+	}
+	return &s, nil
+}
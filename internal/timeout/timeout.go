@@ -13,6 +13,14 @@ var (
 	// defaultTimeoutTestdRetry is the retry loop timeout for testd to respond
 	// for a disk to come online in LCOW.
 	defaultTimeoutTestdRetry = 5 * time.Second
+
+	// defaultUVMCloseStageTimeout is the per-stage deadline for
+	// UtilityVM.Close's staged teardown. It is intentionally much shorter
+	// than defaultTimeout: the entire point of splitting Close into stages
+	// is so that one hung stage (most likely the guest not responding to a
+	// shutdown request) gives up quickly instead of leaving every later
+	// stage -- and the resources they would release -- blocked behind it.
+	defaultUVMCloseStageTimeout = 30 * time.Second
 )
 
 // External variables for HCSShim consumers to use.
@@ -29,6 +37,9 @@ var (
 	// SystemResume is the timeout for resuming a compute system
 	SystemResume time.Duration = defaultTimeout
 
+	// SystemSave is the timeout for saving the state of a compute system
+	SystemSave time.Duration = defaultTimeout
+
 	// SyscallWatcher is the timeout before warning of a potential stuck platform syscall.
 	SyscallWatcher time.Duration = defaultTimeout
 
@@ -44,6 +55,23 @@ var (
 
 	// TestDRetryLoop is the timeout for testd retry loop when onlining a SCSI disk in LCOW
 	TestDRetryLoop = defaultTimeoutTestdRetry
+
+	// UVMCloseGuestShutdown bounds how long UtilityVM.Close waits for a
+	// graceful guest shutdown request to take effect before moving on to
+	// forcibly terminating the UVM.
+	UVMCloseGuestShutdown time.Duration = defaultUVMCloseStageTimeout
+
+	// UVMCloseTerminate bounds how long UtilityVM.Close waits for HCS to
+	// terminate the UVM. If exceeded, Close returns without advancing past
+	// this stage so a later retry does not re-run the (already requested)
+	// guest shutdown.
+	UVMCloseTerminate time.Duration = defaultUVMCloseStageTimeout
+
+	// UVMCloseResourceRelease bounds how long UtilityVM.Close waits for the
+	// UVM to be removed from its CPU group once the UVM itself has
+	// terminated. The GCS connection and output relay are closed without a
+	// deadline.
+	UVMCloseResourceRelease time.Duration = defaultUVMCloseStageTimeout
 )
 
 func init() {
@@ -51,11 +79,15 @@ func init() {
 	SystemStart = durationFromEnvironment("HCSSHIM_TIMEOUT_SYSTEMSTART", SystemStart)
 	SystemPause = durationFromEnvironment("HCSSHIM_TIMEOUT_SYSTEMPAUSE", SystemPause)
 	SystemResume = durationFromEnvironment("HCSSHIM_TIMEOUT_SYSTEMRESUME", SystemResume)
+	SystemSave = durationFromEnvironment("HCSSHIM_TIMEOUT_SYSTEMSAVE", SystemSave)
 	SyscallWatcher = durationFromEnvironment("HCSSHIM_TIMEOUT_SYSCALLWATCHER", SyscallWatcher)
 	Tar2VHD = durationFromEnvironment("HCSSHIM_TIMEOUT_TAR2VHD", Tar2VHD)
 	ExternalCommandToStart = durationFromEnvironment("HCSSHIM_TIMEOUT_EXTERNALCOMMANDSTART", ExternalCommandToStart)
 	ExternalCommandToComplete = durationFromEnvironment("HCSSHIM_TIMEOUT_EXTERNALCOMMANDCOMPLETE", ExternalCommandToComplete)
 	TestDRetryLoop = durationFromEnvironment("HCSSHIM_TIMEOUT_TESTDRETRYLOOP", TestDRetryLoop)
+	UVMCloseGuestShutdown = durationFromEnvironment("HCSSHIM_TIMEOUT_UVMCLOSEGUESTSHUTDOWN", UVMCloseGuestShutdown)
+	UVMCloseTerminate = durationFromEnvironment("HCSSHIM_TIMEOUT_UVMCLOSETERMINATE", UVMCloseTerminate)
+	UVMCloseResourceRelease = durationFromEnvironment("HCSSHIM_TIMEOUT_UVMCLOSERESOURCERELEASE", UVMCloseResourceRelease)
 }
 
 func durationFromEnvironment(env string, defaultValue time.Duration) time.Duration {
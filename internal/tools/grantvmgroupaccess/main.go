@@ -1,18 +1,36 @@
 package main
 
 import (
+	"context"
+	"flag"
 	"fmt"
 	"os"
 
-	"github.com/Microsoft/go-winio/pkg/security"
+	"github.com/Microsoft/hcsshim/internal/security"
 )
 
 func main() {
-	if len(os.Args) != 2 {
-		fmt.Fprintln(os.Stderr, "Usage: grantvmgroupaccess.exe file")
+	recursive := flag.Bool("recursive", false, "grant access to the given directory and everything beneath it")
+	flag.Parse()
+
+	paths := flag.Args()
+	if len(paths) == 0 {
+		fmt.Fprintln(os.Stderr, "Usage: grantvmgroupaccess.exe [-recursive] file...")
 		os.Exit(-1)
 	}
-	if err := security.GrantVmGroupAccess(os.Args[1]); err != nil {
+
+	ctx := context.Background()
+	var err error
+	if *recursive {
+		if len(paths) != 1 {
+			fmt.Fprintln(os.Stderr, "-recursive takes exactly one directory")
+			os.Exit(-1)
+		}
+		err = security.GrantRecursive(ctx, paths[0])
+	} else {
+		err = security.GrantAll(ctx, paths)
+	}
+	if err != nil {
 		fmt.Fprintln(os.Stderr, err)
 		os.Exit(-1)
 	}
@@ -155,6 +155,14 @@ func runLCOW(ctx context.Context, options *uvm.OptionsLCOW, c *cli.Context) erro
 		return err
 	}
 
+	if c.GlobalBool(networkArgName) {
+		cleanup, err := attachNetwork(ctx, uvm, c.GlobalString(networkNameArgName))
+		if err != nil {
+			return err
+		}
+		defer cleanup()
+	}
+
 	if options.UseGuestConnection {
 		if err := execViaGcs(uvm, c); err != nil {
 			return err
@@ -22,10 +22,16 @@ const (
 	debugArgName                = "debug"
 	gcsArgName                  = "gcs"
 	externalBridgeArgName       = "external-bridge"
+	networkArgName              = "network"
+	networkNameArgName          = "network-name"
 
 	execCommandLineArgName = "exec"
 )
 
+// defaultNATNetworkName is the name of the NAT network HNS creates
+// automatically when the Windows Containers feature is installed.
+const defaultNATNetworkName = "nat"
+
 func main() {
 	app := cli.NewApp()
 	app.Name = "uvmboot"
@@ -74,6 +80,15 @@ func main() {
 			Name:  externalBridgeArgName,
 			Usage: "Use the external implementation of the guest connection",
 		},
+		cli.BoolFlag{
+			Name:  networkArgName,
+			Usage: "Attach a network endpoint to the UVM",
+		},
+		cli.StringFlag{
+			Name:  networkNameArgName,
+			Value: defaultNATNetworkName,
+			Usage: "Name of the HNS network to attach the endpoint to",
+		},
 	}
 
 	app.Commands = []cli.Command{
@@ -0,0 +1,52 @@
+package main
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/Microsoft/go-winio/pkg/guid"
+	"github.com/Microsoft/hcsshim/internal/hns"
+	"github.com/Microsoft/hcsshim/internal/uvm"
+)
+
+// attachNetwork creates an HNS endpoint on the network named networkName
+// (which must already exist; the "nat" network is created automatically when
+// the Windows Containers feature is installed) and attaches it to vm as a
+// network namespace containing a single endpoint. The returned cleanup func
+// removes the namespace and deletes the endpoint, and should be called before
+// the uVM is torn down.
+func attachNetwork(ctx context.Context, vm *uvm.UtilityVM, networkName string) (func(), error) {
+	network, err := hns.GetHNSNetworkByName(networkName)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find HNS network %q: %s", networkName, err)
+	}
+
+	endpoint, err := network.NewEndpoint(nil, nil).Create()
+	if err != nil {
+		return nil, fmt.Errorf("failed to create HNS endpoint on network %q: %s", networkName, err)
+	}
+
+	nsID, err := guid.NewV4()
+	if err != nil {
+		endpoint.Delete()
+		return nil, err
+	}
+	nsIDString := nsID.String()
+
+	if err := vm.AddNetNS(ctx, nsIDString); err != nil {
+		endpoint.Delete()
+		return nil, fmt.Errorf("failed to add network namespace to uVM: %s", err)
+	}
+
+	endpoint.Namespace = &hns.Namespace{ID: nsIDString}
+	if err := vm.AddEndpointsToNS(ctx, nsIDString, []*hns.HNSEndpoint{endpoint}); err != nil {
+		vm.RemoveNetNS(ctx, nsIDString)
+		endpoint.Delete()
+		return nil, fmt.Errorf("failed to attach HNS endpoint to uVM: %s", err)
+	}
+
+	return func() {
+		vm.RemoveNetNS(ctx, nsIDString)
+		endpoint.Delete()
+	}, nil
+}
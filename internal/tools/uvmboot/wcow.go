@@ -48,6 +48,14 @@ var wcowCommand = cli.Command{
 			Usage:       "create the process in the UVM with a TTY enabled",
 			Destination: &wcowUseTerminal,
 		},
+		cli.BoolFlag{
+			Name:  forwardStdoutArgName,
+			Usage: "Whether stdout from the process in the UVM should be forwarded. Defaults to true",
+		},
+		cli.BoolFlag{
+			Name:  forwardStderrArgName,
+			Usage: "Whether stderr from the process in the UVM should be forwarded. Defaults to true",
+		},
 	},
 	Action: func(c *cli.Context) error {
 		runMany(c, func(id string) error {
@@ -84,6 +92,13 @@ var wcowCommand = cli.Command{
 			if err := vm.Start(context.TODO()); err != nil {
 				return err
 			}
+			if c.GlobalBool(networkArgName) {
+				cleanup, err := attachNetwork(context.TODO(), vm, c.GlobalString(networkNameArgName))
+				if err != nil {
+					return err
+				}
+				defer cleanup()
+			}
 			if wcowCommandLine != "" {
 				cmd := cmd.Command(vm, "cmd.exe", "/c", wcowCommandLine)
 				cmd.Spec.User.Username = `NT AUTHORITY\SYSTEM`
@@ -101,8 +116,12 @@ var wcowCommand = cli.Command{
 						defer con.Reset()
 					}
 				} else {
-					cmd.Stdout = os.Stdout
-					cmd.Stderr = os.Stdout
+					if !c.IsSet(forwardStdoutArgName) || c.Bool(forwardStdoutArgName) {
+						cmd.Stdout = os.Stdout
+					}
+					if !c.IsSet(forwardStderrArgName) || c.Bool(forwardStderrArgName) {
+						cmd.Stderr = os.Stdout
+					}
 				}
 				err = cmd.Run()
 				if err != nil {
@@ -0,0 +1,262 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/Microsoft/go-winio/pkg/guid"
+	"github.com/Microsoft/hcsshim/internal/hns"
+	"github.com/Microsoft/hcsshim/internal/uvm"
+	"github.com/sirupsen/logrus"
+	"github.com/urfave/cli"
+)
+
+const (
+	durationArgName    = "duration"
+	workersArgName     = "workers"
+	vsmbDirArgName     = "vsmb-dir"
+	plan9DirArgName    = "plan9-dir"
+	scsiVHDArgName     = "scsi-vhd"
+	networkNameArgName = "network-name"
+)
+
+func main() {
+	app := cli.NewApp()
+	app.Name = "uvmstress"
+	app.Usage = "Drive rapid add/remove cycles of uVM resources to shake out churn-related races"
+	app.Flags = []cli.Flag{
+		cli.DurationFlag{
+			Name:  durationArgName,
+			Value: time.Minute,
+			Usage: "How long to run the stress cycles for",
+		},
+		cli.IntFlag{
+			Name:  workersArgName,
+			Value: 4,
+			Usage: "Number of concurrent workers churning each resource type",
+		},
+		cli.StringFlag{
+			Name:  vsmbDirArgName,
+			Usage: "Directory to share via VSMB; one is created under the temp dir if unset",
+		},
+		cli.StringFlag{
+			Name:  plan9DirArgName,
+			Usage: "Directory to share via Plan9; one is created under the temp dir if unset",
+		},
+		cli.StringFlag{
+			Name:  scsiVHDArgName,
+			Usage: "Path to an existing VHD to repeatedly hot-add/remove over SCSI. SCSI churn is skipped if unset",
+		},
+		cli.StringFlag{
+			Name:  networkNameArgName,
+			Usage: "Name of an HNS network to churn endpoints on. NIC churn is skipped if unset",
+		},
+	}
+	app.Action = run
+
+	if err := app.Run(os.Args); err != nil {
+		logrus.Fatal(err)
+	}
+}
+
+// churner is one resource type's add/remove cycle. It runs until ctx is
+// done, recording every attempt and failure in counts so the final report
+// can show per-resource-type error rates.
+type churner struct {
+	name  string
+	cycle func(ctx context.Context, vm *uvm.UtilityVM, worker int) error
+
+	attempts uint64
+	failures uint64
+}
+
+func (c *churner) run(ctx context.Context, vm *uvm.UtilityVM, worker int, wg *sync.WaitGroup) {
+	defer wg.Done()
+	for ctx.Err() == nil {
+		atomic.AddUint64(&c.attempts, 1)
+		if err := c.cycle(ctx, vm, worker); err != nil {
+			atomic.AddUint64(&c.failures, 1)
+			logrus.WithFields(logrus.Fields{
+				"resource": c.name,
+				"worker":   worker,
+			}).WithError(err).Error("churn cycle failed")
+		}
+	}
+}
+
+func run(c *cli.Context) error {
+	ctx, cancel := context.WithTimeout(context.Background(), c.Duration(durationArgName))
+	defer cancel()
+
+	id := "uvmstress-" + time.Now().Format("150405")
+	options := uvm.NewDefaultOptionsLCOW(id, "")
+	vm, err := uvm.CreateLCOW(ctx, options)
+	if err != nil {
+		return fmt.Errorf("failed to create uVM: %s", err)
+	}
+	defer vm.Close()
+	if err := vm.Start(ctx); err != nil {
+		return fmt.Errorf("failed to start uVM: %s", err)
+	}
+
+	vsmbDir, err := resolveDir(c.String(vsmbDirArgName), "uvmstress-vsmb")
+	if err != nil {
+		return err
+	}
+	plan9Dir, err := resolveDir(c.String(plan9DirArgName), "uvmstress-plan9")
+	if err != nil {
+		return err
+	}
+
+	churners := []*churner{
+		{name: "vsmb", cycle: vsmbCycle(vsmbDir)},
+		{name: "plan9", cycle: plan9Cycle(plan9Dir)},
+	}
+	if vhd := c.String(scsiVHDArgName); vhd != "" {
+		churners = append(churners, &churner{name: "scsi", cycle: scsiCycle(vhd)})
+	} else {
+		logrus.Info("no -scsi-vhd given, skipping SCSI churn")
+	}
+	if net := c.String(networkNameArgName); net != "" {
+		churners = append(churners, &churner{name: "nic", cycle: nicCycle(net)})
+	} else {
+		logrus.Info("no -network-name given, skipping NIC churn")
+	}
+
+	workers := c.Int(workersArgName)
+	var wg sync.WaitGroup
+	for _, ch := range churners {
+		for w := 0; w < workers; w++ {
+			wg.Add(1)
+			go ch.run(ctx, vm, w, &wg)
+		}
+	}
+	wg.Wait()
+
+	var failed bool
+	for _, ch := range churners {
+		logrus.WithFields(logrus.Fields{
+			"resource": ch.name,
+			"attempts": ch.attempts,
+			"failures": ch.failures,
+		}).Info("churn summary")
+		if ch.failures > 0 {
+			failed = true
+		}
+	}
+	if failed {
+		return fmt.Errorf("one or more resource types saw churn failures, see log for details")
+	}
+	return nil
+}
+
+// resolveDir returns dir if set, otherwise creates and returns a fresh
+// temporary directory named from prefix.
+func resolveDir(dir, prefix string) (string, error) {
+	if dir != "" {
+		return dir, nil
+	}
+	return os.MkdirTemp("", prefix)
+}
+
+// vsmbCycle adds and removes a VSMB share of a per-worker subdirectory of
+// baseDir, verifying the share is actually gone afterwards.
+func vsmbCycle(baseDir string) func(context.Context, *uvm.UtilityVM, int) error {
+	return func(ctx context.Context, vm *uvm.UtilityVM, worker int) error {
+		dir := filepath.Join(baseDir, fmt.Sprintf("w%d", worker))
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			return err
+		}
+		opts := vm.DefaultVSMBOptions(false)
+		if _, err := vm.AddVSMB(ctx, dir, opts); err != nil {
+			return fmt.Errorf("AddVSMB: %s", err)
+		}
+		if err := vm.RemoveVSMB(ctx, dir, false); err != nil {
+			return fmt.Errorf("RemoveVSMB: %s", err)
+		}
+		if _, err := vm.GetVSMBUvmPath(ctx, dir, false); err != uvm.ErrNotAttached {
+			return fmt.Errorf("VSMB share for %s still attached after Remove", dir)
+		}
+		return nil
+	}
+}
+
+// plan9Cycle adds and removes a Plan9 share of a per-worker subdirectory of
+// baseDir.
+func plan9Cycle(baseDir string) func(context.Context, *uvm.UtilityVM, int) error {
+	return func(ctx context.Context, vm *uvm.UtilityVM, worker int) error {
+		dir := filepath.Join(baseDir, fmt.Sprintf("w%d", worker))
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			return err
+		}
+		share, err := vm.AddPlan9(ctx, dir, fmt.Sprintf("/stress/%d", worker), false, false, nil)
+		if err != nil {
+			return fmt.Errorf("AddPlan9: %s", err)
+		}
+		if err := vm.RemovePlan9(ctx, share); err != nil {
+			return fmt.Errorf("RemovePlan9: %s", err)
+		}
+		return nil
+	}
+}
+
+// scsiCycle hot-adds and removes vhd at a distinct guest path per worker.
+// Since a VHD can only be attached at one SCSI location at a time, vhd
+// itself must be a scratch copy a caller is fine with churning -- the
+// harness does not make per-worker copies.
+func scsiCycle(vhd string) func(context.Context, *uvm.UtilityVM, int) error {
+	return func(ctx context.Context, vm *uvm.UtilityVM, worker int) error {
+		uvmPath := fmt.Sprintf("/stress/scsi%d", worker)
+		if _, err := vm.AddSCSI(ctx, vhd, uvmPath, false, uvm.VMAccessTypeIndividual); err != nil {
+			return fmt.Errorf("AddSCSI: %s", err)
+		}
+		if err := vm.RemoveSCSI(ctx, vhd); err != nil {
+			return fmt.Errorf("RemoveSCSI: %s", err)
+		}
+		return nil
+	}
+}
+
+// nicCycle creates a fresh HNS endpoint on networkName, attaches it to the
+// uVM in its own namespace, then tears both down.
+func nicCycle(networkName string) func(context.Context, *uvm.UtilityVM, int) error {
+	return func(ctx context.Context, vm *uvm.UtilityVM, worker int) error {
+		network, err := hns.GetHNSNetworkByName(networkName)
+		if err != nil {
+			return fmt.Errorf("GetHNSNetworkByName: %s", err)
+		}
+		endpoint, err := network.NewEndpoint(nil, nil).Create()
+		if err != nil {
+			return fmt.Errorf("create HNS endpoint: %s", err)
+		}
+		nsID, err := guid.NewV4()
+		if err != nil {
+			endpoint.Delete()
+			return err
+		}
+		nsIDString := nsID.String()
+		if err := vm.AddNetNS(ctx, nsIDString); err != nil {
+			endpoint.Delete()
+			return fmt.Errorf("AddNetNS: %s", err)
+		}
+		endpoint.Namespace = &hns.Namespace{ID: nsIDString}
+		if err := vm.AddEndpointsToNS(ctx, nsIDString, []*hns.HNSEndpoint{endpoint}); err != nil {
+			vm.RemoveNetNS(ctx, nsIDString)
+			endpoint.Delete()
+			return fmt.Errorf("AddEndpointsToNS: %s", err)
+		}
+		if err := vm.RemoveNetNS(ctx, nsIDString); err != nil {
+			endpoint.Delete()
+			return fmt.Errorf("RemoveNetNS: %s", err)
+		}
+		if _, err := endpoint.Delete(); err != nil {
+			return fmt.Errorf("delete HNS endpoint: %s", err)
+		}
+		return nil
+	}
+}
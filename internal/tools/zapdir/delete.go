@@ -0,0 +1,75 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"github.com/Microsoft/hcsshim/internal/safefile"
+)
+
+// zapDir deletes the directory tree rooted at dir as a plain directory tree,
+// not a WCIFS layer -- see the zapdir tool's "--raw" flag. It does not call
+// DestroyLayer and so skips whatever layer-specific teardown the filter
+// driver does on top of removing files; callers deleting an actual layer
+// must use wclayer.DestroyLayer instead.
+//
+// The top-level entries of dir are removed concurrently by up to parallelism
+// workers, which matters for directories containing many sibling
+// files/subdirectories. Each entry is removed with
+// safefile.RemoveAllRelative, so long paths, reparse points, and files with
+// the readonly attribute set are all handled correctly.
+func zapDir(dir string, parallelism int) error {
+	root, err := safefile.OpenRoot(dir)
+	if err != nil {
+		return err
+	}
+
+	f, err := os.Open(dir)
+	if err != nil {
+		root.Close()
+		return err
+	}
+	names, err := f.Readdirnames(-1)
+	f.Close()
+	if err != nil {
+		root.Close()
+		return err
+	}
+
+	if parallelism < 1 {
+		parallelism = 1
+	}
+
+	var wg sync.WaitGroup
+	sem := make(chan struct{}, parallelism)
+	errs := make(chan error, len(names))
+	for _, name := range names {
+		wg.Add(1)
+		sem <- struct{}{}
+		go func(name string) {
+			defer wg.Done()
+			defer func() { <-sem }()
+			if err := safefile.RemoveAllRelative(name, root); err != nil {
+				errs <- fmt.Errorf("removing %s: %s", filepath.Join(dir, name), err)
+			}
+		}(name)
+	}
+	wg.Wait()
+	close(errs)
+	root.Close()
+
+	var firstErr error
+	for err := range errs {
+		fmt.Fprintln(os.Stderr, err)
+		if firstErr == nil {
+			firstErr = err
+		}
+	}
+	if firstErr != nil {
+		return firstErr
+	}
+
+	return os.Remove(dir)
+}
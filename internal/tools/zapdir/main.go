@@ -1,18 +1,21 @@
 package main
 
 import (
-	"context"
+	gcontext "context"
 	"errors"
 	"fmt"
 	"os"
 	"path/filepath"
+	"runtime"
 
 	"github.com/Microsoft/hcsshim/internal/wclayer"
 	"github.com/urfave/cli"
 )
 
 const (
-	dirArgName = "dir"
+	dirArgName      = "dir"
+	parallelArgName = "parallel"
+	rawArgName      = "raw"
 )
 
 func main() {
@@ -26,6 +29,15 @@ func main() {
 			Value: "",
 			Usage: "Directory to delete",
 		},
+		cli.BoolFlag{
+			Name:  rawArgName,
+			Usage: "Delete dir as a plain directory tree with a parallel worker pool instead of as a layer; do not pass this for a directory that is, or was, an active WCIFS layer",
+		},
+		cli.IntFlag{
+			Name:  parallelArgName,
+			Value: runtime.NumCPU(),
+			Usage: "With --raw, number of workers used to delete the directory's top-level entries concurrently",
+		},
 	}
 
 	app.Action = func(c *cli.Context) error {
@@ -45,11 +57,11 @@ func main() {
 			return err
 		}
 
-		if err := wclayer.DestroyLayer(context.Background(), dir); err != nil {
-			return err
+		if c.Bool(rawArgName) {
+			return zapDir(dir, c.Int(parallelArgName))
 		}
 
-		return nil
+		return wclayer.DestroyLayer(gcontext.Background(), dir)
 	}
 
 	err := app.Run(os.Args)
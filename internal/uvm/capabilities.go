@@ -1,6 +1,55 @@
 package uvm
 
-import "github.com/Microsoft/hcsshim/internal/schema1"
+import (
+	"fmt"
+
+	"github.com/Microsoft/hcsshim/internal/schema1"
+)
+
+// Feature names a guest capability that hcsshim may require before it
+// creates a container in a uVM. Each corresponds to one of the booleans in
+// schema1.GuestDefinedCapabilities, or the GCS protocol version itself.
+type Feature string
+
+// Features recognized by RequireFeatures.
+const (
+	FeatureSignalProcess        Feature = "signal-process"
+	FeatureDeleteContainerState Feature = "delete-container-state"
+)
+
+func (uvm *UtilityVM) supportsFeature(f Feature) (bool, error) {
+	switch f {
+	case FeatureSignalProcess:
+		return uvm.SignalProcessSupported(), nil
+	case FeatureDeleteContainerState:
+		return uvm.DeleteContainerStateSupported(), nil
+	default:
+		return false, fmt.Errorf("unknown guest feature %q", f)
+	}
+}
+
+// RequireFeatures validates that the connected GCS supports every feature in
+// `features`, returning a single error naming every unsupported one. This
+// lets a caller fail fast with a clear "guest rootfs too old" message before
+// creating a container, rather than discovering the gap only when the RPC
+// that depends on the feature fails or hangs waiting on a guest that will
+// never answer it.
+func (uvm *UtilityVM) RequireFeatures(features ...Feature) error {
+	var unsupported []Feature
+	for _, f := range features {
+		ok, err := uvm.supportsFeature(f)
+		if err != nil {
+			return err
+		}
+		if !ok {
+			unsupported = append(unsupported, f)
+		}
+	}
+	if len(unsupported) > 0 {
+		return fmt.Errorf("guest rootfs does not support required feature(s) %v (GCS protocol version %d); it is likely too old", unsupported, uvm.protocol)
+	}
+	return nil
+}
 
 // SignalProcessSupported returns `true` if the guest supports the capability to
 // signal a process.
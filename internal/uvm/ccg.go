@@ -0,0 +1,91 @@
+package uvm
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/Microsoft/hcsshim/internal/credentials"
+	hcsschema "github.com/Microsoft/hcsshim/internal/schema2"
+)
+
+// CCGInstance is a Container Credential Guard instance shared by every
+// container in this uVM that requested the same credential spec. It is
+// ref-counted the same way a VSMBShare or Plan9Share is: the underlying CCG
+// instance is only actually torn down once the last container using it
+// releases it.
+//
+// Pooling matters here because creating a CCG instance costs a
+// ModifyServiceSettings round trip plus a GetServiceProperties round trip to
+// HCS, which together can take multiple seconds; containers in the same pod
+// that share a GMSA credential spec would otherwise each pay that cost.
+type CCGInstance struct {
+	vm       *UtilityVM
+	credSpec string
+	instance *hcsschema.ContainerCredentialGuardInstance
+	resource *credentials.CCGResource
+	refCount uint32
+}
+
+// String returns a description of the credential guard instance for diagnostics.
+func (ccg *CCGInstance) String() string {
+	return fmt.Sprintf("credential guard instance for uvm %s", ccg.vm.id)
+}
+
+// CredentialGuardState returns the connection information a container's HCS
+// document needs to reach this CCG instance.
+func (ccg *CCGInstance) CredentialGuardState() *hcsschema.ContainerCredentialGuardState {
+	return ccg.instance.CredentialGuard
+}
+
+// HvSocketConfig returns the HvSocket service table entry that must be
+// present in the uVM's document for a container to reach this CCG instance.
+func (ccg *CCGInstance) HvSocketConfig() *hcsschema.ContainerCredentialGuardHvSocketServiceConfig {
+	return ccg.instance.HvSocketConfig
+}
+
+// Release decrements the instance's ref count, only actually removing the CCG
+// instance from HCS once the last container using it has released it.
+func (ccg *CCGInstance) Release(ctx context.Context) error {
+	return ccg.vm.releaseCCGInstance(ctx, ccg)
+}
+
+// AddCCGInstance returns the CCG instance for `credSpec` in this uVM, creating
+// one through HCS and registering it under `id` if this is the first
+// container in the uVM to request it. Each call (including ones that reuse an
+// existing instance) must be matched with a call to the returned instance's
+// Release.
+func (uvm *UtilityVM) AddCCGInstance(ctx context.Context, id, credSpec string) (*CCGInstance, error) {
+	uvm.m.Lock()
+	defer uvm.m.Unlock()
+
+	if ccg, ok := uvm.ccgInstances[credSpec]; ok {
+		ccg.refCount++
+		return ccg, nil
+	}
+
+	instance, resource, err := credentials.CreateCredentialGuard(ctx, id, credSpec, true)
+	if err != nil {
+		return nil, err
+	}
+	ccg := &CCGInstance{
+		vm:       uvm,
+		credSpec: credSpec,
+		instance: instance,
+		resource: resource,
+		refCount: 1,
+	}
+	uvm.ccgInstances[credSpec] = ccg
+	return ccg, nil
+}
+
+func (uvm *UtilityVM) releaseCCGInstance(ctx context.Context, ccg *CCGInstance) error {
+	uvm.m.Lock()
+	defer uvm.m.Unlock()
+
+	ccg.refCount--
+	if ccg.refCount > 0 {
+		return nil
+	}
+	delete(uvm.ccgInstances, ccg.credSpec)
+	return ccg.resource.Release(ctx)
+}
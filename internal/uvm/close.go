@@ -0,0 +1,180 @@
+package uvm
+
+import (
+	"context"
+	"sync/atomic"
+	"time"
+
+	"github.com/Microsoft/hcsshim/internal/log"
+	"github.com/Microsoft/hcsshim/internal/logfields"
+	"github.com/Microsoft/hcsshim/internal/oc"
+	"github.com/Microsoft/hcsshim/internal/timeout"
+	"go.opencensus.io/trace"
+	"golang.org/x/sys/windows"
+)
+
+// closeStage tracks how far UtilityVM.Close has gotten through its staged
+// teardown. Stages only ever move forward; a stage is only marked done once
+// it (or, for the best-effort guest shutdown stage, its deadline) has
+// actually completed.
+type closeStage int32
+
+const (
+	closeStageNotStarted closeStage = iota
+	// closeStageGuestShutdownRequested means a graceful shutdown was
+	// requested of the guest (or skipped, for a UVM that never started
+	// HCS). It is not a guarantee the guest actually shut down -- Close
+	// moves on after UVMCloseGuestShutdown regardless, since forcibly
+	// terminating is always the fallback.
+	closeStageGuestShutdownRequested
+	// closeStageTerminated means HCS has confirmed the UVM's compute system
+	// exited.
+	closeStageTerminated
+	// closeStageResourcesReleased means every host-side resource tied to
+	// the UVM (CPU group membership, GCS connection, output relay, the
+	// compute system handle itself) has been released. Close is a no-op
+	// once a prior call reached this stage.
+	closeStageResourcesReleased
+)
+
+// Close tears down the utility VM in three bounded stages -- request a
+// graceful guest shutdown, terminate the compute system, then release the
+// remaining host-side resources -- recording progress in uvm.closeStage as
+// it goes. Each stage is bounded by one of the timeout.UVMClose* variables.
+// If the terminate stage fails or its deadline passes before HCS confirms
+// the exit, Close returns an error without advancing past it, leaving the
+// UVM only partially torn down; the guest shutdown stage and the CPU group
+// release instead log and move on when their deadline passes.
+//
+// This makes Close resumable: since finished stages are skipped rather than
+// repeated, a caller that holds onto a UVM after a timed-out Close (for
+// example a periodic pass over UVMs a crashed or hung shim left behind) can
+// simply call Close again, and it will pick up at the stage that hung
+// rather than re-requesting a guest shutdown that may have already landed.
+func (uvm *UtilityVM) Close() (err error) {
+	ctx, span := trace.StartSpan(context.Background(), "uvm::Close")
+	defer span.End()
+	defer func() { oc.SetSpanStatus(span, err) }()
+	span.AddAttributes(
+		trace.StringAttribute(logfields.UVMID, uvm.id),
+		trace.Int64Attribute("closeStage", int64(atomic.LoadInt32((*int32)(&uvm.closeStage)))))
+
+	if uvm.closeStageL() < closeStageGuestShutdownRequested {
+		uvm.requestGuestShutdown(ctx)
+		uvm.setCloseStageL(closeStageGuestShutdownRequested)
+	}
+
+	if uvm.closeStageL() < closeStageTerminated {
+		if err := uvm.terminateAndWait(ctx); err != nil {
+			return err
+		}
+		uvm.setCloseStageL(closeStageTerminated)
+	}
+
+	if uvm.closeStageL() < closeStageResourcesReleased {
+		if err := uvm.releaseResources(ctx); err != nil {
+			return err
+		}
+		uvm.setCloseStageL(closeStageResourcesReleased)
+	}
+	return nil
+}
+
+func (uvm *UtilityVM) closeStageL() closeStage {
+	return closeStage(atomic.LoadInt32((*int32)(&uvm.closeStage)))
+}
+
+func (uvm *UtilityVM) setCloseStageL(s closeStage) {
+	atomic.StoreInt32((*int32)(&uvm.closeStage), int32(s))
+}
+
+// requestGuestShutdown asks the guest to shut down and waits up to
+// timeout.UVMCloseGuestShutdown for it to do so. It never returns an error:
+// a guest that doesn't respond in time is handled by the forcible terminate
+// that always follows, and a UVM that never reached HCS has nothing to ask.
+func (uvm *UtilityVM) requestGuestShutdown(ctx context.Context) {
+	if uvm.hcsSystem == nil {
+		return
+	}
+	sctx, cancel := context.WithTimeout(ctx, timeout.UVMCloseGuestShutdown)
+	defer cancel()
+	if err := uvm.hcsSystem.Shutdown(sctx); err != nil {
+		log.G(ctx).WithError(err).Debug("guest shutdown request failed, will terminate")
+		return
+	}
+	if _, timedOut := uvm.waitWithDeadline(timeout.UVMCloseGuestShutdown); timedOut {
+		log.G(ctx).Debug("guest did not shut down before deadline, will terminate")
+	}
+}
+
+// terminateAndWait forcibly terminates the UVM's compute system and waits
+// up to timeout.UVMCloseTerminate for HCS to confirm it exited.
+func (uvm *UtilityVM) terminateAndWait(ctx context.Context) error {
+	if uvm.hcsSystem == nil {
+		return nil
+	}
+	tctx, cancel := context.WithTimeout(ctx, timeout.UVMCloseTerminate)
+	defer cancel()
+	if err := uvm.hcsSystem.Terminate(tctx); err != nil {
+		return err
+	}
+	if err, timedOut := uvm.waitWithDeadline(timeout.UVMCloseTerminate); timedOut {
+		return tctx.Err()
+	} else if err != nil {
+		// A non-nil Wait error just describes how the UVM exited (e.g.
+		// unexpected exit); termination itself still succeeded.
+		log.G(ctx).WithError(err).Debug("uvm exited with error after terminate")
+	}
+	return nil
+}
+
+// releaseResources releases every host-side resource still associated with
+// the UVM now that its compute system has exited.
+func (uvm *UtilityVM) releaseResources(ctx context.Context) error {
+	windows.Close(uvm.vmmemProcess)
+
+	if uvm.hcsSystem != nil {
+		rctx, cancel := context.WithTimeout(ctx, timeout.UVMCloseResourceRelease)
+		defer cancel()
+		if err := uvm.ReleaseCPUGroup(rctx); err != nil {
+			log.G(ctx).WithError(err).Warn("failed to release VM resource")
+		}
+	}
+
+	if uvm.gc != nil {
+		uvm.gc.Close()
+	}
+	if uvm.gcListener != nil {
+		uvm.gcListener.Close()
+	}
+
+	// outputListener will only be nil for a Create -> Stop without a Start. In
+	// this case we have no goroutine processing output so its safe to close the
+	// channel here.
+	if uvm.outputListener != nil {
+		close(uvm.outputProcessingDone)
+		uvm.outputListener.Close()
+		uvm.outputListener = nil
+	}
+	if uvm.hcsSystem != nil {
+		return uvm.hcsSystem.Close()
+	}
+	return nil
+}
+
+// waitWithDeadline waits for the UVM to exit, as UtilityVM.Wait does, but
+// gives up after d instead of blocking indefinitely. timedOut is true if d
+// elapsed first, in which case err is always nil (the wait is still running
+// in the background; Wait itself remains safe to call again later).
+func (uvm *UtilityVM) waitWithDeadline(d time.Duration) (err error, timedOut bool) {
+	done := make(chan error, 1)
+	go func() {
+		done <- uvm.Wait()
+	}()
+	select {
+	case err := <-done:
+		return err, false
+	case <-time.After(d):
+		return nil, true
+	}
+}
@@ -32,12 +32,9 @@ func (uvm *UtilityVM) SetCPUGroup(ctx context.Context, id string) error {
 
 // setCPUGroup sets the VM's cpugroup
 func (uvm *UtilityVM) setCPUGroup(ctx context.Context, id string) error {
-	req := &hcsschema.ModifySettingRequest{
-		ResourcePath: cpuGroupResourcePath,
-		Settings: &hcsschema.CpuGroup{
-			Id: id,
-		},
-	}
+	req := newModifyRequest(cpuGroupResourcePath, "", &hcsschema.CpuGroup{
+		Id: id,
+	})
 	if err := uvm.modify(ctx, req); err != nil {
 		return err
 	}
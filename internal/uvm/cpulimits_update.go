@@ -8,10 +8,7 @@ import (
 
 // UpdateCPULimits updates the CPU limits of the utility vm
 func (uvm *UtilityVM) UpdateCPULimits(ctx context.Context, limits *hcsschema.ProcessorLimits) error {
-	req := &hcsschema.ModifySettingRequest{
-		ResourcePath: cpuLimitsResourcePath,
-		Settings:     limits,
-	}
+	req := newModifyRequest(cpuLimitsResourcePath, "", limits)
 
 	return uvm.modify(ctx, req)
 }
@@ -4,21 +4,20 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"net"
 	"os"
 	"path/filepath"
 	"runtime"
 
+	"github.com/Microsoft/go-winio/pkg/guid"
 	"github.com/Microsoft/hcsshim/internal/cow"
 	"github.com/Microsoft/hcsshim/internal/hcs"
 	"github.com/Microsoft/hcsshim/internal/log"
 	"github.com/Microsoft/hcsshim/internal/logfields"
-	"github.com/Microsoft/hcsshim/internal/oc"
 	hcsschema "github.com/Microsoft/hcsshim/internal/schema2"
 	"github.com/Microsoft/hcsshim/internal/schemaversion"
 	"github.com/Microsoft/hcsshim/osversion"
 	"github.com/sirupsen/logrus"
-	"go.opencensus.io/trace"
-	"golang.org/x/sys/windows"
 )
 
 // Options are the set of options passed to Create() to create a utility vm.
@@ -27,6 +26,11 @@ type Options struct {
 	Owner                   string // Specifies the owner. Defaults to executable name.
 	AdditionHCSDocumentJSON string // Optional additional JSON to merge into the HCS document prior
 
+	// RestoreStateFilePath is the path to a file previously written by
+	// UtilityVM.Save. If set, the uVM is restored from that saved state
+	// instead of booting fresh.
+	RestoreStateFilePath string
+
 	// MemorySizeInMB sets the UVM memory. If `0` will default to platform
 	// default.
 	MemorySizeInMB uint64
@@ -35,6 +39,15 @@ type Options struct {
 	HighMMIOBaseInMB uint64
 	HighMMIOGapInMB  uint64
 
+	// VirtualNodeCount sets the number of virtual NUMA nodes to expose to a
+	// large, memory-bound uVM. If `0` (the default) HCS picks its own
+	// topology, which today means a single virtual node regardless of how
+	// many are backing the host -- fine for most uVMs, but it can leave a
+	// memory-bound workload without the NUMA locality it would see on bare
+	// metal. This only configures the guest-visible virtual topology; HCS
+	// picks which physical nodes back each virtual node.
+	VirtualNodeCount uint32
+
 	// Memory for UVM. Defaults to true. For physical backed memory, set to
 	// false.
 	AllowOvercommit bool
@@ -59,6 +72,16 @@ type Options struct {
 	// when scheduling. If `0` will default to platform default.
 	ProcessorWeight int32
 
+	// ProcessorReservation sets the minimum amount of host CPU time, in
+	// thousandths of a percent (0-100000, 100000 being 100%), that the UVM's vCPU's are
+	// guaranteed to get. If `0` (the default) no reservation is made. HCS has
+	// no dedicated "disable guest idle" switch; a latency-critical uVM that
+	// wants to avoid vCPU wake latency should set this instead, since a
+	// reserved vCPU is less likely to be descheduled to idle between bursts
+	// of work. Applied after boot via UpdateCPULimits, since the create-time
+	// processor topology schema has no reservation field.
+	ProcessorReservation uint64
+
 	// StorageQoSIopsMaximum sets the maximum number of Iops. If `0` will
 	// default to the platform default.
 	StorageQoSIopsMaximum int32
@@ -71,6 +94,16 @@ type Options struct {
 	// internally by the OS platform or externally by this package.
 	ExternalGuestConnection bool
 
+	// VSockListenFn, if set, is used instead of an AF_HYPERV (hvsocket)
+	// listener to accept the GCS bridge and stdio connections this package
+	// makes to the guest, keyed by hvsocket service ID (see
+	// UtilityVM.listenVsock). This lets a caller substitute a different
+	// transport to the same guest-side service IDs -- for example a TCP
+	// listener reachable from gcstools running outside a Hyper-V host during
+	// development, or for CI that exercises this package's guest protocol
+	// handling without one. Defaults to winio.ListenHvsock.
+	VSockListenFn func(serviceID guid.GUID) (net.Listener, error)
+
 	// DisableCompartmentNamespace sets whether to disable namespacing the network compartment in the UVM
 	// for WCOW. Namespacing makes it so the compartment created for a container is essentially no longer
 	// aware or able to see any of the other compartments on the host (in this case the UVM).
@@ -85,6 +118,27 @@ type Options struct {
 	// CPUGroupID set the ID of a CPUGroup on the host that the UVM should be added to on start.
 	// Defaults to an empty string which indicates the UVM should not be added to any CPUGroup.
 	CPUGroupID string
+
+	// SCSIAllocationPolicy controls how AddSCSI and AddSCSIPhysicalDisk pick
+	// the next free SCSI location for a new attachment. Defaults to
+	// SCSIAllocationPolicyPack.
+	SCSIAllocationPolicy SCSIAllocationPolicy
+
+	// SCSIReservedSlotCount holds back this many SCSI locations from regular
+	// allocation, only handing them out once every other location is in
+	// use. This gives attachments added later in the uVM's life (e.g. a
+	// volume hot-added to a long-running pod) a much better chance of
+	// finding a free slot, instead of a storage-heavy workload discovering
+	// mid-run that up-front layer mounts already used every one. Defaults
+	// to 0 (no reservation).
+	SCSIReservedSlotCount uint32
+
+	// FirewallRules, if set, is pushed into the uVM once it starts via
+	// UtilityVM.ApplyFirewallRules: guest WFP filters for WCOW, nftables
+	// rules for LCOW. Gives hypervisor-isolated pods a defense-in-depth
+	// layer beyond the HNS ACLs already applied to their external switch
+	// port. Defaults to nil (no rules pushed).
+	FirewallRules []string
 }
 
 // Verifies that the final UVM options are correct and supported.
@@ -97,6 +151,9 @@ func verifyOptions(ctx context.Context, options interface{}) error {
 		if opts.SCSIControllerCount > 1 {
 			return errors.New("SCSI controller count must be 0 or 1") // Future extension here for up to 4
 		}
+		if opts.SCSIReservedSlotCount > opts.SCSIControllerCount*scsiSlotsPerController {
+			return errors.New("SCSIReservedSlotCount cannot be greater than the number of SCSI locations")
+		}
 		if opts.VPMemDeviceCount > MaxVPMEMCount {
 			return fmt.Errorf("VPMem device count cannot be greater than %d", MaxVPMEMCount)
 		}
@@ -123,6 +180,9 @@ func verifyOptions(ctx context.Context, options interface{}) error {
 		if len(opts.LayerFolders) < 2 {
 			return errors.New("at least 2 LayerFolders must be supplied")
 		}
+		if opts.SCSIReservedSlotCount > scsiSlotsPerController {
+			return errors.New("SCSIReservedSlotCount cannot be greater than the number of SCSI locations")
+		}
 	}
 	return nil
 }
@@ -142,6 +202,7 @@ func newDefaultOptions(id, owner string) *Options {
 		ProcessorCount:          defaultProcessorCount(),
 		ExternalGuestConnection: true,
 		FullyPhysicallyBacked:   false,
+		SCSIAllocationPolicy:    SCSIAllocationPolicyPack,
 	}
 
 	if opts.Owner == "" {
@@ -190,43 +251,6 @@ func (uvm *UtilityVM) create(ctx context.Context, doc interface{}) error {
 	return nil
 }
 
-// Close terminates and releases resources associated with the utility VM.
-func (uvm *UtilityVM) Close() (err error) {
-	ctx, span := trace.StartSpan(context.Background(), "uvm::Close")
-	defer span.End()
-	defer func() { oc.SetSpanStatus(span, err) }()
-	span.AddAttributes(trace.StringAttribute(logfields.UVMID, uvm.id))
-
-	windows.Close(uvm.vmmemProcess)
-
-	if uvm.hcsSystem != nil {
-		if err := uvm.ReleaseCPUGroup(ctx); err != nil {
-			log.G(ctx).WithError(err).Warn("failed to release VM resource")
-		}
-		uvm.hcsSystem.Terminate(ctx)
-		uvm.Wait()
-	}
-	if uvm.gc != nil {
-		uvm.gc.Close()
-	}
-	if uvm.gcListener != nil {
-		uvm.gcListener.Close()
-	}
-
-	// outputListener will only be nil for a Create -> Stop without a Start. In
-	// this case we have no goroutine processing output so its safe to close the
-	// channel here.
-	if uvm.outputListener != nil {
-		close(uvm.outputProcessingDone)
-		uvm.outputListener.Close()
-		uvm.outputListener = nil
-	}
-	if uvm.hcsSystem != nil {
-		return uvm.hcsSystem.Close()
-	}
-	return nil
-}
-
 // CreateContainer creates a container in the utility VM.
 func (uvm *UtilityVM) CreateContainer(ctx context.Context, id string, settings interface{}) (cow.Container, error) {
 	if uvm.gc != nil {
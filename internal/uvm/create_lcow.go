@@ -7,6 +7,7 @@ import (
 	"net"
 	"os"
 	"path/filepath"
+	"runtime"
 	"strings"
 
 	"github.com/Microsoft/go-winio"
@@ -71,18 +72,46 @@ type OptionsLCOW struct {
 	PreferredRootFSType   PreferredRootFSType // If `KernelFile` is `InitrdFile` use `PreferredRootFSTypeInitRd`. If `KernelFile` is `VhdFile` use `PreferredRootFSTypeVHD`
 	EnableColdDiscardHint bool                // Whether the HCS should use cold discard hints. Defaults to false
 	VPCIEnabled           bool                // Whether the kernel should enable pci
+
+	// Plan9UseShareRootIdentity configures every Plan9 share added to this
+	// uVM to have the GCS perform I/O using the identity of the share's
+	// root, instead of the (unioned, per-file) identity of whatever process
+	// is accessing it. This lets the GCS skip a permission check against the
+	// guest's in-memory view of the share's ACLs on every 9p request, which
+	// matters for bind mounts of many small files. It is safe only when the
+	// share's contents are already readable/writable by anyone who can reach
+	// the mount, since per-file access control inside the guest is bypassed.
+	Plan9UseShareRootIdentity bool
 }
 
 // defaultLCOWOSBootFilesPath returns the default path used to locate the LCOW
 // OS kernel and root FS files. This default is the subdirectory
 // `LinuxBootFiles` in the directory of the executable that started the current
 // process; or, if it does not exist, `%ProgramFiles%\Linux Containers`.
+//
+// On a non-amd64 host (e.g. arm64) a `GOARCH`-named subdirectory of that
+// location (e.g. `LinuxBootFiles\arm64`) is preferred if present, so a single
+// install can carry boot files for more than one guest architecture side by
+// side. This only resolves which LCOW kernel/initrd to hand to HCS; it does
+// not by itself make hcsshim buildable as a host binary on that architecture,
+// which also depends on OS-layer dependencies such as golang.org/x/sys/windows
+// having arm64 support.
 func defaultLCOWOSBootFilesPath() string {
 	localDirPath := filepath.Join(filepath.Dir(os.Args[0]), "LinuxBootFiles")
-	if _, err := os.Stat(localDirPath); err == nil {
-		return localDirPath
+	if _, err := os.Stat(localDirPath); err != nil {
+		localDirPath = filepath.Join(os.Getenv("ProgramFiles"), "Linux Containers")
+	}
+	if runtime.GOARCH != "amd64" {
+		if archDirPath := filepath.Join(localDirPath, runtime.GOARCH); dirExists(archDirPath) {
+			return archDirPath
+		}
 	}
-	return filepath.Join(os.Getenv("ProgramFiles"), "Linux Containers")
+	return localDirPath
+}
+
+func dirExists(path string) bool {
+	fi, err := os.Stat(path)
+	return err == nil && fi.IsDir()
 }
 
 // NewDefaultOptionsLCOW creates the default options for a bootable version of
@@ -158,16 +187,26 @@ func CreateLCOW(ctx context.Context, opts *OptionsLCOW) (_ *UtilityVM, err error
 	}
 
 	uvm := &UtilityVM{
-		id:                      opts.ID,
-		owner:                   opts.Owner,
-		operatingSystem:         "linux",
-		scsiControllerCount:     opts.SCSIControllerCount,
-		vpmemMaxCount:           opts.VPMemDeviceCount,
-		vpmemMaxSizeBytes:       opts.VPMemSizeBytes,
-		vpciDevices:             make(map[string]*VPCIDevice),
-		physicallyBacked:        !opts.AllowOvercommit,
-		devicesPhysicallyBacked: opts.FullyPhysicallyBacked,
-		cpuGroupID:              opts.CPUGroupID,
+		id:                        opts.ID,
+		owner:                     opts.Owner,
+		operatingSystem:           "linux",
+		scsiControllerCount:       opts.SCSIControllerCount,
+		scsiAllocationPolicy:      opts.SCSIAllocationPolicy,
+		scsiReservedSlotCount:     opts.SCSIReservedSlotCount,
+		firewallRules:             opts.FirewallRules,
+		vpmemMaxCount:             opts.VPMemDeviceCount,
+		vpmemMaxSizeBytes:         opts.VPMemSizeBytes,
+		vpciDevices:               make(map[string]*VPCIDevice),
+		plan9Shares:               make(map[string]*Plan9Share),
+		plan9UseShareRootIdentity: opts.Plan9UseShareRootIdentity,
+		physicallyBacked:          !opts.AllowOvercommit,
+		devicesPhysicallyBacked:   opts.FullyPhysicallyBacked,
+		cpuGroupID:                opts.CPUGroupID,
+		processorReservation:      opts.ProcessorReservation,
+		vsockListenFn:             opts.VSockListenFn,
+	}
+	if uvm.vsockListenFn == nil {
+		uvm.vsockListenFn = uvm.listenHvsock
 	}
 
 	defer func() {
@@ -217,6 +256,7 @@ func CreateLCOW(ctx context.Context, opts *OptionsLCOW) (_ *UtilityVM, err error
 					LowMMIOGapInMB:        opts.LowMMIOGapInMB,
 					HighMMIOBaseInMB:      opts.HighMMIOBaseInMB,
 					HighMMIOGapInMB:       opts.HighMMIOGapInMB,
+					VirtualNodeCount:      opts.VirtualNodeCount,
 				},
 				Processor: &hcsschema.Processor2{
 					Count:  uvm.processorCount,
@@ -373,6 +413,12 @@ func CreateLCOW(ctx context.Context, opts *OptionsLCOW) (_ *UtilityVM, err error
 		}
 	}
 
+	if opts.RestoreStateFilePath != "" {
+		doc.VirtualMachine.RestoreState = &hcsschema.RestoreState{
+			SaveStateFilePath: opts.RestoreStateFilePath,
+		}
+	}
+
 	fullDoc, err := mergemaps.MergeJSON(doc, ([]byte)(opts.AdditionHCSDocumentJSON))
 	if err != nil {
 		return nil, fmt.Errorf("failed to merge additional JSON '%s': %s", opts.AdditionHCSDocumentJSON, err)
@@ -413,8 +459,14 @@ func CreateLCOW(ctx context.Context, opts *OptionsLCOW) (_ *UtilityVM, err error
 }
 
 func (uvm *UtilityVM) listenVsock(port uint32) (net.Listener, error) {
+	return uvm.vsockListenFn(winio.VsockServiceID(port))
+}
+
+// listenHvsock is the default vsockListenFn: it listens on the AF_HYPERV
+// (hvsocket) address HCS uses to expose a guest vsock service to the host.
+func (uvm *UtilityVM) listenHvsock(serviceID guid.GUID) (net.Listener, error) {
 	return winio.ListenHvsock(&winio.HvsockAddr{
 		VMID:      uvm.runtimeID,
-		ServiceID: winio.VsockServiceID(port),
+		ServiceID: serviceID,
 	})
 }
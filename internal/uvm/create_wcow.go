@@ -6,7 +6,6 @@ import (
 	"os"
 	"path/filepath"
 
-	"github.com/Microsoft/go-winio"
 	"github.com/Microsoft/go-winio/pkg/guid"
 	"github.com/Microsoft/hcsshim/internal/gcs"
 	"github.com/Microsoft/hcsshim/internal/log"
@@ -28,6 +27,12 @@ type OptionsWCOW struct {
 	*Options
 
 	LayerFolders []string // Set of folders for base layers and scratch. Ordered from top most read-only through base read-only layer, followed by scratch
+
+	// EncryptedScratchDisk requests that the UVM's scratch disk (sandbox.vhdx)
+	// be created encrypted-at-rest; see oci.AnnotationEncryptedScratchDisk for
+	// the annotation that sets this, and wcow.CreateUVMScratch for why this is
+	// currently rejected rather than honored.
+	EncryptedScratchDisk bool
 }
 
 // NewDefaultOptionsWCOW creates the default options for a bootable version of
@@ -47,7 +52,6 @@ func NewDefaultOptionsWCOW(id, owner string) *OptionsWCOW {
 //
 // WCOW Notes:
 //   - The scratch is always attached to SCSI 0:0
-//
 func CreateWCOW(ctx context.Context, opts *OptionsWCOW) (_ *UtilityVM, err error) {
 	ctx, span := trace.StartSpan(ctx, "uvm::CreateWCOW")
 	defer span.End()
@@ -69,12 +73,21 @@ func CreateWCOW(ctx context.Context, opts *OptionsWCOW) (_ *UtilityVM, err error
 		owner:                   opts.Owner,
 		operatingSystem:         "windows",
 		scsiControllerCount:     1,
+		scsiAllocationPolicy:    opts.SCSIAllocationPolicy,
+		scsiReservedSlotCount:   opts.SCSIReservedSlotCount,
+		firewallRules:           opts.FirewallRules,
 		vsmbDirShares:           make(map[string]*VSMBShare),
 		vsmbFileShares:          make(map[string]*VSMBShare),
 		vpciDevices:             make(map[string]*VPCIDevice),
+		ccgInstances:            make(map[string]*CCGInstance),
 		physicallyBacked:        !opts.AllowOvercommit,
 		devicesPhysicallyBacked: opts.FullyPhysicallyBacked,
 		cpuGroupID:              opts.CPUGroupID,
+		processorReservation:    opts.ProcessorReservation,
+		vsockListenFn:           opts.VSockListenFn,
+	}
+	if uvm.vsockListenFn == nil {
+		uvm.vsockListenFn = uvm.listenHvsock
 	}
 
 	defer func() {
@@ -110,7 +123,7 @@ func CreateWCOW(ctx context.Context, opts *OptionsWCOW) (_ *UtilityVM, err error
 	// Create sandbox.vhdx in the scratch folder based on the template, granting the correct permissions to it
 	scratchPath := filepath.Join(scratchFolder, "sandbox.vhdx")
 	if _, err := os.Stat(scratchPath); os.IsNotExist(err) {
-		if err := wcow.CreateUVMScratch(ctx, uvmFolder, scratchFolder, uvm.id); err != nil {
+		if err := wcow.CreateUVMScratch(ctx, uvmFolder, scratchFolder, uvm.id, opts.EncryptedScratchDisk); err != nil {
 			return nil, fmt.Errorf("failed to create scratch: %s", err)
 		}
 	} else {
@@ -192,6 +205,7 @@ func CreateWCOW(ctx context.Context, opts *OptionsWCOW) (_ *UtilityVM, err error
 					LowMMIOGapInMB:       opts.LowMMIOGapInMB,
 					HighMMIOBaseInMB:     opts.HighMMIOBaseInMB,
 					HighMMIOGapInMB:      opts.HighMMIOGapInMB,
+					VirtualNodeCount:     opts.VirtualNodeCount,
 				},
 				Processor: &hcsschema.Processor2{
 					Count:  uvm.processorCount,
@@ -240,6 +254,12 @@ func CreateWCOW(ctx context.Context, opts *OptionsWCOW) (_ *UtilityVM, err error
 		refCount: 1,
 	}
 
+	if opts.RestoreStateFilePath != "" {
+		doc.VirtualMachine.RestoreState = &hcsschema.RestoreState{
+			SaveStateFilePath: opts.RestoreStateFilePath,
+		}
+	}
+
 	fullDoc, err := mergemaps.MergeJSON(doc, ([]byte)(opts.AdditionHCSDocumentJSON))
 	if err != nil {
 		return nil, fmt.Errorf("failed to merge additional JSON '%s': %s", opts.AdditionHCSDocumentJSON, err)
@@ -252,10 +272,7 @@ func CreateWCOW(ctx context.Context, opts *OptionsWCOW) (_ *UtilityVM, err error
 
 	if opts.ExternalGuestConnection {
 		log.G(ctx).WithField("vmID", uvm.runtimeID).Debug("Using external GCS bridge")
-		l, err := winio.ListenHvsock(&winio.HvsockAddr{
-			VMID:      uvm.runtimeID,
-			ServiceID: gcs.WindowsGcsHvsockServiceID,
-		})
+		l, err := uvm.vsockListenFn(gcs.WindowsGcsHvsockServiceID)
 		if err != nil {
 			return nil, err
 		}
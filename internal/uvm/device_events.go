@@ -0,0 +1,96 @@
+package uvm
+
+import "sync"
+
+// DeviceEventType enumerates the kinds of device lifecycle events a
+// UtilityVM reports to subscribers registered via SubscribeToDeviceEvents.
+type DeviceEventType int
+
+const (
+	DeviceEventTypeArrival DeviceEventType = iota
+	DeviceEventTypeRemoval
+)
+
+func (t DeviceEventType) String() string {
+	switch t {
+	case DeviceEventTypeArrival:
+		return "Arrival"
+	case DeviceEventTypeRemoval:
+		return "Removal"
+	default:
+		return "Unknown"
+	}
+}
+
+// DeviceEvent is sent on the channel returned by
+// UtilityVM.SubscribeToDeviceEvents whenever a VPCI device is assigned to,
+// or removed from, the uvm.
+//
+// This reports that the host side of the assignment or removal completed --
+// the ModifySettingRequest HCS issued for it returned successfully. It does
+// NOT confirm the guest has actually enumerated (or torn down) the device
+// node: for LCOW that would mean GCS forwarding its own udev/PnP
+// notifications back over the bridge, and the vendored GCS bridge protocol
+// in this repo has no message type for that today (msgTypeNotify in
+// internal/gcs/protocol.go currently only carries container exit
+// notifications). A caller that needs that stronger guarantee still has to
+// probe the guest itself once it sees the event here.
+type DeviceEvent struct {
+	Type             DeviceEventType
+	DeviceInstanceID string
+}
+
+// deviceEventBroadcaster fans a DeviceEvent out to every subscriber. It's
+// deliberately simple -- a mutex-guarded map of channels -- since a uvm has
+// at most a handful of subscribers over its lifetime, not a general
+// pub/sub problem.
+type deviceEventBroadcaster struct {
+	m           sync.Mutex
+	subscribers map[int]chan DeviceEvent
+	nextID      int
+}
+
+func (b *deviceEventBroadcaster) subscribe() (<-chan DeviceEvent, func()) {
+	b.m.Lock()
+	defer b.m.Unlock()
+
+	if b.subscribers == nil {
+		b.subscribers = make(map[int]chan DeviceEvent)
+	}
+	id := b.nextID
+	b.nextID++
+	ch := make(chan DeviceEvent, 16)
+	b.subscribers[id] = ch
+
+	cancel := func() {
+		b.m.Lock()
+		defer b.m.Unlock()
+		if ch, ok := b.subscribers[id]; ok {
+			delete(b.subscribers, id)
+			close(ch)
+		}
+	}
+	return ch, cancel
+}
+
+func (b *deviceEventBroadcaster) publish(evt DeviceEvent) {
+	b.m.Lock()
+	defer b.m.Unlock()
+
+	for _, ch := range b.subscribers {
+		select {
+		case ch <- evt:
+		default:
+			// A slow or abandoned subscriber doesn't get to hold up device
+			// assignment/removal; it just misses the event.
+		}
+	}
+}
+
+// SubscribeToDeviceEvents registers for DeviceEvents published whenever a
+// VPCI device is assigned to or removed from the uvm. The caller must call
+// the returned cancel func once it's done to unsubscribe and release the
+// channel.
+func (uvm *UtilityVM) SubscribeToDeviceEvents() (<-chan DeviceEvent, func()) {
+	return uvm.deviceEvents.subscribe()
+}
@@ -0,0 +1,27 @@
+package uvm
+
+import (
+	"context"
+
+	"github.com/Microsoft/hcsshim/internal/guestrequest"
+	"github.com/Microsoft/hcsshim/internal/requesttype"
+)
+
+// ApplyFirewallRules pushes host-defined firewall rules into the guest,
+// applied as WFP filters for WCOW or nftables rules for LCOW, giving
+// hypervisor-isolated pods a defense-in-depth layer beyond the HNS ACLs
+// already applied to their external switch port.
+//
+// rules are opaque to hcsshim: each is a single rule in whatever syntax the
+// guest's GCS build applies it with. A call replaces any rules a previous
+// call applied.
+func (uvm *UtilityVM) ApplyFirewallRules(ctx context.Context, rules []string) error {
+	guestReq := guestrequest.GuestRequest{
+		RequestType:  requesttype.Update,
+		ResourceType: guestrequest.ResourceTypeFirewallRules,
+		Settings: guestrequest.FirewallRulesRequest{
+			Rules: rules,
+		},
+	}
+	return uvm.modify(ctx, newGuestModifyRequest(guestReq))
+}
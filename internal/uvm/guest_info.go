@@ -0,0 +1,32 @@
+package uvm
+
+import "github.com/Microsoft/hcsshim/internal/schema1"
+
+// GuestInfo describes what's known about the uVM's guest from the GCS
+// protocol negotiation that happens at bridge connect time, for diagnostic
+// reporting (see shimdiag.DiagGuestInfo).
+type GuestInfo struct {
+	// OS is the guest's operating system, "windows" or "linux".
+	OS string
+	// ProtocolVersion is the negotiated GCS protocol version.
+	ProtocolVersion uint32
+	// Capabilities are the guest-defined capability flags reported during
+	// negotiation.
+	Capabilities schema1.GuestDefinedCapabilities
+}
+
+// GuestInfo returns what's known about the uVM's connected guest. It
+// returns the zero value if the uVM has no guest connection (e.g. it is not
+// hypervisor isolated).
+//
+// The GCS protocol negotiated here doesn't carry a guest kernel version or
+// GCS build identifier -- only a protocol version number and the boolean
+// capability flags above -- so this can't report those, even though they'd
+// be useful for fleet-wide guest image version auditing.
+func (uvm *UtilityVM) GuestInfo() GuestInfo {
+	return GuestInfo{
+		OS:              uvm.OS(),
+		ProtocolVersion: uvm.protocol,
+		Capabilities:    uvm.guestCaps,
+	}
+}
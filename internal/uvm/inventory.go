@@ -0,0 +1,50 @@
+package uvm
+
+// AssignedDevice describes a single device currently assigned to the uVM,
+// for diagnostic reporting (see shimdiag.DiagListDevices).
+type AssignedDevice struct {
+	// Kind is "vpci" for a PCI passthrough device (this includes GPU
+	// partitions assigned via VPCI; the uVM does not separately track that
+	// distinction once the device is attached) or "scsi" for a SCSI
+	// attachment.
+	Kind string
+	// InstancePath is the host device instance ID, set for Kind == "vpci".
+	InstancePath string
+	// Controller and LUN identify a SCSI attachment's slot in the uVM, set
+	// for Kind == "scsi".
+	Controller int
+	LUN        int32
+	// BackingFile is the SCSI attachment's backing file/VHD path, set for
+	// Kind == "scsi".
+	BackingFile string
+}
+
+// AssignedDevices returns a snapshot of every device currently tracked as
+// assigned to the uVM: VPCI passthrough devices (including GPU partitions)
+// and SCSI attachments. It does not include VPMem devices.
+func (uvm *UtilityVM) AssignedDevices() []AssignedDevice {
+	uvm.m.Lock()
+	defer uvm.m.Unlock()
+
+	var devices []AssignedDevice
+	for _, vpci := range uvm.vpciDevices {
+		devices = append(devices, AssignedDevice{
+			Kind:         "vpci",
+			InstancePath: vpci.deviceInstanceID,
+		})
+	}
+	for _, luns := range uvm.scsiLocations {
+		for _, sm := range luns {
+			if sm == nil {
+				continue
+			}
+			devices = append(devices, AssignedDevice{
+				Kind:        "scsi",
+				Controller:  sm.Controller,
+				LUN:         sm.LUN,
+				BackingFile: sm.HostPath,
+			})
+		}
+	}
+	return devices
+}
@@ -0,0 +1,24 @@
+package uvm
+
+import (
+	"context"
+
+	"github.com/Microsoft/hcsshim/internal/guestrequest"
+	"github.com/Microsoft/hcsshim/internal/requesttype"
+)
+
+// SetGuestLogLevel changes the GCS's log level and, optionally, the set of
+// debug categories it logs at that level, without requiring the uVM to be
+// restarted with a debug GCS.
+func (uvm *UtilityVM) SetGuestLogLevel(ctx context.Context, level string, categories []string) error {
+	guestReq := guestrequest.GuestRequest{
+		RequestType:  requesttype.Update,
+		ResourceType: guestrequest.ResourceTypeLogLevel,
+		Settings: guestrequest.LogLevelRequest{
+			Level:      level,
+			Categories: categories,
+		},
+	}
+	msr := newGuestModifyRequest(guestReq)
+	return uvm.modify(ctx, msr)
+}
@@ -18,10 +18,7 @@ const (
 func (uvm *UtilityVM) UpdateMemory(ctx context.Context, sizeInBytes uint64) error {
 	requestedSizeInMB := sizeInBytes / bytesPerMB
 	actual := uvm.normalizeMemorySize(ctx, requestedSizeInMB)
-	req := &hcsschema.ModifySettingRequest{
-		ResourcePath: memoryResourcePath,
-		Settings:     actual,
-	}
+	req := newModifyRequest(memoryResourcePath, "", actual)
 	return uvm.modify(ctx, req)
 }
 
@@ -0,0 +1,51 @@
+package uvm
+
+import (
+	"context"
+
+	hcsschema "github.com/Microsoft/hcsshim/internal/schema2"
+)
+
+// newModifyRequest builds a ModifySettingRequest that applies settings directly
+// to a host-side resource addressed by resourcePath, e.g. memory, CPU groups, or
+// CPU limits. requestType may be empty, in which case HCS treats the call as a
+// plain update of the resource at resourcePath.
+func newModifyRequest(resourcePath string, requestType string, settings interface{}) *hcsschema.ModifySettingRequest {
+	return &hcsschema.ModifySettingRequest{
+		ResourcePath: resourcePath,
+		RequestType:  requestType,
+		Settings:     settings,
+	}
+}
+
+// newGuestModifyRequest builds a ModifySettingRequest that is routed through to
+// the guest via its GuestRequest payload rather than applied directly to a
+// host-side resource, e.g. GCS log level changes.
+func newGuestModifyRequest(guestRequest interface{}) *hcsschema.ModifySettingRequest {
+	return &hcsschema.ModifySettingRequest{
+		GuestRequest: guestRequest,
+	}
+}
+
+// modifyMulti applies a sequence of modify operations to the uVM, stopping at
+// the first failure and returning its error without attempting any of the
+// remaining operations.
+//
+// HcsModifyComputeSystem, and the guest bridge's rpcModifySettings, each carry
+// exactly one request, so there is no platform-level way to submit several
+// resource modifications as a single round trip. modifyMulti exists to give
+// callers that apply several related resources together (e.g. every endpoint
+// in a namespace) one place to get consistent ordering, cancellation, and
+// error handling instead of repeating the loop-and-bail pattern at each call
+// site.
+func (uvm *UtilityVM) modifyMulti(ctx context.Context, ops []func(ctx context.Context) error) error {
+	for _, op := range ops {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if err := op(ctx); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,30 @@
+package uvm
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+)
+
+// deterministicResourceName derives a short, stable name for a uVM-attached
+// resource (VSMB/Plan9 share) from its natural dedup key, in place of a
+// per-process sequence counter. The same key always yields the same name, so
+// a freshly restarted shim process -- whose in-memory counters have reset to
+// zero, but whose uVM (and whatever share names HCS already has registered
+// against it) survived the restart -- never picks a name some other,
+// unrelated resource already owns.
+//
+// exists reports whether a candidate name is already in use by some other
+// key known to the caller. Collisions between distinct keys are
+// astronomically unlikely with a 256-bit digest, but are checked for rather
+// than assumed; on one, a short disambiguating suffix is appended and
+// retried.
+func deterministicResourceName(prefix, key string, exists func(name string) bool) string {
+	sum := sha256.Sum256([]byte(key))
+	digest := hex.EncodeToString(sum[:])[:16]
+	name := prefix + digest
+	for i := 1; exists(name); i++ {
+		name = fmt.Sprintf("%s%s-%d", prefix, digest, i)
+	}
+	return name
+}
@@ -34,6 +34,11 @@ type NetworkEndpoints struct {
 	Namespace string
 }
 
+// String returns a description of the network endpoints for diagnostics.
+func (endpoints *NetworkEndpoints) String() string {
+	return fmt.Sprintf("network endpoints %v in namespace %s", endpoints.EndpointIDs, endpoints.Namespace)
+}
+
 // Release releases the resources for all of the network endpoints in a namespace.
 func (endpoints *NetworkEndpoints) Release(ctx context.Context) error {
 	for _, endpoint := range endpoints.EndpointIDs {
@@ -110,22 +115,30 @@ func (uvm *UtilityVM) AddEndpointsToNS(ctx context.Context, id string, endpoints
 		return ErrNetNSNotFound
 	}
 
+	var ops []func(ctx context.Context) error
 	for _, endpoint := range endpoints {
 		if _, ok := ns.nics[endpoint.Id]; !ok {
-			nicID, err := guid.NewV4()
-			if err != nil {
-				return err
-			}
-			if err := uvm.addNIC(ctx, nicID, endpoint); err != nil {
-				return err
-			}
-			ns.nics[endpoint.Id] = &nicInfo{
-				ID:       nicID,
-				Endpoint: endpoint,
-			}
+			ops = append(ops, uvm.addEndpointOp(endpoint, ns))
 		}
 	}
-	return nil
+	return uvm.modifyMulti(ctx, ops)
+}
+
+func (uvm *UtilityVM) addEndpointOp(endpoint *hns.HNSEndpoint, ns *namespaceInfo) func(ctx context.Context) error {
+	return func(ctx context.Context) error {
+		nicID, err := guid.NewV4()
+		if err != nil {
+			return err
+		}
+		if err := uvm.addNIC(ctx, nicID, endpoint); err != nil {
+			return err
+		}
+		ns.nics[endpoint.Id] = &nicInfo{
+			ID:       nicID,
+			Endpoint: endpoint,
+		}
+		return nil
+	}
 }
 
 // RemoveNetNS removes the namespace from the uvm and all remaining endpoints in
@@ -191,6 +204,53 @@ func (uvm *UtilityVM) RemoveEndpointsFromNS(ctx context.Context, id string, endp
 	return nil
 }
 
+// MoveEndpointToNS moves the endpoint identified by `endpointID` from the
+// network namespace `fromID` to the network namespace `toID`, both of which
+// must already have been added to the uVM with AddNetNS. This re-plumbs the
+// host side HNS namespace membership and the guest's view of the adapter
+// (by removing and re-adding its NIC, since nothing in this uVM's guest
+// protocol lets a NIC's namespace be changed in place) without tearing down
+// and recreating the endpoint itself, so its IP/MAC and any connections
+// already established for it survive the move.
+//
+// If `fromID` does not have an endpoint matching `endpointID` this command
+// silently succeeds. If `toID` does not match a namespace previously added
+// with AddNetNS, returns `ErrNetNSNotFound`.
+func (uvm *UtilityVM) MoveEndpointToNS(ctx context.Context, endpointID string, fromID string, toID string) error {
+	uvm.m.Lock()
+	defer uvm.m.Unlock()
+
+	toNS, ok := uvm.namespaces[toID]
+	if !ok {
+		return ErrNetNSNotFound
+	}
+
+	fromNS, ok := uvm.namespaces[fromID]
+	if !ok || fromNS.nics[endpointID] == nil {
+		return nil
+	}
+	ninfo := fromNS.nics[endpointID]
+
+	if err := uvm.removeNIC(ctx, ninfo.ID, ninfo.Endpoint); err != nil {
+		return fmt.Errorf("removing endpoint %s from namespace %s in guest: %s", endpointID, fromID, err)
+	}
+	delete(fromNS.nics, endpointID)
+
+	if err := hns.RemoveNamespaceEndpoint(fromID, endpointID); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("removing endpoint %s from namespace %s: %s", endpointID, fromID, err)
+	}
+	if err := hns.AddNamespaceEndpoint(toID, endpointID); err != nil {
+		return fmt.Errorf("adding endpoint %s to namespace %s: %s", endpointID, toID, err)
+	}
+
+	endpoint, err := hns.GetHNSEndpointByID(endpointID)
+	if err != nil {
+		return fmt.Errorf("refreshing endpoint %s after moving it to namespace %s: %s", endpointID, toID, err)
+	}
+
+	return uvm.addEndpointOp(endpoint, toNS)(ctx)
+}
+
 // IsNetworkNamespaceSupported returns bool value specifying if network namespace is supported inside the guest
 func (uvm *UtilityVM) isNetworkNamespaceSupported() bool {
 	return uvm.guestCaps.NamespaceAddRequestSupported
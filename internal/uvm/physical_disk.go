@@ -0,0 +1,96 @@
+package uvm
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"syscall"
+	"unsafe"
+
+	"github.com/Microsoft/hcsshim/internal/log"
+)
+
+// diskAttributeOffline is the DISK_ATTRIBUTE_OFFLINE flag used with
+// ioctlDiskSetDiskAttributes to take a physical disk offline on the host so
+// it can be exclusively passed through to a utility VM: a disk that's still
+// mounted by the host will either fail to SCSI-attach or, worse, end up
+// written by both sides at once.
+const diskAttributeOffline = 0x0000000000000001
+
+// ioctlDiskSetDiskAttributes is IOCTL_DISK_SET_DISK_ATTRIBUTES.
+const ioctlDiskSetDiskAttributes = 0x0007c0f4
+
+// setDiskAttributes mirrors the Win32 SET_DISK_ATTRIBUTES structure.
+type setDiskAttributes struct {
+	Version        uint32
+	Persist        uint8
+	_              [3]uint8
+	Attributes     uint64
+	AttributesMask uint64
+	_              [4]uint32
+}
+
+// setDiskOffline sets or clears the offline attribute of the physical disk
+// numbered `diskNumber` (\\.\PhysicalDriveN). The change is not persisted
+// across host reboots: it only needs to hold for as long as the disk is
+// SCSI-attached to a uVM.
+func setDiskOffline(diskNumber uint32, offline bool) error {
+	path := fmt.Sprintf(`\\.\PhysicalDrive%d`, diskNumber)
+	f, err := os.OpenFile(path, os.O_RDWR, 0)
+	if err != nil {
+		return &os.PathError{Op: "OpenFile", Path: path, Err: err}
+	}
+	defer f.Close()
+
+	attrs := setDiskAttributes{
+		Version:        uint32(unsafe.Sizeof(setDiskAttributes{})),
+		AttributesMask: diskAttributeOffline,
+	}
+	if offline {
+		attrs.Attributes = diskAttributeOffline
+	}
+
+	var bytesReturned uint32
+	err = syscall.DeviceIoControl(
+		syscall.Handle(f.Fd()),
+		ioctlDiskSetDiskAttributes,
+		(*byte)(unsafe.Pointer(&attrs)),
+		uint32(unsafe.Sizeof(attrs)),
+		nil,
+		0,
+		&bytesReturned,
+		nil)
+	if err != nil {
+		return &os.PathError{Op: "IOCTL_DISK_SET_DISK_ATTRIBUTES", Path: path, Err: err}
+	}
+	return nil
+}
+
+// AddSCSIPhysicalDiskByNumber attaches the host physical disk numbered
+// `diskNumber` (as in \\.\PhysicalDriveN) to the utility VM, the same way
+// AddSCSIPhysicalDisk does, but first takes the disk offline on the host and
+// arranges for the returned SCSIMount's Release to bring it back online --
+// so callers using a dedicated disk for a stateful workload don't have to
+// manage that handoff themselves.
+//
+// This does not set up a SCSI persistent reservation: that needs a SCSI
+// pass-through CDB encoder/decoder (PERSISTENT RESERVE IN/OUT) this package
+// doesn't have. Callers that need reservation-based fencing across hosts
+// must still arrange that out of band.
+func (uvm *UtilityVM) AddSCSIPhysicalDiskByNumber(ctx context.Context, diskNumber uint32, uvmPath string, readOnly bool) (*SCSIMount, error) {
+	if err := setDiskOffline(diskNumber, true); err != nil {
+		return nil, fmt.Errorf("failed to take physical disk %d offline: %s", diskNumber, err)
+	}
+
+	hostPath := fmt.Sprintf(`\\.\PhysicalDrive%d`, diskNumber)
+	sm, err := uvm.AddSCSIPhysicalDisk(ctx, hostPath, uvmPath, readOnly)
+	if err != nil {
+		if onlineErr := setDiskOffline(diskNumber, false); onlineErr != nil {
+			log.G(ctx).WithError(onlineErr).Warn("failed to restore physical disk online state after failed attach")
+		}
+		return nil, err
+	}
+
+	sm.managedPhysicalDiskNumber = &diskNumber
+	return sm, nil
+}
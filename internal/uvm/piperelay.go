@@ -0,0 +1,113 @@
+package uvm
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net"
+	"sync"
+
+	winio "github.com/Microsoft/go-winio"
+	"github.com/Microsoft/go-winio/pkg/guid"
+	"github.com/Microsoft/hcsshim/internal/log"
+)
+
+// PipeRelay bridges a host named pipe to an AF_HYPERV (hvsocket) endpoint
+// exposed to this uVM: every hvsocket connection accepted from the guest
+// opens a fresh connection to the host named pipe and pumps bytes between the
+// two until either side closes.
+//
+// This is only the host side of exposing a host named pipe to a Linux
+// container as a Unix socket. Turning the guest end of this relay into an
+// actual AF_UNIX socket bound at a mount destination path inside a LCOW
+// container's filesystem requires something running in the guest to dial
+// ServiceID and forward it to that socket, which is GCS functionality --
+// this repository doesn't vendor the GCS source, and there is no existing
+// guest request type for it (see internal/guestrequest). PipeRelay exists so
+// that capability has a real host-side half to build on; it cannot complete
+// the mount-to-socket mapping on its own.
+type PipeRelay struct {
+	hostPath  string
+	serviceID guid.GUID
+	listener  net.Listener
+	closeOnce sync.Once
+}
+
+// ServiceID returns the hvsocket service ID a guest-side component must
+// connect to in order to reach hostPath through this relay.
+func (r *PipeRelay) ServiceID() guid.GUID {
+	return r.serviceID
+}
+
+// String returns a description of the pipe relay for diagnostics.
+func (r *PipeRelay) String() string {
+	return fmt.Sprintf("pipe relay %s <-> hvsocket %s", r.hostPath, r.serviceID)
+}
+
+// Release stops the relay from accepting new connections. Connections
+// already in progress are left to drain on their own.
+func (r *PipeRelay) Release(ctx context.Context) error {
+	r.closeOnce.Do(func() {
+		r.listener.Close()
+	})
+	return nil
+}
+
+// AddPipeRelay starts relaying hvsocket connections made to a newly
+// allocated service ID to the host named pipe at hostPath, and vice versa:
+// each relayed connection pumps bytes in both directions until either side
+// closes.
+func (uvm *UtilityVM) AddPipeRelay(ctx context.Context, hostPath string) (*PipeRelay, error) {
+	sid, err := guid.NewV4()
+	if err != nil {
+		return nil, fmt.Errorf("failed to allocate pipe relay service ID: %s", err)
+	}
+	l, err := uvm.vsockListenFn(sid)
+	if err != nil {
+		return nil, fmt.Errorf("failed to listen for pipe relay: %s", err)
+	}
+	r := &PipeRelay{
+		hostPath:  hostPath,
+		serviceID: sid,
+		listener:  l,
+	}
+	go r.acceptLoop(ctx)
+	return r, nil
+}
+
+// acceptLoop accepts hvsocket connections until the listener is closed by
+// Release.
+func (r *PipeRelay) acceptLoop(ctx context.Context) {
+	for {
+		conn, err := r.listener.Accept()
+		if err != nil {
+			return
+		}
+		go r.relay(ctx, conn)
+	}
+}
+
+// relay dials a fresh connection to the host named pipe for `conn` and pumps
+// bytes between the two until either side closes.
+func (r *PipeRelay) relay(ctx context.Context, conn net.Conn) {
+	defer conn.Close()
+
+	pipeConn, err := winio.DialPipeContext(ctx, r.hostPath)
+	if err != nil {
+		log.G(ctx).WithError(err).WithField("hostPath", r.hostPath).Error("pipe relay failed to dial host named pipe")
+		return
+	}
+	defer pipeConn.Close()
+
+	var wg sync.WaitGroup
+	wg.Add(2)
+	go func() {
+		defer wg.Done()
+		io.Copy(pipeConn, conn)
+	}()
+	go func() {
+		defer wg.Done()
+		io.Copy(conn, pipeConn)
+	}()
+	wg.Wait()
+}
@@ -6,7 +6,6 @@ import (
 	"strings"
 
 	"github.com/Microsoft/hcsshim/internal/requesttype"
-	hcsschema "github.com/Microsoft/hcsshim/internal/schema2"
 	specs "github.com/opencontainers/runtime-spec/specs-go"
 )
 
@@ -19,6 +18,11 @@ type PipeMount struct {
 	HostPath string
 }
 
+// String returns a description of the pipe mount for diagnostics.
+func (pipe *PipeMount) String() string {
+	return fmt.Sprintf("pipe mount %s", pipe.HostPath)
+}
+
 // Release frees the resources of the corresponding pipe Mount
 func (pipe *PipeMount) Release(ctx context.Context) error {
 	if err := pipe.vm.RemovePipe(ctx, pipe.HostPath); err != nil {
@@ -29,10 +33,7 @@ func (pipe *PipeMount) Release(ctx context.Context) error {
 
 // AddPipe shares a named pipe into the UVM.
 func (uvm *UtilityVM) AddPipe(ctx context.Context, hostPath string) (*PipeMount, error) {
-	modification := &hcsschema.ModifySettingRequest{
-		RequestType:  requesttype.Add,
-		ResourcePath: fmt.Sprintf(mappedPipeResourceFormat, hostPath),
-	}
+	modification := newModifyRequest(fmt.Sprintf(mappedPipeResourceFormat, hostPath), requesttype.Add, nil)
 	if err := uvm.modify(ctx, modification); err != nil {
 		return nil, err
 	}
@@ -41,10 +42,7 @@ func (uvm *UtilityVM) AddPipe(ctx context.Context, hostPath string) (*PipeMount,
 
 // RemovePipe removes a shared named pipe from the UVM.
 func (uvm *UtilityVM) RemovePipe(ctx context.Context, hostPath string) error {
-	modification := &hcsschema.ModifySettingRequest{
-		RequestType:  requesttype.Remove,
-		ResourcePath: fmt.Sprintf(mappedPipeResourceFormat, hostPath),
-	}
+	modification := newModifyRequest(fmt.Sprintf(mappedPipeResourceFormat, hostPath), requesttype.Remove, nil)
 	if err := uvm.modify(ctx, modification); err != nil {
 		return err
 	}
@@ -4,19 +4,39 @@ import (
 	"context"
 	"errors"
 	"fmt"
-	"strconv"
 
 	"github.com/Microsoft/hcsshim/internal/guestrequest"
+	"github.com/Microsoft/hcsshim/internal/log"
 	"github.com/Microsoft/hcsshim/internal/requesttype"
 	hcsschema "github.com/Microsoft/hcsshim/internal/schema2"
 	"github.com/Microsoft/hcsshim/osversion"
+	"github.com/sirupsen/logrus"
 )
 
 // Plan9Share is a struct containing host paths for the UVM
 type Plan9Share struct {
 	// UVM resource belongs to
-	vm            *UtilityVM
-	name, uvmPath string
+	vm                      *UtilityVM
+	name, uvmPath, hostPath string
+	refCount                uint32
+	readOnly                bool
+	restrict                bool
+	allowedNames            []string
+}
+
+// getPlan9ShareKey returns the key used to ref-count plan9 shares of the same
+// host path, so that e.g. two containers in the same pod mounting the same
+// emptyDir reuse one share instead of each hot-adding their own.
+//
+// Restricted (single/selected-file) shares are never looked up through this
+// key -- see AddPlan9.
+func getPlan9ShareKey(hostPath string, readOnly bool) string {
+	return fmt.Sprintf("%s-%t", hostPath, readOnly)
+}
+
+// String returns a description of the Plan9 share for diagnostics.
+func (p9 *Plan9Share) String() string {
+	return fmt.Sprintf("plan9 share %s (%s)", p9.name, p9.uvmPath)
 }
 
 // Release frees the resources of the corresponding Plan9 share
@@ -29,7 +49,21 @@ func (p9 *Plan9Share) Release(ctx context.Context) error {
 
 const plan9Port = 564
 
-// AddPlan9 adds a Plan9 share to a utility VM.
+// AddPlan9 adds a Plan9 share to a utility VM. Unrestricted shares are
+// ref-counted by host path and read-only-ness, and are only actually added
+// once: a second AddPlan9 for the same hostPath (e.g. from a sidecar
+// container sharing an emptyDir with another container in the same pod)
+// reuses the existing share. Restricted (single/selected-file) shares are
+// never reused, since merging unrelated allowed-file lists under one share
+// would grant each caller access to files it never asked to see.
+//
+// The share's identity-check behavior is controlled uVM-wide by
+// OptionsLCOW.Plan9UseShareRootIdentity; this function has no other
+// performance tuning knobs. In particular it does not expose the 9p
+// protocol's msize (maximum message size) or cache mode: those are mount
+// options the guest's 9p client applies when it mounts the share, and
+// nothing in guestrequest.LCOWMappedDirectory or the (unvendored) guest
+// agent carries them today.
 func (uvm *UtilityVM) AddPlan9(ctx context.Context, hostPath string, uvmPath string, readOnly bool, restrict bool, allowedNames []string) (*Plan9Share, error) {
 	if uvm.operatingSystem != "linux" {
 		return nil, errNotSupported
@@ -41,6 +75,17 @@ func (uvm *UtilityVM) AddPlan9(ctx context.Context, hostPath string, uvmPath str
 		return nil, fmt.Errorf("uvmPath must be passed to AddPlan9")
 	}
 
+	uvm.m.Lock()
+	defer uvm.m.Unlock()
+
+	if !restrict {
+		shareKey := getPlan9ShareKey(hostPath, readOnly)
+		if share, ok := uvm.plan9Shares[shareKey]; ok {
+			share.refCount++
+			return share, nil
+		}
+	}
+
 	// TODO: JTERRY75 - These are marked private in the schema. For now use them
 	// but when there are public variants we need to switch to them.
 	const (
@@ -61,21 +106,32 @@ func (uvm *UtilityVM) AddPlan9(ctx context.Context, hostPath string, uvmPath str
 		flags |= shareFlagsRestrictFileAccess
 	}
 
-	uvm.m.Lock()
-	index := uvm.plan9Counter
-	uvm.plan9Counter++
-	uvm.m.Unlock()
-	name := strconv.FormatUint(index, 10)
+	nameKey := getPlan9ShareKey(hostPath, readOnly)
+	if restrict {
+		// Distinguish from the unrestricted share of the same hostPath, and
+		// from other restricted shares of it with a different allowed-file
+		// set, neither of which is reachable through uvm.plan9Shares.
+		nameKey = fmt.Sprintf("%s-restrict-%v", nameKey, allowedNames)
+	}
+	name := deterministicResourceName("p9-", nameKey, func(name string) bool {
+		for _, s := range uvm.plan9Shares {
+			if s.name == name {
+				return true
+			}
+		}
+		return false
+	})
 
 	modification := &hcsschema.ModifySettingRequest{
 		RequestType: requesttype.Add,
 		Settings: hcsschema.Plan9Share{
-			Name:         name,
-			AccessName:   name,
-			Path:         hostPath,
-			Port:         plan9Port,
-			Flags:        flags,
-			AllowedFiles: allowedNames,
+			Name:                 name,
+			AccessName:           name,
+			Path:                 hostPath,
+			Port:                 plan9Port,
+			Flags:                flags,
+			AllowedFiles:         allowedNames,
+			UseShareRootIdentity: uvm.plan9UseShareRootIdentity,
 		},
 		ResourcePath: plan9ShareResourcePath,
 		GuestRequest: guestrequest.GuestRequest{
@@ -94,20 +150,58 @@ func (uvm *UtilityVM) AddPlan9(ctx context.Context, hostPath string, uvmPath str
 		return nil, err
 	}
 
-	return &Plan9Share{
-		vm:      uvm,
-		name:    name,
-		uvmPath: uvmPath,
-	}, nil
+	share := &Plan9Share{
+		vm:           uvm,
+		name:         name,
+		uvmPath:      uvmPath,
+		hostPath:     hostPath,
+		refCount:     1,
+		readOnly:     readOnly,
+		restrict:     restrict,
+		allowedNames: allowedNames,
+	}
+	if !restrict {
+		uvm.plan9Shares[getPlan9ShareKey(hostPath, readOnly)] = share
+	}
+	return share, nil
 }
 
-// RemovePlan9 removes a Plan9 share from a utility VM. Each Plan9 share is ref-counted
-// and only actually removed when the ref-count drops to zero.
+// RemovePlan9 removes a Plan9 share from a utility VM. Each unrestricted
+// Plan9 share is ref-counted and only actually removed when the ref-count
+// drops to zero. If the HCS remove call fails, the share is left attached
+// and an error is returned; see ForceRemovePlan9 to remove it regardless.
 func (uvm *UtilityVM) RemovePlan9(ctx context.Context, share *Plan9Share) error {
+	return uvm.removePlan9(ctx, share, false)
+}
+
+// ForceRemovePlan9 is the same as RemovePlan9, except that if the HCS remove
+// call fails the share is still dropped from hcsshim's own bookkeeping, and
+// the returned error is annotated with the host processes (if any) that the
+// Windows Restart Manager reports as still holding the share's host path
+// open, to help diagnose why it couldn't be released. Use this only when
+// the caller no longer cares whether the guest still considers the share
+// mounted (e.g. tearing down a uVM that failed to shut down).
+//
+// Restart Manager only sees host-side opens; this codebase has no GCS RPC
+// for enumerating handles held inside the guest, so a process in the UVM
+// still using the share is not reported.
+func (uvm *UtilityVM) ForceRemovePlan9(ctx context.Context, share *Plan9Share) error {
+	return uvm.removePlan9(ctx, share, true)
+}
+
+func (uvm *UtilityVM) removePlan9(ctx context.Context, share *Plan9Share, force bool) error {
 	if uvm.operatingSystem != "linux" {
 		return errNotSupported
 	}
 
+	uvm.m.Lock()
+	defer uvm.m.Unlock()
+
+	share.refCount--
+	if share.refCount > 0 {
+		return nil
+	}
+
 	modification := &hcsschema.ModifySettingRequest{
 		RequestType: requesttype.Remove,
 		Settings: hcsschema.Plan9Share{
@@ -127,7 +221,22 @@ func (uvm *UtilityVM) RemovePlan9(ctx context.Context, share *Plan9Share) error
 		},
 	}
 	if err := uvm.modify(ctx, modification); err != nil {
-		return fmt.Errorf("failed to remove plan9 share %s from %s: %+v: %s", share.name, uvm.id, modification, err)
+		if !force {
+			return fmt.Errorf("failed to remove plan9 share %s from %s: %+v: %s", share.name, uvm.id, modification, err)
+		}
+		log.G(ctx).WithError(err).WithFields(logrus.Fields{
+			"shareName": share.name,
+			"hostPath":  share.hostPath,
+			"openHosts": describeHostOpenHandles(share.hostPath),
+		}).Warn("failed to remove plan9 share, forcing removal from bookkeeping anyway")
+	}
+	if !share.restrict {
+		for key, s := range uvm.plan9Shares {
+			if s == share {
+				delete(uvm.plan9Shares, key)
+				break
+			}
+		}
 	}
 	return nil
 }
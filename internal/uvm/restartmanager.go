@@ -0,0 +1,90 @@
+package uvm
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/Microsoft/hcsshim/internal/winapi"
+	"golang.org/x/sys/windows"
+)
+
+// openFileUser describes a host process that the Windows Restart Manager
+// reports as currently holding an open handle to a file.
+type openFileUser struct {
+	PID     uint32
+	AppName string
+}
+
+// hostProcessesUsingFile asks the Windows Restart Manager -- the same
+// mechanism behind "this file is open in another program" dialogs -- which
+// host processes currently have hostPath open, for use as diagnostic
+// context when a forced resource removal fails to actually free the file.
+//
+// This only reports host-side opens. There is no GCS RPC in this codebase
+// for enumerating handles held inside the guest, so a caller cannot learn
+// whether a process in the UVM still has the corresponding share mapping
+// open; that remains an unknown.
+func hostProcessesUsingFile(hostPath string) ([]openFileUser, error) {
+	pathPtr, err := windows.UTF16PtrFromString(hostPath)
+	if err != nil {
+		return nil, err
+	}
+
+	var session uint32
+	var sessionKey [winapi.CCH_RM_SESSION_KEY + 1]uint16
+	if err := winapi.RmStartSession(&session, &sessionKey[0]); err != nil {
+		return nil, fmt.Errorf("RmStartSession: %w", err)
+	}
+	defer winapi.RmEndSession(session)
+
+	fileNames := [1]*uint16{pathPtr}
+	if err := winapi.RmRegisterResources(session, 1, &fileNames[0]); err != nil {
+		return nil, fmt.Errorf("RmRegisterResources: %w", err)
+	}
+
+	var needed, got uint32
+	err = winapi.RmGetList(session, &needed, &got, nil, nil)
+	if err != nil && err != windows.ERROR_MORE_DATA {
+		return nil, fmt.Errorf("RmGetList: %w", err)
+	}
+	if needed == 0 {
+		return nil, nil
+	}
+
+	infos := make([]winapi.RM_PROCESS_INFO, needed)
+	got = needed
+	if err := winapi.RmGetList(session, &needed, &got, &infos[0], nil); err != nil {
+		return nil, fmt.Errorf("RmGetList: %w", err)
+	}
+
+	users := make([]openFileUser, 0, got)
+	for i := uint32(0); i < got; i++ {
+		users = append(users, openFileUser{
+			PID:     infos[i].Process.ProcessId,
+			AppName: windows.UTF16ToString(infos[i].AppName[:]),
+		})
+	}
+	return users, nil
+}
+
+// describeHostOpenHandles is a best-effort diagnostic helper for force-
+// removal paths: it returns a short human-readable summary of which host
+// processes, if any, the Restart Manager reports as still holding hostPath
+// open. If the query itself fails (e.g. insufficient privilege), that
+// failure is folded into the summary rather than returned as an error,
+// since this is diagnostic context for an already-failing operation, not a
+// condition that should itself cause a caller to fail.
+func describeHostOpenHandles(hostPath string) string {
+	users, err := hostProcessesUsingFile(hostPath)
+	if err != nil {
+		return fmt.Sprintf("unable to determine host processes with an open handle: %s", err)
+	}
+	if len(users) == 0 {
+		return "no host process found with an open handle (guest-side opens, if any, are not visible to the host)"
+	}
+	descs := make([]string, 0, len(users))
+	for _, u := range users {
+		descs = append(descs, fmt.Sprintf("%s (pid %d)", u.AppName, u.PID))
+	}
+	return strings.Join(descs, ", ")
+}
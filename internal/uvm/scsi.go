@@ -4,17 +4,60 @@ import (
 	"context"
 	"fmt"
 	"strconv"
+	"time"
 
-	"github.com/Microsoft/go-winio/pkg/security"
 	"github.com/Microsoft/hcsshim/internal/guestrequest"
 	"github.com/Microsoft/hcsshim/internal/log"
 	"github.com/Microsoft/hcsshim/internal/requesttype"
 	hcsschema "github.com/Microsoft/hcsshim/internal/schema2"
+	"github.com/Microsoft/hcsshim/internal/security"
 	"github.com/Microsoft/hcsshim/internal/wclayer"
 	"github.com/pkg/errors"
 	"github.com/sirupsen/logrus"
 )
 
+// scsiUnmountTimeout is how long RemoveSCSI waits for the guest to
+// acknowledge a SCSI disk's unmount before giving up on a graceful detach.
+const scsiUnmountTimeout = 30 * time.Second
+
+// scsiSlotsPerController is the number of LUNs Hyper-V exposes per SCSI
+// controller, and thus the size of each row of uvm.scsiLocations.
+const scsiSlotsPerController = 64
+
+// SCSIAllocationPolicy selects how allocateSCSISlot picks the next free
+// SCSI location for a new attachment.
+type SCSIAllocationPolicy int
+
+const (
+	// SCSIAllocationPolicyPack fills the lowest-numbered free location
+	// first, so attachments stay bunched at the front of the controller.
+	// This is the default, and matches the allocation order this package
+	// has always used.
+	SCSIAllocationPolicyPack SCSIAllocationPolicy = iota
+	// SCSIAllocationPolicySpread fills the free location that is farthest
+	// from any already-occupied one, scattering attachments across the
+	// controller instead of bunching them at the front.
+	SCSIAllocationPolicySpread
+)
+
+// SCSICapacity reports a uVM's SCSI attachment capacity, as returned by
+// (*UtilityVM).SCSICapacity.
+type SCSICapacity struct {
+	// Total is the number of SCSI locations available across every
+	// configured controller.
+	Total uint32
+	// Used is the number of those locations currently occupied by an
+	// attachment.
+	Used uint32
+	// Reserved is the number of free locations held back from regular
+	// allocation by Options.SCSIReservedSlotCount; see AddSCSI. It is
+	// already excluded from Available.
+	Reserved uint32
+	// Available is the number of free locations AddSCSI/AddSCSIPhysicalDisk
+	// will allocate from before falling back to the reserved pool.
+	Available uint32
+}
+
 // VMAccessType is used to determine the various types of access we can
 // grant for a given file.
 type VMAccessType int
@@ -46,6 +89,11 @@ func (sm *SCSIMount) Release(ctx context.Context) error {
 	if err := sm.vm.RemoveSCSI(ctx, sm.HostPath); err != nil {
 		return fmt.Errorf("failed to remove SCSI device: %s", err)
 	}
+	if sm.managedPhysicalDiskNumber != nil {
+		if err := setDiskOffline(*sm.managedPhysicalDiskNumber, false); err != nil {
+			return fmt.Errorf("failed to restore physical disk %d online state: %s", *sm.managedPhysicalDiskNumber, err)
+		}
+	}
 	return nil
 }
 
@@ -67,6 +115,19 @@ type SCSIMount struct {
 	// read-only layers. As RO layers are shared, we perform ref-counting.
 	isLayer  bool
 	refCount uint32
+	// vmAccess is the access this mount's hostPath was granted when it was
+	// attached; Release uses it to know whether it needs to give back a
+	// host-wide VM group access reference (see grantAccess).
+	vmAccess VMAccessType
+	// managedPhysicalDiskNumber is set by AddSCSIPhysicalDiskByNumber to the
+	// host physical disk number this mount took offline, so Release can
+	// bring it back online once detached. nil for every other SCSI mount.
+	managedPhysicalDiskNumber *uint32
+}
+
+// String returns a description of the scsi mount for diagnostics.
+func (sm *SCSIMount) String() string {
+	return fmt.Sprintf("scsi mount %s -> %s (controller %d, lun %d)", sm.HostPath, sm.UVMPath, sm.Controller, sm.LUN)
 }
 
 func (sm *SCSIMount) logFormat() logrus.Fields {
@@ -80,28 +141,132 @@ func (sm *SCSIMount) logFormat() logrus.Fields {
 	}
 }
 
-// allocateSCSISlot finds the next available slot on the
-// SCSI controllers associated with a utility VM to use.
+// allocateSCSISlot finds the next available slot on the SCSI controllers
+// associated with a utility VM to use, honoring uvm.scsiAllocationPolicy and
+// holding back uvm.scsiReservedSlotCount free locations until every other
+// one is occupied.
 // Lock must be held when calling this function
-func (uvm *UtilityVM) allocateSCSISlot(ctx context.Context, hostPath string, uvmPath string) (*SCSIMount, error) {
-	for controller, luns := range uvm.scsiLocations {
-		for lun, sm := range luns {
-			// If sm is nil, we have found an open slot so we allocate a new SCSIMount
-			if sm == nil {
-				uvm.scsiLocations[controller][lun] = &SCSIMount{
-					vm:         uvm,
-					HostPath:   hostPath,
-					UVMPath:    uvmPath,
-					refCount:   1,
-					Controller: controller,
-					LUN:        int32(lun),
-				}
-				log.G(ctx).WithFields(uvm.scsiLocations[controller][lun].logFormat()).Debug("allocated SCSI mount")
-				return uvm.scsiLocations[controller][lun], nil
+func (uvm *UtilityVM) allocateSCSISlot(ctx context.Context, hostPath string, uvmPath string, vmAccess VMAccessType) (*SCSIMount, error) {
+	if uvm.scsiControllerCount == 0 {
+		return nil, ErrNoSCSIControllers
+	}
+	controller, lun, err := uvm.findSCSISlot(ctx, uvm.scsiReservedSlotCount)
+	if err != nil {
+		// Nothing free outside the reserved pool; fall back to it rather
+		// than fail outright, since a caller that actually needed the
+		// reservation honored has no other way to find that out ahead of
+		// time than by calling SCSICapacity first.
+		controller, lun, err = uvm.findSCSISlot(ctx, 0)
+		if err != nil {
+			return nil, err
+		}
+	}
+	uvm.scsiLocations[controller][lun] = &SCSIMount{
+		vm:         uvm,
+		HostPath:   hostPath,
+		UVMPath:    uvmPath,
+		refCount:   1,
+		vmAccess:   vmAccess,
+		Controller: controller,
+		LUN:        int32(lun),
+	}
+	log.G(ctx).WithFields(uvm.scsiLocations[controller][lun].logFormat()).Debug("allocated SCSI mount")
+	return uvm.scsiLocations[controller][lun], nil
+}
+
+// findSCSISlot picks the free (controller, lun) allocateSCSISlot should use
+// next, according to uvm.scsiAllocationPolicy, excluding the last
+// `reserveCount` locations (in controller-major, lun-minor order) from
+// consideration.
+// Lock must be held when calling this function.
+func (uvm *UtilityVM) findSCSISlot(ctx context.Context, reserveCount uint32) (controller int, lun int, err error) {
+	total := uvm.scsiControllerCount * scsiSlotsPerController
+	usableLimit := total
+	if reserveCount < total {
+		usableLimit = total - reserveCount
+	} else {
+		usableLimit = 0
+	}
+
+	switch uvm.scsiAllocationPolicy {
+	case SCSIAllocationPolicySpread:
+		bestIdx := -1
+		bestDistance := -1
+		for idx := uint32(0); idx < usableLimit; idx++ {
+			c, l := int(idx/scsiSlotsPerController), int(idx%scsiSlotsPerController)
+			if uvm.scsiLocations[c][l] != nil {
+				continue
+			}
+			d := uvm.distanceToNearestOccupied(idx, total)
+			if d > bestDistance {
+				bestDistance = d
+				bestIdx = int(idx)
+			}
+		}
+		if bestIdx < 0 {
+			return 0, 0, ErrNoAvailableLocation
+		}
+		return bestIdx / scsiSlotsPerController, bestIdx % scsiSlotsPerController, nil
+	default: // SCSIAllocationPolicyPack
+		for idx := uint32(0); idx < usableLimit; idx++ {
+			c, l := int(idx/scsiSlotsPerController), int(idx%scsiSlotsPerController)
+			if uvm.scsiLocations[c][l] == nil {
+				return c, l, nil
+			}
+		}
+		return 0, 0, ErrNoAvailableLocation
+	}
+}
+
+// distanceToNearestOccupied returns how many locations away (in
+// controller-major, lun-minor order) the nearest occupied SCSI location is
+// from `idx`, or `total` if none of the uVM's locations are occupied yet.
+// Lock must be held when calling this function.
+func (uvm *UtilityVM) distanceToNearestOccupied(idx, total uint32) int {
+	best := int(total)
+	for other := uint32(0); other < total; other++ {
+		c, l := int(other/scsiSlotsPerController), int(other%scsiSlotsPerController)
+		if uvm.scsiLocations[c][l] == nil {
+			continue
+		}
+		d := int(idx) - int(other)
+		if d < 0 {
+			d = -d
+		}
+		if d < best {
+			best = d
+		}
+	}
+	return best
+}
+
+// SCSICapacity reports this uVM's current SCSI attachment usage and
+// remaining capacity, so a caller can tell ahead of time whether a
+// storage-heavy workload is approaching "no free SCSI slots" instead of only
+// discovering it from an ErrNoAvailableLocation returned by AddSCSI mid-run.
+func (uvm *UtilityVM) SCSICapacity() SCSICapacity {
+	uvm.m.Lock()
+	defer uvm.m.Unlock()
+
+	total := uvm.scsiControllerCount * scsiSlotsPerController
+	var used uint32
+	for controller := uint32(0); controller < uvm.scsiControllerCount; controller++ {
+		for _, sm := range uvm.scsiLocations[controller] {
+			if sm != nil {
+				used++
 			}
 		}
 	}
-	return nil, ErrNoAvailableLocation
+	reserved := uvm.scsiReservedSlotCount
+	if reserved > total-used {
+		reserved = total - used
+	}
+	return SCSICapacity{
+		Total:     total,
+		Used:      used,
+		Reserved:  reserved,
+		Available: total - used - reserved,
+	}
 }
 
 func (uvm *UtilityVM) deallocateSCSIMount(ctx context.Context, sm *SCSIMount) {
@@ -126,8 +291,27 @@ func (uvm *UtilityVM) findSCSIAttachment(ctx context.Context, findThisHostPath s
 	return nil, ErrNotAttached
 }
 
-// RemoveSCSI removes a SCSI disk from a utility VM.
+// RemoveSCSI removes a SCSI disk from a utility VM. It first asks the guest
+// to unmount/flush the disk and waits up to scsiUnmountTimeout for it to
+// acknowledge that before detaching; if the guest does not respond in time
+// the disk is left attached and an error is returned, naming the host and
+// guest paths still mounted so a caller can decide whether it's safe to
+// retry with ForceRemoveSCSI.
 func (uvm *UtilityVM) RemoveSCSI(ctx context.Context, hostPath string) error {
+	return uvm.removeSCSI(ctx, hostPath, false)
+}
+
+// ForceRemoveSCSI is the same as RemoveSCSI, except that if the guest
+// doesn't acknowledge the unmount within scsiUnmountTimeout the disk is
+// detached anyway. Use this only when the guest is already known to be
+// unresponsive (e.g. tearing down a uVM that failed to shut down), since a
+// forced detach out from under a container that is still using the disk can
+// corrupt its data.
+func (uvm *UtilityVM) ForceRemoveSCSI(ctx context.Context, hostPath string) error {
+	return uvm.removeSCSI(ctx, hostPath, true)
+}
+
+func (uvm *UtilityVM) removeSCSI(ctx context.Context, hostPath string, force bool) error {
 	uvm.m.Lock()
 	defer uvm.m.Unlock()
 
@@ -146,19 +330,15 @@ func (uvm *UtilityVM) RemoveSCSI(ctx context.Context, hostPath string) error {
 		return nil
 	}
 
-	scsiModification := &hcsschema.ModifySettingRequest{
-		RequestType:  requesttype.Remove,
-		ResourcePath: fmt.Sprintf(scsiResourceFormat, strconv.Itoa(sm.Controller), sm.LUN),
-	}
-
 	// Include the GuestRequest so that the GCS ejects the disk cleanly if the
 	// disk was attached/mounted
 	//
 	// Note: We always send a guest eject even if there is no UVM path in lcow
 	// so that we synchronize the guest state. This seems to always avoid SCSI
 	// related errors if this index quickly reused by another container.
+	var guestReq guestrequest.GuestRequest
 	if uvm.operatingSystem == "windows" && sm.UVMPath != "" {
-		scsiModification.GuestRequest = guestrequest.GuestRequest{
+		guestReq = guestrequest.GuestRequest{
 			ResourceType: guestrequest.ResourceTypeMappedVirtualDisk,
 			RequestType:  requesttype.Remove,
 			Settings: guestrequest.WCOWMappedVirtualDisk{
@@ -167,7 +347,7 @@ func (uvm *UtilityVM) RemoveSCSI(ctx context.Context, hostPath string) error {
 			},
 		}
 	} else {
-		scsiModification.GuestRequest = guestrequest.GuestRequest{
+		guestReq = guestrequest.GuestRequest{
 			ResourceType: guestrequest.ResourceTypeMappedVirtualDisk,
 			RequestType:  requesttype.Remove,
 			Settings: guestrequest.LCOWMappedVirtualDisk{
@@ -178,11 +358,30 @@ func (uvm *UtilityVM) RemoveSCSI(ctx context.Context, hostPath string) error {
 		}
 	}
 
-	if err := uvm.modify(ctx, scsiModification); err != nil {
+	if uvm.gc != nil {
+		unmountCtx, cancel := context.WithTimeout(ctx, scsiUnmountTimeout)
+		err := uvm.gc.Modify(unmountCtx, guestReq)
+		cancel()
+		if err != nil {
+			if !force {
+				return fmt.Errorf("guest did not unmount SCSI disk %s (%s, controller %d, lun %d) within %s: %s", hostPath, sm.UVMPath, sm.Controller, sm.LUN, scsiUnmountTimeout, err)
+			}
+			log.G(ctx).WithFields(sm.logFormat()).WithError(err).Warn("guest unmount failed or timed out, forcing SCSI detach")
+		}
+	}
+
+	scsiModification := &hcsschema.ModifySettingRequest{
+		RequestType:  requesttype.Remove,
+		ResourcePath: fmt.Sprintf(scsiResourceFormat, strconv.Itoa(sm.Controller), sm.LUN),
+	}
+	if err := uvm.hcsSystem.Modify(ctx, scsiModification); err != nil {
 		return fmt.Errorf("failed to remove SCSI disk %s from container %s: %s", hostPath, uvm.id, err)
 	}
 	log.G(ctx).WithFields(sm.logFormat()).Debug("removed SCSI location")
 	uvm.scsiLocations[sm.Controller][sm.LUN] = nil
+	if err := uvm.releaseAccess(ctx, hostPath, sm.vmAccess); err != nil {
+		log.G(ctx).WithFields(sm.logFormat()).WithError(err).Warn("failed to release vm group access")
+	}
 	return nil
 }
 
@@ -311,7 +510,7 @@ func (uvm *UtilityVM) allocateSCSIMount(ctx context.Context, hostPath, uvmPath s
 		return sm, true, nil
 	}
 
-	sm, err := uvm.allocateSCSISlot(ctx, hostPath, uvmPath)
+	sm, err := uvm.allocateSCSISlot(ctx, hostPath, uvmPath, vmAccess)
 	if err != nil {
 		return nil, false, err
 	}
@@ -335,10 +534,28 @@ func (uvm *UtilityVM) GetScsiUvmPath(ctx context.Context, hostPath string) (stri
 func (uvm *UtilityVM) grantAccess(ctx context.Context, hostPath string, vmAccess VMAccessType) error {
 	switch vmAccess {
 	case VMAccessTypeGroup:
-		log.G(ctx).WithField("path", hostPath).Debug("granting vm group access")
-		return security.GrantVmGroupAccess(hostPath)
+		// VMAccessTypeGroup grants access to every VM on the host, not just
+		// this one, so the grant itself is shared too: many pods (each its
+		// own shim process, each its own uVM) routinely attach the same
+		// read-only layer VHD this way, and the grant must stay in place
+		// until the last one of them is done with it, not just the first
+		// one that happens to release it.
+		log.G(ctx).WithField("path", hostPath).Debug("acquiring vm group access")
+		return security.AcquireVmGroupAccess(hostPath)
 	case VMAccessTypeIndividual:
 		return wclayer.GrantVmAccess(ctx, uvm.id, hostPath)
 	}
 	return nil
 }
+
+// releaseAccess gives back the access obtained by a matching grantAccess
+// call for a SCSI mount that's being removed. VMAccessTypeIndividual grants
+// are scoped to this uVM alone and are implicitly undone when the uVM is
+// torn down, so there is nothing to release for those here.
+func (uvm *UtilityVM) releaseAccess(ctx context.Context, hostPath string, vmAccess VMAccessType) error {
+	if vmAccess != VMAccessTypeGroup {
+		return nil
+	}
+	log.G(ctx).WithField("path", hostPath).Debug("releasing vm group access")
+	return security.ReleaseVmGroupAccess(hostPath)
+}
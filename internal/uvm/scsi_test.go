@@ -0,0 +1,82 @@
+package uvm
+
+import (
+	"context"
+	"testing"
+)
+
+func Test_findSCSISlot_pack(t *testing.T) {
+	u := &UtilityVM{scsiControllerCount: 1}
+	u.scsiLocations[0][0] = &SCSIMount{}
+
+	c, l, err := u.findSCSISlot(context.Background(), 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c != 0 || l != 1 {
+		t.Fatalf("expected the next free slot after 0:0, got %d:%d", c, l)
+	}
+}
+
+func Test_findSCSISlot_spread(t *testing.T) {
+	u := &UtilityVM{scsiControllerCount: 1, scsiAllocationPolicy: SCSIAllocationPolicySpread}
+	u.scsiLocations[0][0] = &SCSIMount{}
+
+	c, l, err := u.findSCSISlot(context.Background(), 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c != 0 || l != scsiSlotsPerController-1 {
+		t.Fatalf("expected the slot farthest from 0:0 (lun %d), got %d:%d", scsiSlotsPerController-1, c, l)
+	}
+}
+
+func Test_findSCSISlot_reservedSlotsHeldBack(t *testing.T) {
+	u := &UtilityVM{scsiControllerCount: 1}
+	for lun := 0; lun < scsiSlotsPerController-1; lun++ {
+		u.scsiLocations[0][lun] = &SCSIMount{}
+	}
+
+	// One free slot remains, but it's inside the reserved pool: a regular
+	// allocation must not be handed it.
+	if _, _, err := u.findSCSISlot(context.Background(), 1); err != ErrNoAvailableLocation {
+		t.Fatalf("expected ErrNoAvailableLocation, got %v", err)
+	}
+
+	// allocateSCSISlot falls back to the reserved pool once nothing else is
+	// free.
+	sm, err := u.allocateSCSISlot(context.Background(), "host", "uvm", VMAccessTypeIndividual)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if sm.Controller != 0 || sm.LUN != scsiSlotsPerController-1 {
+		t.Fatalf("expected fallback to the last reserved slot, got %d:%d", sm.Controller, sm.LUN)
+	}
+}
+
+func Test_allocateSCSISlot_noControllers(t *testing.T) {
+	u := &UtilityVM{}
+	if _, err := u.allocateSCSISlot(context.Background(), "host", "uvm", VMAccessTypeIndividual); err != ErrNoSCSIControllers {
+		t.Fatalf("expected ErrNoSCSIControllers, got %v", err)
+	}
+}
+
+func Test_SCSICapacity(t *testing.T) {
+	u := &UtilityVM{scsiControllerCount: 1, scsiReservedSlotCount: 4}
+	u.scsiLocations[0][0] = &SCSIMount{}
+	u.scsiLocations[0][1] = &SCSIMount{}
+
+	got := u.SCSICapacity()
+	if got.Total != scsiSlotsPerController {
+		t.Fatalf("expected total %d, got %d", scsiSlotsPerController, got.Total)
+	}
+	if got.Used != 2 {
+		t.Fatalf("expected used 2, got %d", got.Used)
+	}
+	if got.Reserved != 4 {
+		t.Fatalf("expected reserved 4, got %d", got.Reserved)
+	}
+	if got.Available != scsiSlotsPerController-2-4 {
+		t.Fatalf("expected available %d, got %d", scsiSlotsPerController-2-4, got.Available)
+	}
+}
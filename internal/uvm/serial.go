@@ -0,0 +1,56 @@
+package uvm
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/Microsoft/hcsshim/internal/requesttype"
+	hcsschema "github.com/Microsoft/hcsshim/internal/schema2"
+)
+
+// ComPort represents a hot-added serial port (COM1-COM4) backed by a named
+// pipe on the host, connected via AddComPort.
+type ComPort struct {
+	vm        *UtilityVM
+	port      uint8
+	namedPipe string
+}
+
+// String returns a description of the com port for diagnostics.
+func (c *ComPort) String() string {
+	return fmt.Sprintf("com port %d -> %s", c.port+1, c.namedPipe)
+}
+
+// Release frees the resources of the corresponding com port.
+func (c *ComPort) Release(ctx context.Context) error {
+	if err := c.vm.RemoveComPort(ctx, c.port); err != nil {
+		return fmt.Errorf("failed to remove com port: %s", err)
+	}
+	return nil
+}
+
+// AddComPort hot-adds a serial port to the uVM, connecting it to namedPipe on
+// the host. port is zero-based, so port 0 is COM1, port 1 is COM2, and so on,
+// matching the numbering HCS itself uses in VirtualMachine.Devices.ComPorts.
+func (uvm *UtilityVM) AddComPort(ctx context.Context, port uint8, namedPipe string) (*ComPort, error) {
+	modification := newModifyRequest(
+		fmt.Sprintf(serialResourceFormat, port),
+		requesttype.Add,
+		hcsschema.ComPort{NamedPipe: namedPipe},
+	)
+	if err := uvm.modify(ctx, modification); err != nil {
+		return nil, err
+	}
+	return &ComPort{vm: uvm, port: port, namedPipe: namedPipe}, nil
+}
+
+// RemoveComPort hot-removes the serial port at port (zero-based, so 0 is
+// COM1) from the uVM.
+func (uvm *UtilityVM) RemoveComPort(ctx context.Context, port uint8) error {
+	modification := newModifyRequest(
+		fmt.Sprintf(serialResourceFormat, port),
+		requesttype.Remove,
+		hcsschema.ComPort{},
+	)
+	return uvm.modify(ctx, modification)
+}
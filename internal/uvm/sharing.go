@@ -0,0 +1,67 @@
+package uvm
+
+import "sync"
+
+// sharedUVM tracks a utility VM that multiple pod sandboxes have opted,
+// via a shared key, to reuse instead of each getting their own.
+//
+// NOTE: this registry is process-local, so sharing only actually takes
+// effect across pods hosted by the same process. Today every pod sandbox
+// gets its own shim process, so in practice each key only ever has one
+// acquirer; this becomes useful once something hosts multiple pod
+// sandboxes in a single process.
+type sharedUVM struct {
+	vm       *UtilityVM
+	refCount uint32
+}
+
+var (
+	sharedUVMsMu sync.Mutex
+	sharedUVMs   = map[string]*sharedUVM{}
+)
+
+// AcquireSharedUVM returns the utility VM already registered under `key`,
+// incrementing its ref count, or else calls `create` to make one and
+// registers the result under `key` with a ref count of 1.
+//
+// `owned` is `true` if `create` was called: the caller is the owner of the
+// returned VM and is responsible for starting it and eventually calling
+// ReleaseSharedUVM(key). If `false`, some other caller already owns the VM
+// and this caller must not start or close it directly.
+func AcquireSharedUVM(key string, create func() (*UtilityVM, error)) (vm *UtilityVM, owned bool, err error) {
+	sharedUVMsMu.Lock()
+	defer sharedUVMsMu.Unlock()
+
+	if shared, ok := sharedUVMs[key]; ok {
+		shared.refCount++
+		return shared.vm, false, nil
+	}
+
+	vm, err = create()
+	if err != nil {
+		return nil, false, err
+	}
+	sharedUVMs[key] = &sharedUVM{vm: vm, refCount: 1}
+	return vm, true, nil
+}
+
+// ReleaseSharedUVM decrements the ref count of the utility VM registered
+// under `key` and, once it drops to zero, unregisters and closes it. It is a
+// no-op if `key` is not registered.
+func ReleaseSharedUVM(key string) error {
+	sharedUVMsMu.Lock()
+	shared, ok := sharedUVMs[key]
+	if !ok {
+		sharedUVMsMu.Unlock()
+		return nil
+	}
+	shared.refCount--
+	if shared.refCount > 0 {
+		sharedUVMsMu.Unlock()
+		return nil
+	}
+	delete(sharedUVMs, key)
+	sharedUVMsMu.Unlock()
+
+	return shared.vm.Close()
+}
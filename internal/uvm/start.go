@@ -13,6 +13,7 @@ import (
 	"syscall"
 	"time"
 
+	hcserrdefs "github.com/Microsoft/hcsshim/internal/errdefs"
 	"github.com/Microsoft/hcsshim/internal/gcs"
 	"github.com/Microsoft/hcsshim/internal/guestrequest"
 	"github.com/Microsoft/hcsshim/internal/log"
@@ -20,6 +21,7 @@ import (
 	"github.com/Microsoft/hcsshim/internal/requesttype"
 	"github.com/Microsoft/hcsshim/internal/schema1"
 	hcsschema "github.com/Microsoft/hcsshim/internal/schema2"
+	"github.com/pkg/errors"
 	"github.com/sirupsen/logrus"
 	"golang.org/x/sync/errgroup"
 )
@@ -45,7 +47,8 @@ type gcsLogEntry struct {
 }
 
 // FUTURE-jstarks: Change the GCS log format to include type information
-//                 (e.g. by using a different encoding such as protobuf).
+//
+//	(e.g. by using a different encoding such as protobuf).
 func (e *gcsLogEntry) UnmarshalJSON(b []byte) error {
 	// Default the log level to info.
 	e.Level = logrus.InfoLevel
@@ -210,6 +213,15 @@ func (uvm *UtilityVM) Start(ctx context.Context) (err error) {
 		}
 	}
 
+	// reserve a minimum share of host CPU time, if requested, so a
+	// latency-critical uVM's vCPU's are less likely to be descheduled to
+	// idle between bursts of work
+	if uvm.processorReservation != 0 {
+		if err := uvm.UpdateCPULimits(ctx, &hcsschema.ProcessorLimits{Reservation: uvm.processorReservation}); err != nil {
+			return err
+		}
+	}
+
 	// Start waiting on the utility VM.
 	uvm.exitCh = make(chan struct{})
 	go func() {
@@ -232,7 +244,7 @@ func (uvm *UtilityVM) Start(ctx context.Context) (err error) {
 		conn, err := uvm.acceptAndClose(ctx, uvm.gcListener)
 		uvm.gcListener = nil
 		if err != nil {
-			return fmt.Errorf("failed to connect to GCS: %s", err)
+			return errors.Wrapf(hcserrdefs.ErrGuestUnreachable, "failed to connect to GCS: %s", err)
 		}
 		// Start the GCS protocol.
 		gcc := &gcs.GuestConnectionConfig{
@@ -242,7 +254,7 @@ func (uvm *UtilityVM) Start(ctx context.Context) (err error) {
 		}
 		uvm.gc, err = gcc.Connect(ctx)
 		if err != nil {
-			return err
+			return errors.Wrap(hcserrdefs.ErrGuestUnreachable, err.Error())
 		}
 		uvm.guestCaps = *uvm.gc.Capabilities()
 		uvm.protocol = uvm.gc.Protocol()
@@ -261,6 +273,12 @@ func (uvm *UtilityVM) Start(ctx context.Context) (err error) {
 		uvm.protocol = properties.GuestConnectionInfo.ProtocolVersion
 	}
 
+	if len(uvm.firewallRules) > 0 {
+		if err := uvm.ApplyFirewallRules(ctx, uvm.firewallRules); err != nil {
+			return fmt.Errorf("failed to apply firewall rules: %s", err)
+		}
+	}
+
 	return nil
 }
 
@@ -39,13 +39,17 @@ type namespaceInfo struct {
 
 // UtilityVM is the object used by clients representing a utility VM
 type UtilityVM struct {
-	id               string               // Identifier for the utility VM (user supplied or generated)
-	runtimeID        guid.GUID            // Hyper-V VM ID
-	owner            string               // Owner for the utility VM (user supplied or generated)
-	operatingSystem  string               // "windows" or "linux"
-	hcsSystem        *hcs.System          // The handle to the compute system
-	gcListener       net.Listener         // The GCS connection listener
-	gc               *gcs.GuestConnection // The GCS connection
+	id              string               // Identifier for the utility VM (user supplied or generated)
+	runtimeID       guid.GUID            // Hyper-V VM ID
+	owner           string               // Owner for the utility VM (user supplied or generated)
+	operatingSystem string               // "windows" or "linux"
+	hcsSystem       *hcs.System          // The handle to the compute system
+	gcListener      net.Listener         // The GCS connection listener
+	gc              *gcs.GuestConnection // The GCS connection
+	// vsockListenFn creates a listener for a well-known guest hvsocket
+	// service ID (the GCS bridge, output, or entropy channels). Defaults to
+	// an AF_HYPERV (hvsocket) listener; see Options.VSockListenFn.
+	vsockListenFn    func(serviceID guid.GUID) (net.Listener, error)
 	processorCount   int32
 	physicallyBacked bool       // If the uvm is backed by physical memory and not virtual memory
 	m                sync.Mutex // Lock for adding/removing devices
@@ -53,6 +57,12 @@ type UtilityVM struct {
 	exitErr error
 	exitCh  chan struct{}
 
+	// closeStage records how far a prior call to Close got through its
+	// staged teardown, so a later call (e.g. from a janitor retrying a UVM
+	// whose Close previously timed out) resumes instead of repeating
+	// completed stages. See close.go. Access via sync/atomic.
+	closeStage int32
+
 	// devicesPhysicallyBacked indicates if additional devices added to a uvm should be
 	// entirely physically backed
 	devicesPhysicallyBacked bool
@@ -75,7 +85,6 @@ type UtilityVM struct {
 	// part of a temporary fix to allow WCOW single-file mapping to function.
 	vsmbDirShares  map[string]*VSMBShare
 	vsmbFileShares map[string]*VSMBShare
-	vsmbCounter    uint64 // Counter to generate a unique share name for each VSMB share.
 
 	// VPMEM devices that are mapped into a Linux UVM. These are used for read-only layers, or for
 	// booting from VHD.
@@ -84,16 +93,38 @@ type UtilityVM struct {
 	vpmemMaxSizeBytes uint64                    // The max size of the layer in bytes per vPMem device.
 
 	// SCSI devices that are mapped into a Windows or Linux utility VM
-	scsiLocations       [4][64]*SCSIMount // Hyper-V supports 4 controllers, 64 slots per controller. Limited to 1 controller for now though.
-	scsiControllerCount uint32            // Number of SCSI controllers in the utility VM
+	scsiLocations         [4][64]*SCSIMount    // Hyper-V supports 4 controllers, 64 slots per controller. Limited to 1 controller for now though.
+	scsiControllerCount   uint32               // Number of SCSI controllers in the utility VM
+	scsiAllocationPolicy  SCSIAllocationPolicy // How allocateSCSISlot picks the next free location
+	scsiReservedSlotCount uint32               // SCSI locations held back from allocateSCSISlot until every other one is in use
+
+	firewallRules []string // Rules applied to the guest via ApplyFirewallRules once the GCS connection comes up, see Start
 
 	vpciDevices map[string]*VPCIDevice // map of device instance id to vpci device
+	// deviceEvents fans out arrival/removal events for vpciDevices to
+	// subscribers registered via SubscribeToDeviceEvents.
+	deviceEvents deviceEventBroadcaster
 
 	// Plan9 are directories mapped into a Linux utility VM
-	plan9Counter uint64 // Each newly-added plan9 share has a counter used as its ID in the ResourceURI and for the name
+	// plan9Shares are ref-counted by host path, the same way vsmbDirShares/
+	// vsmbFileShares are for Windows, so that containers sharing a uVM (e.g.
+	// containers in the same pod sharing an emptyDir) reuse the same share
+	// instead of adding a duplicate one each.
+	plan9Shares map[string]*Plan9Share
+	// plan9UseShareRootIdentity configures every Plan9 share added to this
+	// uVM to use the share root's identity for guest-side I/O; see
+	// OptionsLCOW.Plan9UseShareRootIdentity.
+	plan9UseShareRootIdentity bool
 
 	namespaces map[string]*namespaceInfo
 
+	// ccgInstances are the Container Credential Guard instances hot-added to
+	// this uVM, ref-counted by credential spec the same way vsmbDirShares/
+	// plan9Shares are by path, so that containers in the same pod sharing a
+	// GMSA credential spec reuse the same CCG instance instead of each paying
+	// CCG's multi-second setup cost.
+	ccgInstances map[string]*CCGInstance
+
 	outputListener       net.Listener
 	outputProcessingDone chan struct{}
 	outputHandler        OutputHandler
@@ -116,4 +147,9 @@ type UtilityVM struct {
 
 	// cpuGroupID is the ID of the cpugroup on the host that this UVM is assigned to
 	cpuGroupID string
+
+	// processorReservation is the minimum amount of host CPU time, in
+	// ten-thousandths of a percent, the UVM's vCPU's are guaranteed to get.
+	// Applied once the UVM starts (see Start). See Options.ProcessorReservation.
+	processorReservation uint64
 }
@@ -53,6 +53,11 @@ func (uvm *UtilityVM) GetAssignedDeviceVMBUSInstanceID(vmBusChannelGUID string)
 	return fmt.Sprintf("%s\\%s\\{%s}", assignedDeviceEnumerator, vmbusChannelTypeGUIDFormatted, vmBusChannelGUID)
 }
 
+// String returns a description of the vpci device for diagnostics.
+func (vpci *VPCIDevice) String() string {
+	return fmt.Sprintf("vpci device %s (vmbus %s)", vpci.deviceInstanceID, vpci.VMBusGUID)
+}
+
 // Release frees the resources of the corresponding vpci device
 func (vpci *VPCIDevice) Release(ctx context.Context) error {
 	if err := vpci.vm.removeDevice(ctx, vpci.deviceInstanceID); err != nil {
@@ -121,9 +126,32 @@ func (uvm *UtilityVM) AssignDevice(ctx context.Context, deviceID string) (*VPCID
 		refCount:         1,
 	}
 	uvm.vpciDevices[deviceID] = result
+	uvm.deviceEvents.publish(DeviceEvent{Type: DeviceEventTypeArrival, DeviceInstanceID: deviceID})
 	return result, nil
 }
 
+// AssignedVPCIDeviceIDs returns the host device instance IDs of every device
+// currently VPCI-assigned to the uvm, including any GPUs assigned via
+// GPUDeviceIDType. Callers can use these to correlate the uvm with host-side
+// GPU inventory/monitoring tools.
+//
+// This does not report utilization or memory usage: the HCS statistics
+// schema (hcsschema.Statistics) has no GPU counters, and this module has no
+// code to query host WDDM performance counters or, for LCOW, to scrape NVML
+// from inside the guest (there is no vendored GCS guest agent in this repo
+// to extend). Collecting those would require new infrastructure outside
+// this package's scope.
+func (uvm *UtilityVM) AssignedVPCIDeviceIDs() []string {
+	uvm.m.Lock()
+	defer uvm.m.Unlock()
+
+	ids := make([]string, 0, len(uvm.vpciDevices))
+	for deviceID := range uvm.vpciDevices {
+		ids = append(ids, deviceID)
+	}
+	return ids
+}
+
 // removeDevice removes a vpci device from a uvm when there are
 // no more references to a given VPCIDevice. Otherwise, decrements
 // the reference count of the stored VPCIDevice and returns nil.
@@ -139,10 +167,13 @@ func (uvm *UtilityVM) removeDevice(ctx context.Context, deviceInstanceID string)
 	vpci.refCount--
 	if vpci.refCount == 0 {
 		delete(uvm.vpciDevices, deviceInstanceID)
-		return uvm.modify(ctx, &hcsschema.ModifySettingRequest{
+		if err := uvm.modify(ctx, &hcsschema.ModifySettingRequest{
 			ResourcePath: fmt.Sprintf(virtualPciResourceFormat, vpci.VMBusGUID),
 			RequestType:  requesttype.Remove,
-		})
+		}); err != nil {
+			return err
+		}
+		uvm.deviceEvents.publish(DeviceEvent{Type: DeviceEventTypeRemoval, DeviceInstanceID: deviceInstanceID})
 	}
 	return nil
 }
@@ -55,6 +55,49 @@ func (uvm *UtilityVM) findVPMEMDevice(ctx context.Context, findThisHostPath stri
 	return 0, ErrNotAttached
 }
 
+// VPMemMapping describes one occupied vPMEM device slot.
+type VPMemMapping struct {
+	// DeviceNumber is the vPMEM device's index, in [0, VPMemDeviceCount).
+	DeviceNumber uint32
+	// HostPath is the layer VHD backing the device.
+	HostPath string
+	// UVMPath is the path at which the device is mounted in the guest.
+	UVMPath string
+	// RefCount is the number of containers currently using this layer.
+	RefCount uint32
+}
+
+// VPMemMappings returns the current occupant of every in-use vPMEM device
+// slot, letting a caller report which layers occupy which vPMEM regions --
+// e.g. to debug a "no space on vPMEM" failure from AddVPMEM, which means
+// every one of the uVM's `VPMemDeviceCount` slots already holds a distinct
+// layer.
+//
+// Each slot holds exactly one layer: this package has no support for
+// multi-mapping (packing more than one layer's address range into a single
+// vPMEM device), which would let a uVM serve more layers than it has vPMEM
+// slots. That needs a GCS guest request richer than
+// guestrequest.LCOWMappedVPMemDevice (at minimum a byte offset and length
+// per mapping) plus matching support in the guest agent; this repo vendors
+// neither.
+func (uvm *UtilityVM) VPMemMappings() []VPMemMapping {
+	uvm.m.Lock()
+	defer uvm.m.Unlock()
+
+	var mappings []VPMemMapping
+	for i := uint32(0); i < uvm.vpmemMaxCount; i++ {
+		if vi := uvm.vpmemDevices[i]; vi != nil {
+			mappings = append(mappings, VPMemMapping{
+				DeviceNumber: i,
+				HostPath:     vi.hostPath,
+				UVMPath:      vi.uvmPath,
+				RefCount:     vi.refCount,
+			})
+		}
+	}
+	return mappings
+}
+
 // AddVPMEM adds a VPMEM disk to a utility VM at the next available location and
 // returns the UVM path where the layer was mounted.
 func (uvm *UtilityVM) AddVPMEM(ctx context.Context, hostPath string) (_ string, err error) {
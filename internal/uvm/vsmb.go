@@ -5,7 +5,6 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
-	"strconv"
 	"unsafe"
 
 	"github.com/Microsoft/hcsshim/internal/log"
@@ -31,6 +30,11 @@ type VSMBShare struct {
 	readOnly     bool
 }
 
+// String returns a description of the vsmb share for diagnostics.
+func (vsmb *VSMBShare) String() string {
+	return fmt.Sprintf("vsmb share %s -> %s", vsmb.HostPath, vsmb.guestPath)
+}
+
 // Release frees the resources of the corresponding vsmb Mount
 func (vsmb *VSMBShare) Release(ctx context.Context) error {
 	if err := vsmb.vm.RemoveVSMB(ctx, vsmb.HostPath, vsmb.readOnly); err != nil {
@@ -54,6 +58,48 @@ func (uvm *UtilityVM) DefaultVSMBOptions(readOnly bool) *hcsschema.VirtualSmbSha
 	return opts
 }
 
+// DefaultLazyVSMBOptions returns the VSMB options to use for a share whose
+// backing file is populated on demand by something other than hcsshim (e.g.
+// a remote snapshotter lazily fetching blocks of an image layer as the guest
+// reads them). It starts from the default read-only options and layers on:
+//
+//   - NoDirectmap: direct map pins the guest's view to the host file's
+//     current pages, which only makes sense once the file is fully and
+//     stably populated. For a file still being filled in that would let the
+//     guest see whatever bytes happen to be on disk at map time, not what
+//     the snapshotter has actually fetched.
+//   - NonCacheIo: bypasses the VSMB host cache so the guest always observes
+//     the snapshotter's latest writes instead of a cached read from before
+//     the relevant region was populated.
+func (uvm *UtilityVM) DefaultLazyVSMBOptions() *hcsschema.VirtualSmbShareOptions {
+	opts := uvm.DefaultVSMBOptions(true)
+	opts.NoDirectmap = true
+	opts.NonCacheIo = true
+	return opts
+}
+
+// DirectMapVSMBSupported reports whether a direct-mapped (NoDirectmap:
+// false) read-only VSMB share can safely be used for hostPath on this host.
+//
+// Direct mapping pins the guest's view to the host file's pages in place of
+// going through the VSMB host cache, which is faster to read from but is
+// unsafe when the uVM has physically backed devices assigned (see
+// DevicesPhysicallyBacked), or when the host build/filesystem combination is
+// one AddVSMB would otherwise force NoDirectmap for (see forceNoDirectMap).
+// Callers that want to opt a read-only share into direct mapping for
+// performance should check this first and surface a clear error instead of
+// silently falling back to the cached default.
+func (uvm *UtilityVM) DirectMapVSMBSupported(hostPath string) (bool, error) {
+	if uvm.DevicesPhysicallyBacked() {
+		return false, nil
+	}
+	force, err := forceNoDirectMap(hostPath)
+	if err != nil {
+		return false, err
+	}
+	return !force, nil
+}
+
 // findVSMBShare finds a share by `hostPath`. If not found returns `ErrNotAttached`.
 func (uvm *UtilityVM) findVSMBShare(ctx context.Context, m map[string]*VSMBShare, shareKey string) (*VSMBShare, error) {
 	share, ok := m[shareKey]
@@ -68,9 +114,10 @@ func (uvm *UtilityVM) findVSMBShare(ctx context.Context, m map[string]*VSMBShare
 // function is intended to return a handle suitable for use with GetFileInformationByHandleEx.
 //
 // We are not able to use builtin Go functionality for opening a directory path:
-// - os.Open on a directory returns a os.File where Fd() is a search handle from FindFirstFile.
-// - syscall.Open does not provide a way to specify FILE_FLAG_BACKUP_SEMANTICS, which is needed to
-//   open a directory.
+//   - os.Open on a directory returns a os.File where Fd() is a search handle from FindFirstFile.
+//   - syscall.Open does not provide a way to specify FILE_FLAG_BACKUP_SEMANTICS, which is needed to
+//     open a directory.
+//
 // We could use os.Open if the path is a file, but it's easier to just use the same code for both.
 // Therefore, we call windows.CreateFile directly.
 func openHostPath(path string) (windows.Handle, error) {
@@ -168,8 +215,19 @@ func (uvm *UtilityVM) AddVSMB(ctx context.Context, hostPath string, options *hcs
 	share, err := uvm.findVSMBShare(ctx, m, shareKey)
 	if err == ErrNotAttached {
 		requestType = requesttype.Add
-		uvm.vsmbCounter++
-		shareName := "s" + strconv.FormatUint(uvm.vsmbCounter, 16)
+		shareName := deterministicResourceName("s", shareKey, func(name string) bool {
+			for _, s := range uvm.vsmbDirShares {
+				if s.name == name {
+					return true
+				}
+			}
+			for _, s := range uvm.vsmbFileShares {
+				if s.name == name {
+					return true
+				}
+			}
+			return false
+		})
 
 		share = &VSMBShare{
 			vm:        uvm,
@@ -216,8 +274,30 @@ func (uvm *UtilityVM) AddVSMB(ctx context.Context, hostPath string, options *hcs
 }
 
 // RemoveVSMB removes a VSMB share from a utility VM. Each VSMB share is ref-counted
-// and only actually removed when the ref-count drops to zero.
+// and only actually removed when the ref-count drops to zero. If the HCS
+// remove call fails, the share is left attached and an error is returned;
+// see ForceRemoveVSMB to remove it regardless.
 func (uvm *UtilityVM) RemoveVSMB(ctx context.Context, hostPath string, readOnly bool) error {
+	return uvm.removeVSMB(ctx, hostPath, readOnly, false)
+}
+
+// ForceRemoveVSMB is the same as RemoveVSMB, except that if the HCS remove
+// call fails the share is still dropped from hcsshim's own bookkeeping, and
+// the returned error is annotated with the host processes (if any) that the
+// Windows Restart Manager reports as still holding hostPath open, to help
+// diagnose why the underlying directory or file can't be released. Use this
+// only when the caller no longer cares whether the guest still considers
+// the share mounted (e.g. tearing down a uVM that failed to shut down),
+// since it can leave the VSMB share object attached on the host side.
+//
+// Restart Manager only sees host-side opens; this codebase has no GCS RPC
+// for enumerating handles held inside the guest, so a process in the UVM
+// still using the share is not reported.
+func (uvm *UtilityVM) ForceRemoveVSMB(ctx context.Context, hostPath string, readOnly bool) error {
+	return uvm.removeVSMB(ctx, hostPath, readOnly, true)
+}
+
+func (uvm *UtilityVM) removeVSMB(ctx context.Context, hostPath string, readOnly, force bool) error {
 	if uvm.operatingSystem != "windows" {
 		return errNotSupported
 	}
@@ -252,7 +332,13 @@ func (uvm *UtilityVM) RemoveVSMB(ctx context.Context, hostPath string, readOnly
 		ResourcePath: vSmbShareResourcePath,
 	}
 	if err := uvm.modify(ctx, modification); err != nil {
-		return fmt.Errorf("failed to remove vsmb share %s from %s: %+v: %s", hostPath, uvm.id, modification, err)
+		if !force {
+			return fmt.Errorf("failed to remove vsmb share %s from %s: %+v: %s", hostPath, uvm.id, modification, err)
+		}
+		log.G(ctx).WithError(err).WithFields(logrus.Fields{
+			"hostPath":  hostPath,
+			"openHosts": describeHostOpenHandles(hostPath),
+		}).Warn("failed to remove vsmb share, forcing removal from bookkeeping anyway")
 	}
 
 	delete(m, shareKey)
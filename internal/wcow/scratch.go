@@ -2,6 +2,7 @@ package wcow
 
 import (
 	"context"
+	"errors"
 	"os"
 	"path/filepath"
 
@@ -9,9 +10,27 @@ import (
 	"github.com/Microsoft/hcsshim/internal/wclayer"
 )
 
+// ErrEncryptedScratchDiskNotSupported is returned by CreateUVMScratch when
+// asked for an encrypted scratch disk. sandbox.vhdx is attached to the UVM as
+// a raw SCSI disk and formatted by the guest, not mounted as a host volume at
+// any point in its creation, so there is no host-side volume here for
+// BitLocker (or any other host-mounted-volume encryption mechanism) to act
+// on. Encrypting it at rest needs either a guest-side formatting step the
+// shim doesn't control, or new platform bindings to attach/format/encrypt the
+// VHDX from the host before handing it to HCS -- neither of which exists in
+// this repo today. Surfacing an explicit error here, rather than silently
+// creating an unencrypted scratch disk, is deliberate: see
+// oci.AnnotationEncryptedScratchDisk.
+var ErrEncryptedScratchDiskNotSupported = errors.New("encrypted scratch disk is not supported")
+
 // CreateUVMScratch is a helper to create a scratch for a Windows utility VM
-// with permissions to the specified VM ID in a specified directory
-func CreateUVMScratch(ctx context.Context, imagePath, destDirectory, vmID string) error {
+// with permissions to the specified VM ID in a specified directory. If
+// encrypted is true, returns ErrEncryptedScratchDiskNotSupported instead of
+// creating an unencrypted scratch disk.
+func CreateUVMScratch(ctx context.Context, imagePath, destDirectory, vmID string, encrypted bool) error {
+	if encrypted {
+		return ErrEncryptedScratchDiskNotSupported
+	}
 	sourceScratch := filepath.Join(imagePath, `UtilityVM\SystemTemplate.vhdx`)
 	targetScratch := filepath.Join(destDirectory, "sandbox.vhdx")
 	if err := copyfile.CopyFile(ctx, sourceScratch, targetScratch, true); err != nil {
@@ -6,6 +6,13 @@ import "github.com/Microsoft/go-winio/pkg/guid"
 //sys CMGetDeviceIDList(pszFilter *byte, buffer *byte, bufferLen uint32, uFlags uint32) (hr error)= cfgmgr32.CM_Get_Device_ID_ListA
 //sys CMLocateDevNode(pdnDevInst *uint32, pDeviceID string, uFlags uint32) (hr error) = cfgmgr32.CM_Locate_DevNodeW
 //sys CMGetDevNodeProperty(dnDevInst uint32, propertyKey *DevPropKey, propertyType *uint32, propertyBuffer *uint16, propertyBufferSize *uint32, uFlags uint32) (hr error) = cfgmgr32.CM_Get_DevNode_PropertyW
+//sys CMGetDevNodeStatus(pulStatus *uint32, pulProblemNumber *uint32, dnDevInst uint32, uFlags uint32) (hr error) = cfgmgr32.CM_Get_DevNode_Status
+
+// DN_STARTED indicates that the devinst's driver has been loaded and the
+// device has been started, as returned in the status field of
+// CMGetDevNodeStatus. A device that has been dismounted for VM assignment
+// will not have this bit set.
+const DN_STARTED uint32 = 0x00000008
 
 type DevPropKey struct {
 	Fmtid guid.GUID
@@ -21,6 +21,20 @@ const (
 	JOB_OBJECT_MSG_NOTIFICATION_LIMIT    uint32 = 11
 )
 
+// Memory limit flags used with JobObjectNotificationLimitInformation. Unlike
+// the JOB_OBJECT_LIMIT_JOB_MEMORY flag used with
+// JobObjectExtendedLimitInformation (which terminates a process in the job
+// as soon as the job's memory limit is exceeded), these let a caller set a
+// limit that only generates a JOB_OBJECT_MSG_NOTIFICATION_LIMIT completion
+// port message: _LOW for a soft, notify-only threshold, _HIGH for a hard one
+// that still terminates.
+//
+// https://docs.microsoft.com/en-us/windows/win32/api/winnt/ns-winnt-jobobject_notification_limit_information
+const (
+	JOB_OBJECT_LIMIT_JOB_MEMORY_HIGH uint32 = 0x00400000
+	JOB_OBJECT_LIMIT_JOB_MEMORY_LOW  uint32 = 0x00800000
+)
+
 // IO limit flags
 //
 // https://docs.microsoft.com/en-us/windows/win32/api/jobapi2/ns-jobapi2-jobobject_io_rate_control_information
@@ -45,11 +59,32 @@ const (
 	JobObjectBasicProcessIdList              uint32 = 3
 	JobObjectBasicAndIoAccountingInformation uint32 = 8
 	JobObjectLimitViolationInformation       uint32 = 13
+	JobObjectNotificationLimitInformation    uint32 = 12
 	JobObjectMemoryUsageInformation          uint32 = 28
 	JobObjectNotificationLimitInformation2   uint32 = 33
 	JobObjectIoAttribution                   uint32 = 42
 )
 
+// JobObjectGroupInformationEx is also a valid JobObjectInformationClass value for a call to
+// SetInformationJobObject, used to confine every process in the job to a specific set of
+// processor groups and the logical processors within them. This is what lets a job be pinned
+// to specific cores on a host with more than 64 logical processors (which Windows splits into
+// more than one processor group), where a single GROUP_AFFINITY can't span every processor the
+// job should be allowed to run on.
+//
+// https://docs.microsoft.com/en-us/windows/win32/api/jobapi2/nf-jobapi2-setinformationjobobject
+const JobObjectGroupInformationEx uint32 = 24
+
+// GROUP_AFFINITY specifies a processor group number and a processor affinity mask within
+// that group.
+//
+// https://docs.microsoft.com/en-us/windows/win32/api/winnt/ns-winnt-group_affinity
+type GROUP_AFFINITY struct {
+	Mask     uintptr
+	Group    uint16
+	Reserved [3]uint16
+}
+
 // https://docs.microsoft.com/en-us/windows/win32/api/winnt/ns-winnt-jobobject_basic_limit_information
 type JOBOBJECT_BASIC_LIMIT_INFORMATION struct {
 	PerProcessUserTimeLimit int64
@@ -103,29 +138,54 @@ type JOBOBJECT_BASIC_ACCOUNTING_INFORMATION struct {
 	TotalTerminateProcesses   uint32
 }
 
-//https://docs.microsoft.com/en-us/windows/win32/api/winnt/ns-winnt-jobobject_basic_and_io_accounting_information
+// https://docs.microsoft.com/en-us/windows/win32/api/winnt/ns-winnt-jobobject_basic_and_io_accounting_information
 type JOBOBJECT_BASIC_AND_IO_ACCOUNTING_INFORMATION struct {
 	BasicInfo JOBOBJECT_BASIC_ACCOUNTING_INFORMATION
 	IoInfo    windows.IO_COUNTERS
 }
 
-// typedef struct _JOBOBJECT_MEMORY_USAGE_INFORMATION {
-//     ULONG64 JobMemory;
-//     ULONG64 PeakJobMemoryUsed;
-// } JOBOBJECT_MEMORY_USAGE_INFORMATION, *PJOBOBJECT_MEMORY_USAGE_INFORMATION;
-//
+// https://docs.microsoft.com/en-us/windows/win32/api/winnt/ns-winnt-jobobject_notification_limit_information
+type JOBOBJECT_NOTIFICATION_LIMIT_INFORMATION struct {
+	IoReadBytesLimit             uint64
+	IoWriteBytesLimit            uint64
+	PerJobUserTimeLimit          int64
+	JobMemoryLimit               uint64
+	RateControlTolerance         uint32
+	RateControlToleranceInterval uint32
+	LimitFlags                   uint32
+}
+
+// https://docs.microsoft.com/en-us/windows/win32/api/winnt/ns-winnt-jobobject_limit_violation_information
+type JOBOBJECT_LIMIT_VIOLATION_INFORMATION struct {
+	LimitFlags                uint32
+	ViolationLimitFlags       uint32
+	IoReadBytes               uint64
+	IoReadBytesLimit          uint64
+	IoWriteBytes              uint64
+	IoWriteBytesLimit         uint64
+	PerJobUserTime            int64
+	PerJobUserTimeLimit       int64
+	JobMemory                 uint64
+	JobMemoryLimit            uint64
+	RateControlTolerance      uint32
+	RateControlToleranceLimit uint32
+}
+
+//	typedef struct _JOBOBJECT_MEMORY_USAGE_INFORMATION {
+//	    ULONG64 JobMemory;
+//	    ULONG64 PeakJobMemoryUsed;
+//	} JOBOBJECT_MEMORY_USAGE_INFORMATION, *PJOBOBJECT_MEMORY_USAGE_INFORMATION;
 type JOBOBJECT_MEMORY_USAGE_INFORMATION struct {
 	JobMemory         uint64
 	PeakJobMemoryUsed uint64
 }
 
-// typedef struct _JOBOBJECT_IO_ATTRIBUTION_STATS {
-//     ULONG_PTR IoCount;
-//     ULONGLONG TotalNonOverlappedQueueTime;
-//     ULONGLONG TotalNonOverlappedServiceTime;
-//     ULONGLONG TotalSize;
-// } JOBOBJECT_IO_ATTRIBUTION_STATS, *PJOBOBJECT_IO_ATTRIBUTION_STATS;
-//
+//	typedef struct _JOBOBJECT_IO_ATTRIBUTION_STATS {
+//	    ULONG_PTR IoCount;
+//	    ULONGLONG TotalNonOverlappedQueueTime;
+//	    ULONGLONG TotalNonOverlappedServiceTime;
+//	    ULONGLONG TotalSize;
+//	} JOBOBJECT_IO_ATTRIBUTION_STATS, *PJOBOBJECT_IO_ATTRIBUTION_STATS;
 type JOBOBJECT_IO_ATTRIBUTION_STATS struct {
 	IoCount                       uintptr
 	TotalNonOverlappedQueueTime   uint64
@@ -133,12 +193,11 @@ type JOBOBJECT_IO_ATTRIBUTION_STATS struct {
 	TotalSize                     uint64
 }
 
-// typedef struct _JOBOBJECT_IO_ATTRIBUTION_INFORMATION {
-//     ULONG ControlFlags;
-//     JOBOBJECT_IO_ATTRIBUTION_STATS ReadStats;
-//     JOBOBJECT_IO_ATTRIBUTION_STATS WriteStats;
-// } JOBOBJECT_IO_ATTRIBUTION_INFORMATION, *PJOBOBJECT_IO_ATTRIBUTION_INFORMATION;
-//
+//	typedef struct _JOBOBJECT_IO_ATTRIBUTION_INFORMATION {
+//	    ULONG ControlFlags;
+//	    JOBOBJECT_IO_ATTRIBUTION_STATS ReadStats;
+//	    JOBOBJECT_IO_ATTRIBUTION_STATS WriteStats;
+//	} JOBOBJECT_IO_ATTRIBUTION_INFORMATION, *PJOBOBJECT_IO_ATTRIBUTION_INFORMATION;
 type JOBOBJECT_IO_ATTRIBUTION_INFORMATION struct {
 	ControlFlags uint32
 	ReadStats    JOBOBJECT_IO_ATTRIBUTION_STATS
@@ -9,3 +9,26 @@ package winapi
 
 //sys LocalAlloc(flags uint32, size int) (ptr uintptr) = kernel32.LocalAlloc
 //sys LocalFree(ptr uintptr) = kernel32.LocalFree
+
+// MemoryStatusEx mirrors the Win32 MEMORYSTATUSEX structure returned by
+// GlobalMemoryStatusEx, reporting current system-wide memory usage.
+// https://docs.microsoft.com/en-us/windows/win32/api/sysinfoapi/ns-sysinfoapi-memorystatusex
+type MemoryStatusEx struct { //revive:disable-line:var-naming Win32 naming convention
+	Length               uint32
+	MemoryLoad           uint32
+	TotalPhys            uint64
+	AvailPhys            uint64
+	TotalPageFile        uint64
+	AvailPageFile        uint64
+	TotalVirtual         uint64
+	AvailVirtual         uint64
+	AvailExtendedVirtual uint64
+}
+
+//sys GlobalMemoryStatusEx(buffer *MemoryStatusEx) (err error) = kernel32.GlobalMemoryStatusEx
+
+// GetSystemTimes reports the host's cumulative idle, kernel, and user CPU
+// time across all logical processors since boot. Sampling it twice and
+// diffing gives the idle percentage over the sampled window.
+// https://docs.microsoft.com/en-us/windows/win32/api/processthreadsapi/nf-processthreadsapi-getsystemtimes
+//sys GetSystemTimes(idleTime *windows.Filetime, kernelTime *windows.Filetime, userTime *windows.Filetime) (err error) = kernel32.GetSystemTimes
@@ -0,0 +1,37 @@
+package winapi
+
+import (
+	"golang.org/x/sys/windows"
+)
+
+//sys RmStartSession(session *uint32, sessionKey *uint16) (win32Err error) = rstrtmgr.RmStartSession
+//sys RmEndSession(session uint32) (win32Err error) = rstrtmgr.RmEndSession
+//sys RmRegisterResources(session uint32, numFiles uint32, fileNames **uint16) (win32Err error) = rstrtmgr.RmRegisterResources
+//sys RmGetList(session uint32, procInfoNeeded *uint32, procInfo *uint32, processInfo *RM_PROCESS_INFO, rebootReasons *uint32) (win32Err error) = rstrtmgr.RmGetList
+
+const (
+	CCH_RM_SESSION_KEY  = 32
+	CCH_RM_MAX_APP_NAME = 255
+	CCH_RM_MAX_SVC_NAME = 63
+)
+
+// RM_UNIQUE_PROCESS identifies a process by PID and creation time, as
+// required by the Restart Manager APIs to disambiguate a PID that has been
+// reused.
+type RM_UNIQUE_PROCESS struct { //revive:disable-line:var-naming
+	ProcessId        uint32
+	ProcessStartTime windows.Filetime
+}
+
+// RM_PROCESS_INFO is the per-process entry returned by RmGetList, describing
+// one process that currently holds a resource registered with
+// RmRegisterResources open.
+type RM_PROCESS_INFO struct { //revive:disable-line:var-naming
+	Process          RM_UNIQUE_PROCESS
+	AppName          [CCH_RM_MAX_APP_NAME + 1]uint16
+	ServiceShortName [CCH_RM_MAX_SVC_NAME + 1]uint16
+	ApplicationType  uint32
+	AppStatus        uint32
+	TSSessionId      uint32 //revive:disable-line:var-naming
+	Restartable      int32
+}
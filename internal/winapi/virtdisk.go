@@ -0,0 +1,90 @@
+package winapi
+
+import (
+	"unsafe"
+)
+
+// GetVirtualDiskInformationVersion selects which member of the
+// GET_VIRTUAL_DISK_INFO union GetVirtualDiskInformation should fill in.
+//
+// https://docs.microsoft.com/en-us/windows/win32/api/virtdisk/ne-virtdisk-get_virtual_disk_info_version
+const (
+	GetVirtualDiskInfoSize                uint32 = 1
+	GetVirtualDiskInfoParentLocation      uint32 = 3
+	GetVirtualDiskInfoChangeTrackingState uint32 = 15
+)
+
+// GetVirtualDiskInfoSizeData is the GET_VIRTUAL_DISK_INFO union member
+// selected by GetVirtualDiskInfoSize.
+//
+// https://docs.microsoft.com/en-us/windows/win32/api/virtdisk/ns-virtdisk-get_virtual_disk_info
+type GetVirtualDiskInfoSizeData struct {
+	VirtualSize  uint64
+	PhysicalSize uint64
+	BlockSize    uint32
+	SectorSize   uint32
+}
+
+// GetVirtualDiskInfoParentLocationData is the fixed-size header of the
+// GET_VIRTUAL_DISK_INFO union member selected by
+// GetVirtualDiskInfoParentLocation. It is followed in the real struct by a
+// variable-length, NUL-terminated UTF-16 ParentLocationBuffer; callers should
+// over-allocate the buffer passed to GetVirtualDiskInformation and read the
+// trailing string out of it themselves, the same way
+// JOBOBJECT_BASIC_PROCESS_ID_LIST.AllPids does for its trailing array.
+//
+// https://docs.microsoft.com/en-us/windows/win32/api/virtdisk/ns-virtdisk-get_virtual_disk_info
+type GetVirtualDiskInfoParentLocationData struct {
+	ParentResolved int32
+	// ParentLocationBuffer WCHAR[1] follows; not represented here.
+}
+
+// GetVirtualDiskInfoChangeTrackingStateData is the fixed-size header of the
+// GET_VIRTUAL_DISK_INFO union member selected by
+// GetVirtualDiskInfoChangeTrackingState. As with
+// GetVirtualDiskInfoParentLocationData, the variable-length MostRecentId
+// UTF-16 string that follows it in the real struct is not represented here.
+//
+// https://docs.microsoft.com/en-us/windows/win32/api/virtdisk/ns-virtdisk-get_virtual_disk_info
+type GetVirtualDiskInfoChangeTrackingStateData struct {
+	Enabled      int32
+	NewerChanges int32
+	// MostRecentId WCHAR[1] follows; not represented here.
+}
+
+// getVirtualDiskInfoHeader is the layout shared by every GET_VIRTUAL_DISK_INFO
+// union member: a four-byte Version selector, followed (after alignment
+// padding on amd64) by the selected member. Callers write Version into the
+// first four bytes of their buffer before calling GetVirtualDiskInformation,
+// then reinterpret the remainder of the buffer as the member matching the
+// version they requested.
+type getVirtualDiskInfoHeader struct {
+	Version uint32
+	_       uint32 // padding to align the union member on an 8-byte boundary
+}
+
+// VirtualDiskInfoVersionOffset is the byte offset of the union member within
+// a GET_VIRTUAL_DISK_INFO buffer, i.e. where a caller should write the
+// selected member's fields after setting Version at offset 0.
+const VirtualDiskInfoVersionOffset = int(unsafe.Sizeof(getVirtualDiskInfoHeader{}))
+
+//sys GetVirtualDiskInformation(handle windows.Handle, virtualDiskInfoSize *uint32, virtualDiskInfo *byte, sizeUsed *uint32) (win32Err error) = virtdisk.GetVirtualDiskInformation
+
+// CompactVirtualDiskFlag values, passed to CompactVirtualDisk.
+//
+// https://docs.microsoft.com/en-us/windows/win32/api/virtdisk/nf-virtdisk-compactvirtualdisk
+const (
+	CompactVirtualDiskFlagNone uint32 = 0
+)
+
+// CompactVirtualDiskParameters is COMPACT_VIRTUAL_DISK_PARAMETERS. Version 1
+// is the only version currently defined, and carries no parameters of its
+// own.
+//
+// https://docs.microsoft.com/en-us/windows/win32/api/virtdisk/ns-virtdisk-compact_virtual_disk_parameters
+type CompactVirtualDiskParameters struct {
+	Version  uint32
+	Reserved uint32
+}
+
+//sys CompactVirtualDisk(handle windows.Handle, flags uint32, parameters *CompactVirtualDiskParameters, overlapped *syscall.Overlapped) (win32Err error) = virtdisk.CompactVirtualDisk
@@ -42,28 +42,39 @@ var (
 	modadvapi32 = windows.NewLazySystemDLL("advapi32.dll")
 	modcfgmgr32 = windows.NewLazySystemDLL("cfgmgr32.dll")
 	modntdll    = windows.NewLazySystemDLL("ntdll.dll")
+	modvirtdisk = windows.NewLazySystemDLL("virtdisk.dll")
+	modrstrtmgr = windows.NewLazySystemDLL("rstrtmgr.dll")
 
 	procSetJobCompartmentId                  = modiphlpapi.NewProc("SetJobCompartmentId")
+	procGetQueuedCompletionStatus            = modkernel32.NewProc("GetQueuedCompletionStatus")
 	procIsProcessInJob                       = modkernel32.NewProc("IsProcessInJob")
 	procQueryInformationJobObject            = modkernel32.NewProc("QueryInformationJobObject")
 	procOpenJobObjectW                       = modkernel32.NewProc("OpenJobObjectW")
 	procSetIoRateControlInformationJobObject = modkernel32.NewProc("SetIoRateControlInformationJobObject")
-	procGetQueuedCompletionStatus            = modkernel32.NewProc("GetQueuedCompletionStatus")
 	procSearchPathW                          = modkernel32.NewProc("SearchPathW")
 	procLogonUserW                           = modadvapi32.NewProc("LogonUserW")
 	procRtlMoveMemory                        = modkernel32.NewProc("RtlMoveMemory")
 	procLocalAlloc                           = modkernel32.NewProc("LocalAlloc")
 	procLocalFree                            = modkernel32.NewProc("LocalFree")
+	procGlobalMemoryStatusEx                 = modkernel32.NewProc("GlobalMemoryStatusEx")
+	procGetSystemTimes                       = modkernel32.NewProc("GetSystemTimes")
 	procGetActiveProcessorCount              = modkernel32.NewProc("GetActiveProcessorCount")
 	procCM_Get_Device_ID_List_SizeA          = modcfgmgr32.NewProc("CM_Get_Device_ID_List_SizeA")
 	procCM_Get_Device_ID_ListA               = modcfgmgr32.NewProc("CM_Get_Device_ID_ListA")
 	procCM_Locate_DevNodeW                   = modcfgmgr32.NewProc("CM_Locate_DevNodeW")
 	procCM_Get_DevNode_PropertyW             = modcfgmgr32.NewProc("CM_Get_DevNode_PropertyW")
+	procCM_Get_DevNode_Status                = modcfgmgr32.NewProc("CM_Get_DevNode_Status")
 	procNtCreateFile                         = modntdll.NewProc("NtCreateFile")
 	procNtSetInformationFile                 = modntdll.NewProc("NtSetInformationFile")
 	procNtOpenDirectoryObject                = modntdll.NewProc("NtOpenDirectoryObject")
 	procNtQueryDirectoryObject               = modntdll.NewProc("NtQueryDirectoryObject")
 	procRtlNtStatusToDosError                = modntdll.NewProc("RtlNtStatusToDosError")
+	procGetVirtualDiskInformation            = modvirtdisk.NewProc("GetVirtualDiskInformation")
+	procCompactVirtualDisk                   = modvirtdisk.NewProc("CompactVirtualDisk")
+	procRmStartSession                       = modrstrtmgr.NewProc("RmStartSession")
+	procRmEndSession                         = modrstrtmgr.NewProc("RmEndSession")
+	procRmRegisterResources                  = modrstrtmgr.NewProc("RmRegisterResources")
+	procRmGetList                            = modrstrtmgr.NewProc("RmGetList")
 )
 
 func SetJobCompartmentId(handle windows.Handle, compartmentId uint32) (win32Err error) {
@@ -74,6 +85,18 @@ func SetJobCompartmentId(handle windows.Handle, compartmentId uint32) (win32Err
 	return
 }
 
+func GetQueuedCompletionStatus(cphandle windows.Handle, qty *uint32, key *uintptr, overlapped **windows.Overlapped, timeout uint32) (err error) {
+	r1, _, e1 := syscall.Syscall6(procGetQueuedCompletionStatus.Addr(), 5, uintptr(cphandle), uintptr(unsafe.Pointer(qty)), uintptr(unsafe.Pointer(key)), uintptr(unsafe.Pointer(overlapped)), uintptr(timeout), 0)
+	if r1 == 0 {
+		if e1 != 0 {
+			err = errnoErr(e1)
+		} else {
+			err = syscall.EINVAL
+		}
+	}
+	return
+}
+
 func IsProcessInJob(procHandle windows.Handle, jobHandle windows.Handle, result *bool) (err error) {
 	r1, _, e1 := syscall.Syscall(procIsProcessInJob.Addr(), 3, uintptr(procHandle), uintptr(jobHandle), uintptr(unsafe.Pointer(result)))
 	if r1 == 0 {
@@ -130,18 +153,6 @@ func SetIoRateControlInformationJobObject(jobHandle windows.Handle, ioRateContro
 	return
 }
 
-func GetQueuedCompletionStatus(cphandle windows.Handle, qty *uint32, key *uintptr, overlapped **windows.Overlapped, timeout uint32) (err error) {
-	r1, _, e1 := syscall.Syscall6(procGetQueuedCompletionStatus.Addr(), 5, uintptr(cphandle), uintptr(unsafe.Pointer(qty)), uintptr(unsafe.Pointer(key)), uintptr(unsafe.Pointer(overlapped)), uintptr(timeout), 0)
-	if r1 == 0 {
-		if e1 != 0 {
-			err = errnoErr(e1)
-		} else {
-			err = syscall.EINVAL
-		}
-	}
-	return
-}
-
 func SearchPath(lpPath *uint16, lpFileName *uint16, lpExtension *uint16, nBufferLength uint32, lpBuffer *uint16, lpFilePath **uint16) (size uint32, err error) {
 	r0, _, e1 := syscall.Syscall6(procSearchPathW.Addr(), 6, uintptr(unsafe.Pointer(lpPath)), uintptr(unsafe.Pointer(lpFileName)), uintptr(unsafe.Pointer(lpExtension)), uintptr(nBufferLength), uintptr(unsafe.Pointer(lpBuffer)), uintptr(unsafe.Pointer(lpFilePath)))
 	size = uint32(r0)
@@ -190,6 +201,30 @@ func LocalFree(ptr uintptr) {
 	return
 }
 
+func GlobalMemoryStatusEx(buffer *MemoryStatusEx) (err error) {
+	r1, _, e1 := syscall.Syscall(procGlobalMemoryStatusEx.Addr(), 1, uintptr(unsafe.Pointer(buffer)), 0, 0)
+	if r1 == 0 {
+		if e1 != 0 {
+			err = errnoErr(e1)
+		} else {
+			err = syscall.EINVAL
+		}
+	}
+	return
+}
+
+func GetSystemTimes(idleTime *windows.Filetime, kernelTime *windows.Filetime, userTime *windows.Filetime) (err error) {
+	r1, _, e1 := syscall.Syscall(procGetSystemTimes.Addr(), 3, uintptr(unsafe.Pointer(idleTime)), uintptr(unsafe.Pointer(kernelTime)), uintptr(unsafe.Pointer(userTime)))
+	if r1 == 0 {
+		if e1 != 0 {
+			err = errnoErr(e1)
+		} else {
+			err = syscall.EINVAL
+		}
+	}
+	return
+}
+
 func GetActiveProcessorCount(groupNumber uint16) (amount uint32) {
 	r0, _, _ := syscall.Syscall(procGetActiveProcessorCount.Addr(), 1, uintptr(groupNumber), 0, 0)
 	amount = uint32(r0)
@@ -249,6 +284,17 @@ func CMGetDevNodeProperty(dnDevInst uint32, propertyKey *DevPropKey, propertyTyp
 	return
 }
 
+func CMGetDevNodeStatus(pulStatus *uint32, pulProblemNumber *uint32, dnDevInst uint32, uFlags uint32) (hr error) {
+	r0, _, _ := syscall.Syscall6(procCM_Get_DevNode_Status.Addr(), 4, uintptr(unsafe.Pointer(pulStatus)), uintptr(unsafe.Pointer(pulProblemNumber)), uintptr(dnDevInst), uintptr(uFlags), 0, 0)
+	if int32(r0) < 0 {
+		if r0&0x1fff0000 == 0x00070000 {
+			r0 &= 0xffff
+		}
+		hr = syscall.Errno(r0)
+	}
+	return
+}
+
 func NtCreateFile(handle *uintptr, accessMask uint32, oa *ObjectAttributes, iosb *IOStatusBlock, allocationSize *uint64, fileAttributes uint32, shareAccess uint32, createDisposition uint32, createOptions uint32, eaBuffer *byte, eaLength uint32) (status uint32) {
 	r0, _, _ := syscall.Syscall12(procNtCreateFile.Addr(), 11, uintptr(unsafe.Pointer(handle)), uintptr(accessMask), uintptr(unsafe.Pointer(oa)), uintptr(unsafe.Pointer(iosb)), uintptr(unsafe.Pointer(allocationSize)), uintptr(fileAttributes), uintptr(shareAccess), uintptr(createDisposition), uintptr(createOptions), uintptr(unsafe.Pointer(eaBuffer)), uintptr(eaLength), 0)
 	status = uint32(r0)
@@ -292,3 +338,51 @@ func RtlNtStatusToDosError(status uint32) (winerr error) {
 	}
 	return
 }
+
+func GetVirtualDiskInformation(handle windows.Handle, virtualDiskInfoSize *uint32, virtualDiskInfo *byte, sizeUsed *uint32) (win32Err error) {
+	r0, _, _ := syscall.Syscall6(procGetVirtualDiskInformation.Addr(), 4, uintptr(handle), uintptr(unsafe.Pointer(virtualDiskInfoSize)), uintptr(unsafe.Pointer(virtualDiskInfo)), uintptr(unsafe.Pointer(sizeUsed)), 0, 0)
+	if r0 != 0 {
+		win32Err = syscall.Errno(r0)
+	}
+	return
+}
+
+func CompactVirtualDisk(handle windows.Handle, flags uint32, parameters *CompactVirtualDiskParameters, overlapped *syscall.Overlapped) (win32Err error) {
+	r0, _, _ := syscall.Syscall6(procCompactVirtualDisk.Addr(), 4, uintptr(handle), uintptr(flags), uintptr(unsafe.Pointer(parameters)), uintptr(unsafe.Pointer(overlapped)), 0, 0)
+	if r0 != 0 {
+		win32Err = syscall.Errno(r0)
+	}
+	return
+}
+
+func RmStartSession(session *uint32, sessionKey *uint16) (win32Err error) {
+	r0, _, _ := syscall.Syscall(procRmStartSession.Addr(), 2, uintptr(unsafe.Pointer(session)), uintptr(unsafe.Pointer(sessionKey)), 0)
+	if r0 != 0 {
+		win32Err = syscall.Errno(r0)
+	}
+	return
+}
+
+func RmEndSession(session uint32) (win32Err error) {
+	r0, _, _ := syscall.Syscall(procRmEndSession.Addr(), 1, uintptr(session), 0, 0)
+	if r0 != 0 {
+		win32Err = syscall.Errno(r0)
+	}
+	return
+}
+
+func RmRegisterResources(session uint32, numFiles uint32, fileNames **uint16) (win32Err error) {
+	r0, _, _ := syscall.Syscall9(procRmRegisterResources.Addr(), 7, uintptr(session), uintptr(numFiles), uintptr(unsafe.Pointer(fileNames)), 0, 0, 0, 0, 0, 0)
+	if r0 != 0 {
+		win32Err = syscall.Errno(r0)
+	}
+	return
+}
+
+func RmGetList(session uint32, procInfoNeeded *uint32, procInfo *uint32, processInfo *RM_PROCESS_INFO, rebootReasons *uint32) (win32Err error) {
+	r0, _, _ := syscall.Syscall6(procRmGetList.Addr(), 5, uintptr(session), uintptr(unsafe.Pointer(procInfoNeeded)), uintptr(unsafe.Pointer(procInfo)), uintptr(unsafe.Pointer(processInfo)), uintptr(unsafe.Pointer(rebootReasons)), 0)
+	if r0 != 0 {
+		win32Err = syscall.Errno(r0)
+	}
+	return
+}
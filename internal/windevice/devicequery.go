@@ -12,6 +12,7 @@ import (
 
 const (
 	_CM_GETIDLIST_FILTER_BUSRELATIONS uint32 = 0x00000020
+	_CM_GETIDLIST_FILTER_ENUMERATOR   uint32 = 0x00000001
 
 	_CM_LOCATE_DEVNODE_NORMAL uint32 = 0x00000000
 
@@ -91,6 +92,30 @@ func convertFirstNullTerminatedValueToString(buf []uint16) (string, error) {
 	return converted[:zerosIndex], nil
 }
 
+// GetDeviceIDsByEnumerator returns the instance IDs of every device node
+// currently reported by the given enumerator (for example "PCI"), which is
+// the starting point for discovering devices eligible for VPCI assignment to
+// a UVM.
+func GetDeviceIDsByEnumerator(enumerator string) ([]string, error) {
+	pszFilter := append([]byte(enumerator), 0)
+	return getDeviceIDList(&pszFilter[0], _CM_GETIDLIST_FILTER_ENUMERATOR)
+}
+
+// IsDeviceStarted returns whether the device identified by id currently has
+// a driver loaded and running. Devices that have been dismounted in
+// preparation for assignment to a UVM will not be started.
+func IsDeviceStarted(id string) (bool, error) {
+	var devNodeInst uint32
+	if err := winapi.CMLocateDevNode(&devNodeInst, id, _CM_LOCATE_DEVNODE_NORMAL); err != nil {
+		return false, errors.Wrapf(err, "failed to locate device node for %s", id)
+	}
+	var status, problemNumber uint32
+	if err := winapi.CMGetDevNodeStatus(&status, &problemNumber, devNodeInst, 0); err != nil {
+		return false, errors.Wrapf(err, "failed to get device node status for %s", id)
+	}
+	return status&winapi.DN_STARTED != 0, nil
+}
+
 func GetChildrenFromInstanceIDs(parentIDs []string) ([]string, error) {
 	var result []string
 	for _, id := range parentIDs {
@@ -0,0 +1,32 @@
+package osversion
+
+// Capabilities summarizes the HCS schema version and platform features
+// available on the current build of Windows, so callers can branch on what
+// the host supports instead of comparing Build against scattered magic
+// numbers.
+type Capabilities struct {
+	// SchemaV21 is true if the host supports the 2.1 HCS schema, introduced
+	// in RS5. Hosts that don't support it fall back to the 1.0 schema.
+	SchemaV21 bool
+	// KernelDirect is true if the host supports starting an LCOW uVM directly
+	// from a Linux kernel binary rather than through a UEFI bootloader.
+	KernelDirect bool
+	// ColdDiscardHint is true if the host supports the ColdDiscardHint memory
+	// setting, which lets HCS discard cold pages more aggressively.
+	ColdDiscardHint bool
+	// VSMBFileMappingRestricted is true if the host's VSMB implementation
+	// supports restricting a share to a single file (and the set of allowed
+	// names), rather than exposing the whole host directory.
+	VSMBFileMappingRestricted bool
+}
+
+// GetCapabilities returns the Capabilities of the running host.
+func GetCapabilities() Capabilities {
+	build := Get().Build
+	return Capabilities{
+		SchemaV21:                 build >= RS5,
+		KernelDirect:              build >= 18286,
+		ColdDiscardHint:           build >= 18967,
+		VSMBFileMappingRestricted: build >= V19H1 && build <= V20H2,
+	}
+}